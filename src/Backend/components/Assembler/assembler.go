@@ -0,0 +1,475 @@
+package assembler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"Backend/utils"
+)
+
+// Maximum number of nested macro expansions, it stops recursive macros
+const maxMacroDepth = 16
+
+// Kind of operand that every instruction expects
+const (
+	noOperand = iota
+	addressOperand
+	targetOperand
+)
+
+// Instruction set of the Processing Elements
+var instructionSet = map[string]int{
+	"INC":   noOperand,
+	"FENCE": noOperand,
+	"READ":  addressOperand,
+	"WRITE": addressOperand,
+	"LL":    addressOperand,
+	"SC":    addressOperand,
+	"BEQZ":  targetOperand,
+}
+
+// Position and description of a problem in a program
+type Diagnostic struct {
+	Line    int    `json:"Line"`
+	Column  int    `json:"Column"`
+	Message string `json:"Message"`
+}
+
+// Error returned when a program can't be assembled, it keeps every diagnostic found
+type Error struct {
+	Name        string       `json:"Name"`
+	Diagnostics []Diagnostic `json:"Diagnostics"`
+}
+
+// Function to write every diagnostic as "name:line:column: message"
+func (e *Error) Error() string {
+	lines := []string{}
+	for _, diagnostic := range e.Diagnostics {
+		lines = append(lines, fmt.Sprintf("%s:%d:%d: %s", e.Name, diagnostic.Line, diagnostic.Column, diagnostic.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Word of the source with the position where it starts
+type token struct {
+	Text   string
+	Line   int
+	Column int
+}
+
+// Source line already split in words, macros keep their body as statements
+type statement struct {
+	Tokens []token
+	Macro  string // Name of the macro that produced the statement, if any
+}
+
+// Macro defined with .macro NAME PARAM... and closed with .endm
+type macro struct {
+	Params []string
+	Body   []statement
+	Line   int
+}
+
+// Instruction waiting for its operand to be resolved
+type pendingInstruction struct {
+	Mnemonic token
+	Operand  *token
+	Macro    string
+}
+
+// State of the assembler while it reads a program
+type assembler struct {
+	errors       *Error
+	constants    map[string]int
+	constantPos  map[string]token
+	labels       map[string]int
+	macros       map[string]*macro
+	instructions []pendingInstruction
+}
+
+// AssembleFile reads a program from a text file and assembles it.
+func AssembleFile(filename string) ([]string, error) {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Assemble(filepath.Base(filename), string(source))
+}
+
+// Assemble translates a program into the instructions executed by a Processing Element.
+//
+// Besides the instructions, a program can use:
+//   - comments starting with ';', '#' or '//', and blank lines
+//   - constants: .equ NAME VALUE
+//   - labels: NAME: (a BEQZ can jump to them)
+//   - macros: .macro NAME PARAM... / body / .endm, used as NAME ARG...
+//
+// Every problem found is reported with its line and column.
+func Assemble(name string, source string) ([]string, error) {
+	a := &assembler{
+		errors:      &Error{Name: name},
+		constants:   map[string]int{},
+		constantPos: map[string]token{},
+		labels:      map[string]int{},
+		macros:      map[string]*macro{},
+	}
+
+	// First pass: directives, labels and macro expansion
+	statements := []statement{}
+	for number, line := range strings.Split(source, "\n") {
+		tokens := tokenize(line, number+1)
+		if len(tokens) > 0 {
+			statements = append(statements, statement{Tokens: tokens})
+		}
+	}
+	a.readStatements(statements)
+
+	// Second pass: resolve the operands once every label and constant is known
+	program := []string{}
+	for _, instruction := range a.instructions {
+		if text, ok := a.resolve(instruction, len(a.instructions)); ok {
+			program = append(program, text)
+		}
+	}
+
+	if len(a.errors.Diagnostics) > 0 {
+		// Report the problems in the order they appear in the source
+		sort.SliceStable(a.errors.Diagnostics, func(i, j int) bool {
+			first, second := a.errors.Diagnostics[i], a.errors.Diagnostics[j]
+			return first.Line < second.Line || (first.Line == second.Line && first.Column < second.Column)
+		})
+		return nil, a.errors
+	}
+	return program, nil
+}
+
+// Function to report a problem at the position of a token
+func (a *assembler) errorf(at token, macroName string, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if macroName != "" {
+		message += fmt.Sprintf(" (in macro %s)", macroName)
+	}
+	a.errors.Diagnostics = append(a.errors.Diagnostics, Diagnostic{Line: at.Line, Column: at.Column, Message: message})
+}
+
+// Function to split a line in words, without its comment
+func tokenize(line string, number int) []token {
+	for _, marker := range []string{";", "#", "//"} {
+		if index := strings.Index(line, marker); index >= 0 {
+			line = line[:index]
+		}
+	}
+	tokens := []token{}
+	start := -1
+	for i, char := range line + " " {
+		separator := char == ' ' || char == '\t' || char == ',' || char == '\r'
+		if separator && start >= 0 {
+			tokens = append(tokens, token{Text: line[start:i], Line: number, Column: start + 1})
+			start = -1
+		} else if !separator && start < 0 {
+			start = i
+		}
+	}
+	return tokens
+}
+
+// Function to process a list of statements, macros are expanded in place
+func (a *assembler) readStatements(statements []statement) {
+	for i := 0; i < len(statements); i++ {
+		current := statements[i]
+		tokens := current.Tokens
+
+		// Labels end with ':' and can be followed by an instruction
+		for len(tokens) > 0 && strings.HasSuffix(tokens[0].Text, ":") {
+			label := tokens[0]
+			label.Text = strings.TrimSuffix(label.Text, ":")
+			a.defineLabel(label, current.Macro)
+			tokens = tokens[1:]
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		first := tokens[0]
+		switch strings.ToLower(first.Text) {
+		case ".equ":
+			a.defineConstant(tokens, current.Macro)
+
+		case ".macro":
+			// Collect the body until .endm
+			end := i + 1
+			for end < len(statements) && strings.ToLower(statements[end].Tokens[0].Text) != ".endm" {
+				end++
+			}
+			if end == len(statements) {
+				a.errorf(first, current.Macro, "missing .endm for this .macro")
+				return
+			}
+			a.defineMacro(tokens, statements[i+1:end], current.Macro)
+			i = end
+
+		case ".endm":
+			a.errorf(first, current.Macro, ".endm without a .macro")
+
+		default:
+			if definition, found := a.macros[strings.ToUpper(first.Text)]; found {
+				a.expandMacro(definition, tokens, current.Macro, 1)
+				continue
+			}
+			a.addInstruction(tokens, current.Macro)
+		}
+	}
+}
+
+// Function to remember the position of a label
+func (a *assembler) defineLabel(label token, macroName string) {
+	name := strings.ToUpper(label.Text)
+	if macroName != "" {
+		a.errorf(label, macroName, "labels can't be defined inside a macro")
+		return
+	}
+	if !isIdentifier(name) {
+		a.errorf(label, macroName, "invalid label name %q", label.Text)
+		return
+	}
+	if _, found := a.labels[name]; found {
+		a.errorf(label, macroName, "the label %q is defined twice", label.Text)
+		return
+	}
+	a.labels[name] = len(a.instructions)
+}
+
+// Function to define a constant: .equ NAME VALUE
+func (a *assembler) defineConstant(tokens []token, macroName string) {
+	if len(tokens) != 3 {
+		a.errorf(tokens[0], macroName, ".equ expects a name and a value, like '.equ FLAG 3'")
+		return
+	}
+	name := strings.ToUpper(tokens[1].Text)
+	if !isIdentifier(name) {
+		a.errorf(tokens[1], macroName, "invalid constant name %q", tokens[1].Text)
+		return
+	}
+	if _, found := a.constants[name]; found {
+		a.errorf(tokens[1], macroName, "the constant %q is defined twice", tokens[1].Text)
+		return
+	}
+	value, ok := a.number(tokens[2])
+	if !ok {
+		a.errorf(tokens[2], macroName, "invalid value %q for the constant %s", tokens[2].Text, tokens[1].Text)
+		return
+	}
+	a.constants[name] = value
+	a.constantPos[name] = tokens[1]
+}
+
+// Function to define a macro: .macro NAME PARAM...
+func (a *assembler) defineMacro(tokens []token, body []statement, macroName string) {
+	if len(tokens) < 2 {
+		a.errorf(tokens[0], macroName, ".macro expects a name")
+		return
+	}
+	name := strings.ToUpper(tokens[1].Text)
+	if !isIdentifier(name) {
+		a.errorf(tokens[1], macroName, "invalid macro name %q", tokens[1].Text)
+		return
+	}
+	if _, found := instructionSet[name]; found {
+		a.errorf(tokens[1], macroName, "the macro %q has the name of an instruction", tokens[1].Text)
+		return
+	}
+	if _, found := a.macros[name]; found {
+		a.errorf(tokens[1], macroName, "the macro %q is defined twice", tokens[1].Text)
+		return
+	}
+	params := []string{}
+	for _, param := range tokens[2:] {
+		params = append(params, strings.ToUpper(param.Text))
+	}
+	a.macros[name] = &macro{Params: params, Body: body, Line: tokens[0].Line}
+}
+
+// Function to replace a macro call with its body, the parameters are replaced by the arguments
+func (a *assembler) expandMacro(definition *macro, call []token, macroName string, depth int) {
+	name := strings.ToUpper(call[0].Text)
+	if depth > maxMacroDepth {
+		a.errorf(call[0], macroName, "the macro %s expands too deep, is it recursive?", name)
+		return
+	}
+	args := call[1:]
+	if len(args) != len(definition.Params) {
+		a.errorf(call[0], macroName, "the macro %s expects %d arguments, got %d", name, len(definition.Params), len(args))
+		return
+	}
+
+	// The expanded statements point to the line of the call
+	expanded := []statement{}
+	for _, line := range definition.Body {
+		tokens := []token{}
+		for _, word := range line.Tokens {
+			replaced := token{Text: word.Text, Line: call[0].Line, Column: call[0].Column}
+			for p, param := range definition.Params {
+				if strings.ToUpper(word.Text) == param {
+					replaced = args[p]
+				}
+			}
+			tokens = append(tokens, replaced)
+		}
+		expanded = append(expanded, statement{Tokens: tokens, Macro: name})
+	}
+
+	// Nested macro calls are expanded with a deeper level
+	for _, line := range expanded {
+		if nested, found := a.macros[strings.ToUpper(line.Tokens[0].Text)]; found {
+			a.expandMacro(nested, line.Tokens, name, depth+1)
+			continue
+		}
+		a.readStatements([]statement{line})
+	}
+}
+
+// Function to check the shape of an instruction, its operand is resolved later
+func (a *assembler) addInstruction(tokens []token, macroName string) {
+	mnemonic := tokens[0]
+	kind, found := instructionSet[strings.ToUpper(mnemonic.Text)]
+	if !found {
+		message := fmt.Sprintf("unknown instruction %q", mnemonic.Text)
+		if suggestion := closestInstruction(mnemonic.Text); suggestion != "" {
+			message += fmt.Sprintf(", did you mean %s?", suggestion)
+		}
+		a.errorf(mnemonic, macroName, "%s", message)
+		return
+	}
+	if kind == noOperand && len(tokens) > 1 {
+		a.errorf(tokens[1], macroName, "%s doesn't take an operand", strings.ToUpper(mnemonic.Text))
+		return
+	}
+	if kind != noOperand && len(tokens) < 2 {
+		a.errorf(mnemonic, macroName, "%s expects an operand", strings.ToUpper(mnemonic.Text))
+		return
+	}
+	if len(tokens) > 2 {
+		a.errorf(tokens[2], macroName, "unexpected %q after the operand", tokens[2].Text)
+		return
+	}
+	instruction := pendingInstruction{Mnemonic: mnemonic, Macro: macroName}
+	if len(tokens) == 2 {
+		operand := tokens[1]
+		instruction.Operand = &operand
+	}
+	a.instructions = append(a.instructions, instruction)
+}
+
+// Function to get the final text of an instruction with its operand as a number
+func (a *assembler) resolve(instruction pendingInstruction, programSize int) (string, bool) {
+	mnemonic := strings.ToUpper(instruction.Mnemonic.Text)
+	switch instructionSet[mnemonic] {
+	case addressOperand:
+		address, ok := a.value(*instruction.Operand, false)
+		if !ok {
+			a.errorf(*instruction.Operand, instruction.Macro, "invalid address %q, expected a number or a constant", instruction.Operand.Text)
+			return "", false
+		}
+		if address < 0 || address >= utils.MemorySize {
+			a.errorf(*instruction.Operand, instruction.Macro, "the address %d is out of range, the memory has the addresses 0 to %d", address, utils.MemorySize-1)
+			return "", false
+		}
+		return fmt.Sprintf("%s %d", mnemonic, address), true
+
+	case targetOperand:
+		target, ok := a.value(*instruction.Operand, true)
+		if !ok {
+			a.errorf(*instruction.Operand, instruction.Macro, "unknown label %q", instruction.Operand.Text)
+			return "", false
+		}
+		if target < 0 || target > programSize {
+			a.errorf(*instruction.Operand, instruction.Macro, "the jump target %d is outside the program (0 to %d)", target, programSize)
+			return "", false
+		}
+		return fmt.Sprintf("%s %d", mnemonic, target), true
+	}
+	return mnemonic, true
+}
+
+// Function to get the value of an operand: a number, a constant or, for jumps, a label
+func (a *assembler) value(operand token, allowLabels bool) (int, bool) {
+	if value, ok := a.number(operand); ok {
+		return value, true
+	}
+	name := strings.ToUpper(operand.Text)
+	if value, found := a.constants[name]; found {
+		return value, true
+	}
+	if allowLabels {
+		if value, found := a.labels[name]; found {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// Function to read a decimal or hexadecimal (0x) number
+func (a *assembler) number(operand token) (int, bool) {
+	value, err := strconv.ParseInt(operand.Text, 0, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int(value), true
+}
+
+// Function to know if a name can be used for a label, a constant or a macro
+func isIdentifier(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, char := range name {
+		if !(char == '_' || (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// Function to find the instruction closest to a typo, if it is close enough
+func closestInstruction(text string) string {
+	mnemonics := []string{}
+	for mnemonic := range instructionSet {
+		mnemonics = append(mnemonics, mnemonic)
+	}
+	sort.Strings(mnemonics)
+
+	best := ""
+	bestDistance := 3
+	for _, mnemonic := range mnemonics {
+		if distance := editDistance(strings.ToUpper(text), mnemonic); distance < bestDistance {
+			best = mnemonic
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// Function to count the edits needed to turn a string into another one (Levenshtein distance)
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Exit statuses of the commands
+const (
+	ExitSuccess = 0 // The command finished and the run completed
+	ExitFailure = 1 // The system couldn't start or the run didn't complete
+	ExitUsage   = 2 // Invalid flags, configuration file or command
+)
+
+// Command of the command line
+type command struct {
+	Name        string
+	Description string
+	Run         func(args []string, stdout io.Writer, stderr io.Writer) int
+}
+
+// Function to list the commands, without arguments the program starts the REST server
+func commands() []command {
+	return []command{
+		{Name: "serve", Description: "Start the REST server with the console, the default without a command", Run: serveCommand},
+		{Name: "run", Description: "Initialize a system, run it to completion and print the results and the final state", Run: runCommand},
+		{Name: "console", Description: "Open the interactive console without the REST server", Run: consoleMain},
+		{Name: "dashboard", Description: "Show a system in a full screen view that steps with the keyboard, it takes the flags of run", Run: dashboardCommand},
+		{Name: "verify", Description: "Explore every reachable state of a protocol and print a counterexample for any violation", Run: verifyCommand},
+	}
+}
+
+// Main runs the command named by the first argument and returns the exit status of the program.
+func Main(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return ExitUsage
+		}
+		return ExitSuccess
+	}
+	for _, command := range commands() {
+		if command.Name == args[0] {
+			return command.Run(args[1:], stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+	usage(stderr)
+	return ExitUsage
+}
+
+// Function to print the available commands
+func usage(writer io.Writer) {
+	fmt.Fprintf(writer, "Usage: Backend [command] [flags]\n\nWithout a command the serve command starts the REST server.\n\nCommands:\n")
+	for _, command := range commands() {
+		fmt.Fprintf(writer, "  %-8s %s\n", command.Name, command.Description)
+	}
+	fmt.Fprintf(writer, "\nRun 'Backend <command> -h' to see the flags of a command.\n")
+}
+
+// Function to split a comma separated flag, an empty flag gives no values
+func splitList(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	values := []string{}
+	for _, value := range strings.Split(text, ",") {
+		values = append(values, strings.TrimSpace(value))
+	}
+	return values
+}
@@ -0,0 +1,659 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"Backend/components/MultiprocessingSystem"
+)
+
+// Interactive console that drives a system from a terminal
+type Console struct {
+	output       io.Writer
+	spec         RunSpec    // Settings of the next init
+	mutex        sync.Mutex // Taken by every command and by every step of a background run
+	system       *MultiprocessingSystem.MultiprocessingSystem
+	logDirectory string     // Temporary folder of the log files of the system
+	generator    *rand.Rand // Scheduler of run, seeded by init
+	history      []string
+	pause        chan struct{} // Closed to stop the background run
+	stopped      chan struct{} // Closed when the background run returns, nil before the first run
+	OnQuit       func()        // Called when the console ends
+}
+
+// Command of the console
+type consoleCommand struct {
+	Name        string
+	Usage       string
+	Description string
+	Unlocked    bool // The command doesn't touch the system, it runs while a background run is stepping
+	Run         func(console *Console, args []string) error
+}
+
+// Function to list the commands of the console
+func consoleCommands() []consoleCommand {
+	return []consoleCommand{
+		{Name: "help", Usage: "help [command]", Description: "List the commands or show the usage of one", Unlocked: true, Run: (*Console).help},
+		{Name: "init", Usage: "init [MESI|MOESI] [key=value ...]", Description: "Start a new system, keys: protocol, consistency, cores, lines, ways, instructions, workload, seed, memory", Run: (*Console).initialize},
+		{Name: "load", Usage: "load <pe> <file|saved program|->", Description: "Give a program to a PE for the next init, - goes back to a generated program", Run: (*Console).load},
+		{Name: "step", Usage: "step <pe> [count]", Description: "Execute the next instructions of a PE", Run: (*Console).step},
+		{Name: "drain", Usage: "drain <pe> [index]", Description: "Send a store of the store buffer of a PE to its cache", Run: (*Console).drain},
+		{Name: "rewind", Usage: "rewind <step>", Description: "Go back to the state after a step of the history and continue from there", Run: (*Console).rewind},
+		{Name: "run", Usage: "run [steps]", Description: "Run the scheduler in the background until every PE finishes or pause, or run some steps", Run: (*Console).run},
+		{Name: "pause", Usage: "pause", Description: "Stop the background run", Unlocked: true, Run: (*Console).pauseRun},
+		{Name: "pes", Usage: "pes", Description: "Print the register, the status and the next instruction of every PE", Run: (*Console).printProcessingElements},
+		{Name: "program", Usage: "program <pe>", Description: "Print the program of a PE with its next instruction", Run: (*Console).printProgram},
+		{Name: "caches", Usage: "caches [cc]", Description: "Print the blocks of the caches", Run: (*Console).printCaches},
+		{Name: "memory", Usage: "memory", Description: "Print the Main Memory and the value of every address as the processors see it", Run: (*Console).printMemory},
+		{Name: "bus", Usage: "bus [count]", Description: "Print the last bus transactions, 20 by default", Run: (*Console).printBus},
+		{Name: "metrics", Usage: "metrics", Description: "Print the metrics of the execution", Run: (*Console).printMetrics},
+		{Name: "set", Usage: "set cache <cc> <block> <address> <value> [state] | set memory <address> <value>", Description: "Change a cache block or a memory word", Run: (*Console).set},
+		{Name: "history", Usage: "history", Description: "List the commands entered, !n repeats the command n and !! the last one", Unlocked: true, Run: (*Console).printHistory},
+		{Name: "stop", Usage: "stop", Description: "Stop the system", Run: (*Console).stop},
+		{Name: "quit", Usage: "quit", Description: "Leave the console, C and exit do the same", Unlocked: true},
+	}
+}
+
+// NewConsole creates a console that reads commands from Run and writes to output.
+func NewConsole(output io.Writer) *Console {
+	return &Console{output: output, spec: DefaultRunSpec()}
+}
+
+// Run reads and executes commands until quit or the end of the input.
+func (console *Console) Run(input io.Reader) {
+	scanner := bufio.NewScanner(input)
+	fmt.Fprintf(console.output, "Type 'help' to list the commands.\n")
+	for {
+		fmt.Fprintf(console.output, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(console.output)
+			break
+		}
+		if console.Execute(scanner.Text()) {
+			break
+		}
+	}
+	console.Close()
+}
+
+// Execute runs a command line and returns true when it asks to leave the console.
+func (console *Console) Execute(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+
+	// Repeat a command of the history
+	if strings.HasPrefix(line, "!") {
+		number := len(console.history)
+		if line != "!!" {
+			parsed, err := strconv.Atoi(line[1:])
+			if err != nil {
+				fmt.Fprintf(console.output, "invalid history reference %q\n", line)
+				return false
+			}
+			number = parsed
+		}
+		if number < 1 || number > len(console.history) {
+			fmt.Fprintf(console.output, "there is no command %d in the history\n", number)
+			return false
+		}
+		line = console.history[number-1]
+		fmt.Fprintf(console.output, "%s\n", line)
+	}
+	console.history = append(console.history, line)
+
+	words := strings.Fields(line)
+	name := strings.ToLower(words[0])
+	if name == "c" || name == "exit" || name == "quit" {
+		return true
+	}
+	for _, command := range consoleCommands() {
+		if command.Name != name {
+			continue
+		}
+		if !command.Unlocked {
+			console.mutex.Lock()
+			defer console.mutex.Unlock()
+		}
+		if err := command.Run(console, words[1:]); err != nil {
+			fmt.Fprintf(console.output, "%s: %v\n", name, err)
+		}
+		return false
+	}
+	fmt.Fprintf(console.output, "unknown command %q, type 'help' to list the commands\n", words[0])
+	return false
+}
+
+// Close pauses the background run, stops the system and calls OnQuit.
+func (console *Console) Close() {
+	console.pauseRun(nil)
+	console.mutex.Lock()
+	console.stop(nil)
+	console.mutex.Unlock()
+	if console.OnQuit != nil {
+		console.OnQuit()
+	}
+}
+
+// Function to know if the background run is stepping the system
+func (console *Console) running() bool {
+	if console.stopped == nil {
+		return false
+	}
+	select {
+	case <-console.stopped:
+		return false
+	default:
+		return true
+	}
+}
+
+// Function to get the system, the commands that change it also need the background run to be paused
+func (console *Console) current(changes bool) (*MultiprocessingSystem.MultiprocessingSystem, error) {
+	if console.system == nil {
+		return nil, fmt.Errorf("there is no system, use init")
+	}
+	if changes && console.running() {
+		return nil, fmt.Errorf("the system is running, use pause first")
+	}
+	return console.system, nil
+}
+
+// Function to read a number argument between 0 and limit-1
+func parseIndex(text string, what string, limit int) (int, error) {
+	value, err := strconv.Atoi(text)
+	if err != nil || value < 0 || value >= limit {
+		return 0, fmt.Errorf("invalid %s %q, expected 0 to %d", what, text, limit-1)
+	}
+	return value, nil
+}
+
+func (console *Console) help(args []string) error {
+	for _, command := range consoleCommands() {
+		if len(args) == 0 {
+			fmt.Fprintf(console.output, "  %-9s %s\n", command.Name, command.Description)
+		} else if command.Name == args[0] {
+			fmt.Fprintf(console.output, "%s\n  %s\n", command.Usage, command.Description)
+			return nil
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return nil
+}
+
+func (console *Console) initialize(args []string) error {
+	if console.running() {
+		return fmt.Errorf("the system is running, use pause first")
+	}
+	spec := console.spec
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			spec.Protocol = strings.ToUpper(arg)
+			continue
+		}
+		var err error
+		switch strings.ToLower(key) {
+		case "protocol":
+			spec.Protocol = strings.ToUpper(value)
+		case "consistency":
+			spec.Consistency = strings.ToUpper(value)
+		case "cores":
+			spec.Cores, err = strconv.Atoi(value)
+		case "lines":
+			spec.CacheLines, err = strconv.Atoi(value)
+		case "ways":
+			spec.CacheWays, err = strconv.Atoi(value)
+		case "instructions":
+			spec.Instructions, err = strconv.Atoi(value)
+		case "workload":
+			spec.Workload = value
+		case "seed":
+			spec.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "memory":
+			spec.Memory = nil
+			for _, text := range splitList(value) {
+				word, parseErr := strconv.ParseUint(text, 0, 32)
+				if parseErr != nil {
+					err = parseErr
+				}
+				spec.Memory = append(spec.Memory, uint32(word))
+			}
+		default:
+			return fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %q", key, value)
+		}
+	}
+
+	cfg, err := spec.Config()
+	if err != nil {
+		return err
+	}
+	logDirectory, err := os.MkdirTemp("", "console-logs")
+	if err != nil {
+		return err
+	}
+	cfg.LogDirectory = logDirectory
+	system, err := MultiprocessingSystem.StartWithConfig(cfg)
+	if err != nil {
+		os.RemoveAll(logDirectory)
+		return err
+	}
+
+	// The new system replaces the previous one, the settings are kept for the next init
+	console.stop(nil)
+	console.system, console.logDirectory, console.spec = system, logDirectory, spec
+	console.generator = rand.New(rand.NewSource(spec.Seed))
+	fmt.Fprintf(console.output, "Started a %s system with %d PEs under %s (seed %d)\n", cfg.Protocol, cfg.Cores, cfg.Consistency, spec.Seed)
+	return nil
+}
+
+func (console *Console) load(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: load <pe> <file|saved program|->")
+	}
+	pe, err := parseIndex(args[0], "PE", MultiprocessingSystem.MaxCores)
+	if err != nil {
+		return err
+	}
+	// A file is read, any other name is a saved program of the library
+	programs, library := console.spec.Programs, console.spec.Library
+	for len(programs) <= pe {
+		programs = append(programs, "")
+	}
+	for len(library) <= pe {
+		library = append(library, "")
+	}
+	programs[pe], library[pe] = "", ""
+	switch _, statErr := os.Stat(args[1]); {
+	case args[1] == "-":
+		fmt.Fprintf(console.output, "PE%d will run a generated program\n", pe)
+	case statErr == nil:
+		programs[pe] = args[1]
+		fmt.Fprintf(console.output, "PE%d will run the file %s after the next init\n", pe, args[1])
+	default:
+		library[pe] = args[1]
+		fmt.Fprintf(console.output, "PE%d will run the saved program %s after the next init\n", pe, args[1])
+	}
+	console.spec.Programs, console.spec.Library = programs, library
+	if pe >= console.spec.Cores {
+		console.spec.Cores = pe + 1
+	}
+	return nil
+}
+
+func (console *Console) step(args []string) error {
+	system, err := console.current(true)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: step <pe> [count]")
+	}
+	pe, err := parseIndex(args[0], "PE", len(system.ProcessingElements))
+	if err != nil {
+		return err
+	}
+	count := 1
+	if len(args) == 2 {
+		if count, err = strconv.Atoi(args[1]); err != nil || count < 1 {
+			return fmt.Errorf("invalid count %q", args[1])
+		}
+	}
+	for i := 0; i < count; i++ {
+		if err := system.StepAndWait(pe); err != nil {
+			return err
+		}
+	}
+	processingElement := system.ProcessingElements[pe]
+	fmt.Fprintf(console.output, "PE%d register %d (%s)\n", pe, processingElement.GetRegister(), processingElement.GetStatus())
+	return nil
+}
+
+func (console *Console) drain(args []string) error {
+	system, err := console.current(true)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: drain <pe> [index]")
+	}
+	pe, err := parseIndex(args[0], "PE", len(system.ProcessingElements))
+	if err != nil {
+		return err
+	}
+	index := 0
+	if len(args) == 2 {
+		if index, err = parseIndex(args[1], "store", len(system.ProcessingElements[pe].StoreBuffer)); err != nil {
+			return err
+		}
+	}
+	return system.DrainAndWait(pe, index)
+}
+
+func (console *Console) rewind(args []string) error {
+	system, err := console.current(true)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: rewind <step>")
+	}
+	step, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid step %q", args[0])
+	}
+	rewound, err := system.Rewind(step)
+	if err != nil {
+		// A system stopped by a failed rewind can't go on
+		if system.Stopped() {
+			os.RemoveAll(console.logDirectory)
+			console.system = nil
+		}
+		return err
+	}
+	console.system = rewound
+	fmt.Fprintf(console.output, "Back at step %d, the later steps were undone\n", step)
+	return nil
+}
+
+func (console *Console) run(args []string) error {
+	system, err := console.current(true)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("usage: run [steps]")
+	}
+
+	// A number of steps runs in the foreground
+	if len(args) == 1 {
+		steps, err := strconv.Atoi(args[0])
+		if err != nil || steps < 1 {
+			return fmt.Errorf("invalid number of steps %q", args[0])
+		}
+		for i := 0; i < steps; i++ {
+			busy, err := system.StepRandom(console.generator)
+			if err != nil {
+				return err
+			}
+			if !busy {
+				fmt.Fprintf(console.output, "Every PE finished after %d steps\n", i)
+				return nil
+			}
+		}
+		fmt.Fprintf(console.output, "Executed %d steps\n", steps)
+		return nil
+	}
+
+	// The background run takes the lock for every step, the commands run between the steps
+	pause, stopped := make(chan struct{}), make(chan struct{})
+	console.pause, console.stopped = pause, stopped
+	go func() {
+		defer close(stopped)
+		for steps := 0; ; steps++ {
+			select {
+			case <-pause:
+				fmt.Fprintf(console.output, "Paused after %d steps\n", steps)
+				return
+			default:
+			}
+			console.mutex.Lock()
+			busy, err := system.StepRandom(console.generator)
+			console.mutex.Unlock()
+			if err != nil {
+				fmt.Fprintf(console.output, "\nrun: %v\n", err)
+				return
+			}
+			if !busy {
+				fmt.Fprintf(console.output, "\nEvery PE finished after %d steps\n", steps)
+				return
+			}
+		}
+	}()
+	fmt.Fprintf(console.output, "Running, use pause to stop\n")
+	return nil
+}
+
+func (console *Console) pauseRun(args []string) error {
+	if !console.running() {
+		if args != nil {
+			return fmt.Errorf("the system is not running")
+		}
+		return nil
+	}
+	close(console.pause)
+	<-console.stopped
+	return nil
+}
+
+func (console *Console) printProcessingElements(args []string) error {
+	system, err := console.current(false)
+	if err != nil {
+		return err
+	}
+	for _, pe := range system.ProcessingElements {
+		next := "-"
+		if pc, _ := pe.GetInstructions(); pc < len(pe.Program) {
+			next = pe.Program[pc]
+		}
+		fmt.Fprintf(console.output, "  PE%d register %d (%s), next: %s, store buffer: %d\n", pe.ID, pe.GetRegister(), pe.GetStatus(), next, len(pe.GetStoreBuffer()))
+	}
+	return nil
+}
+
+func (console *Console) printProgram(args []string) error {
+	system, err := console.current(false)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: program <pe>")
+	}
+	id, err := parseIndex(args[0], "PE", len(system.ProcessingElements))
+	if err != nil {
+		return err
+	}
+	pe := system.ProcessingElements[id]
+	pc, _ := pe.GetInstructions()
+	for position, instruction := range pe.Program {
+		marker := " "
+		if position == pc {
+			marker = ">"
+		}
+		fmt.Fprintf(console.output, "%s %3d  %s\n", marker, position, instruction)
+	}
+	return nil
+}
+
+func (console *Console) printCaches(args []string) error {
+	system, err := console.current(false)
+	if err != nil {
+		return err
+	}
+	ccs := system.State().CCs
+	if len(args) == 1 {
+		id, err := parseIndex(args[0], "CC", len(ccs))
+		if err != nil {
+			return err
+		}
+		ccs = ccs[id : id+1]
+	}
+	writeCaches(console.output, ccs)
+	return nil
+}
+
+func (console *Console) printMemory(args []string) error {
+	system, err := console.current(false)
+	if err != nil {
+		return err
+	}
+	memory, coherent := []int{}, []int{}
+	for address := 0; address < MultiprocessingSystem.MemorySize; address++ {
+		memory = append(memory, int(system.MainMemory.Data[address]))
+		coherent = append(coherent, system.CoherentValue(address))
+	}
+	writeMemory(console.output, "Main Memory", memory)
+	writeMemory(console.output, "Coherent memory", coherent)
+	return nil
+}
+
+func (console *Console) printBus(args []string) error {
+	system, err := console.current(false)
+	if err != nil {
+		return err
+	}
+	count := 20
+	if len(args) == 1 {
+		if count, err = strconv.Atoi(args[0]); err != nil || count < 1 {
+			return fmt.Errorf("invalid count %q", args[0])
+		}
+	}
+	_, transactions := system.Recorder.Snapshot()
+	if len(transactions) > count {
+		transactions = transactions[len(transactions)-count:]
+	}
+	if len(transactions) == 0 {
+		fmt.Fprintf(console.output, "  There are no bus transactions\n")
+	}
+	for _, transaction := range transactions {
+		fmt.Fprintf(console.output, "  #%d CC%d %s/%s address %d data %d, remote %s -> %s, memory reads %d writes %d\n",
+			transaction.Sequence, transaction.Requester, transaction.Type, transaction.AR, transaction.Address, transaction.Data,
+			transaction.RemoteStatus, transaction.NewStatus, transaction.MemoryReads, transaction.MemoryWrites)
+	}
+	return nil
+}
+
+func (console *Console) printMetrics(args []string) error {
+	system, err := console.current(false)
+	if err != nil {
+		return err
+	}
+	writeResults(console.output, system.Results())
+	return nil
+}
+
+func (console *Console) set(args []string) error {
+	system, err := console.current(true)
+	if err != nil {
+		return err
+	}
+	values := []int{}
+	for _, arg := range args[1:] {
+		if value, err := strconv.Atoi(arg); err == nil {
+			values = append(values, value)
+		}
+	}
+	switch {
+	case len(args) == 3 && args[0] == "memory" && len(values) == 2:
+		address, err := parseIndex(args[1], "address", MultiprocessingSystem.MemorySize)
+		if err != nil {
+			return err
+		}
+		system.MainMemory.Data[address] = uint32(values[1])
+		system.Changed()
+		return nil
+
+	case (len(args) == 5 || len(args) == 6) && args[0] == "cache" && len(values) == 4:
+		id, err := parseIndex(args[1], "CC", len(system.CacheControllers))
+		if err != nil {
+			return err
+		}
+		cache := system.CacheControllers[id].Cache
+		block, err := parseIndex(args[2], "block", cache.Lines())
+		if err != nil {
+			return err
+		}
+		address, err := parseIndex(args[3], "address", MultiprocessingSystem.MemorySize)
+		if err != nil {
+			return err
+		}
+		// The address must stay in its set, the lookups only search there
+		if block/cache.Ways() != cache.SetOf(address) {
+			return fmt.Errorf("the address %d belongs to the set %d, the block %d is in the set %d", address, cache.SetOf(address), block, block/cache.Ways())
+		}
+		state := cache.GetState(block)
+		if len(args) == 6 {
+			state = strings.ToUpper(args[5])
+			if !strings.Contains("MOESI", state) || len(state) != 1 {
+				return fmt.Errorf("invalid state %q", args[5])
+			}
+		}
+		if other := cache.Find(address); other != -1 && other != block {
+			return fmt.Errorf("the address %d is already in the block %d", address, other)
+		}
+		cache.SetAddress(block, address)
+		cache.SetData(block, values[3])
+		cache.SetState(block, state)
+		system.Changed()
+		return nil
+	}
+	return fmt.Errorf("usage: set cache <cc> <block> <address> <value> [state] | set memory <address> <value>")
+}
+
+func (console *Console) printHistory(args []string) error {
+	for number, line := range console.history {
+		fmt.Fprintf(console.output, "  %3d  %s\n", number+1, line)
+	}
+	return nil
+}
+
+func (console *Console) stop(args []string) error {
+	if console.system == nil {
+		if args != nil {
+			return fmt.Errorf("there is no system")
+		}
+		return nil
+	}
+	if console.running() {
+		return fmt.Errorf("the system is running, use pause first")
+	}
+	console.system.Stop()
+	os.RemoveAll(console.logDirectory)
+	console.system = nil
+	return nil
+}
+
+// Function to run the console command, without the REST server
+func consoleMain(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("console", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	script := flags.String("script", "", "file with commands to execute before reading the standard input")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitSuccess
+		}
+		return ExitUsage
+	}
+	console := NewConsole(stdout)
+	if *script != "" {
+		file, err := os.Open(*script)
+		if err != nil {
+			fmt.Fprintf(stderr, "console: %v\n", err)
+			return ExitUsage
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fmt.Fprintf(stdout, "> %s\n", scanner.Text())
+			if console.Execute(scanner.Text()) {
+				console.Close()
+				return ExitSuccess
+			}
+		}
+	}
+	console.Run(os.Stdin)
+	return ExitSuccess
+}
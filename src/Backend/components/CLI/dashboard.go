@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Delays between the steps of a run, + and - move between them
+var dashboardDelays = []time.Duration{1000 * time.Millisecond, 500 * time.Millisecond, 200 * time.Millisecond, 100 * time.Millisecond, 50 * time.Millisecond, 10 * time.Millisecond}
+
+// Keyboard shortcuts of the dashboard
+const dashboardKeys = "space step  0-9 step PE  r run/pause  +/- speed  i restart  q quit"
+
+// Full screen view of a system that steps with the keyboard
+type Dashboard struct {
+	output    io.Writer
+	spec      RunSpec
+	cfg       MultiprocessingSystem.Config
+	system    *MultiprocessingSystem.MultiprocessingSystem
+	generator *rand.Rand // Scheduler of the steps, seeded with the seed of the run
+	steps     int
+	running   bool
+	speed     int    // Position in dashboardDelays
+	message   string // Result of the last key
+	Colors    bool   // Colour the coherence states
+	BusLines  int    // Last lines of the bus log on screen
+}
+
+// NewDashboard creates a dashboard for the system described by spec, Start initializes it.
+func NewDashboard(output io.Writer, spec RunSpec, cfg MultiprocessingSystem.Config) *Dashboard {
+	return &Dashboard{output: output, spec: spec, cfg: cfg, speed: 2, Colors: true, BusLines: 10}
+}
+
+// Start initializes the system again with the configuration of the dashboard.
+func (dashboard *Dashboard) Start() error {
+	dashboard.Stop()
+	system, err := MultiprocessingSystem.StartWithConfig(dashboard.cfg)
+	if err != nil {
+		return err
+	}
+	dashboard.system = system
+	dashboard.generator = rand.New(rand.NewSource(dashboard.spec.Seed))
+	dashboard.steps, dashboard.running = 0, false
+	dashboard.message = "Started"
+	return nil
+}
+
+// Stop stops the system of the dashboard.
+func (dashboard *Dashboard) Stop() {
+	if dashboard.system != nil {
+		dashboard.system.Stop()
+		dashboard.system = nil
+	}
+}
+
+// Key applies a keyboard shortcut and returns false when it asks to leave the dashboard.
+func (dashboard *Dashboard) Key(key byte) bool {
+	switch {
+	case key == 'q' || key == 'Q' || key == 3: // Ctrl+C arrives as a byte, the raw mode disables its SIGINT
+		return false
+	case key == ' ' || key == 's' || key == 'n':
+		dashboard.running = false
+		dashboard.step()
+	case key >= '0' && key <= '9':
+		pe := int(key - '0')
+		dashboard.running = false
+		if pe >= len(dashboard.system.ProcessingElements) {
+			dashboard.message = fmt.Sprintf("There is no PE%d", pe)
+		} else if err := dashboard.system.StepAndWait(pe); err != nil {
+			dashboard.message = err.Error()
+		} else {
+			dashboard.steps++
+			dashboard.message = fmt.Sprintf("Stepped PE%d", pe)
+		}
+	case key == 'r' || key == 'p':
+		dashboard.running = !dashboard.running
+		if dashboard.running {
+			dashboard.message = "Running"
+		} else {
+			dashboard.message = "Paused"
+		}
+	case key == '+' || key == '=':
+		if dashboard.speed < len(dashboardDelays)-1 {
+			dashboard.speed++
+		}
+	case key == '-' || key == '_':
+		if dashboard.speed > 0 {
+			dashboard.speed--
+		}
+	case key == 'i':
+		if err := dashboard.Start(); err != nil {
+			dashboard.message = err.Error()
+		}
+	}
+	return true
+}
+
+// Function to take a step of the seeded scheduler, the run pauses when the system finishes
+func (dashboard *Dashboard) step() {
+	busy, err := dashboard.system.StepRandom(dashboard.generator)
+	switch {
+	case err != nil:
+		dashboard.running = false
+		dashboard.message = err.Error()
+	case !busy:
+		dashboard.running = false
+		dashboard.message = "Every PE finished"
+	default:
+		dashboard.steps++
+		dashboard.message = fmt.Sprintf("Step %d", dashboard.steps)
+	}
+}
+
+// Render draws the whole screen with the current state of the system.
+func (dashboard *Dashboard) Render() {
+	builder := &strings.Builder{}
+	builder.WriteString(clearScreen)
+	status := "paused"
+	if dashboard.running {
+		status = fmt.Sprintf("running every %v", dashboardDelays[dashboard.speed])
+	}
+	fmt.Fprintf(builder, "%s%s %s, %d PEs, seed %d%s  steps %d, %s\n", dashboard.style(boldText), dashboard.cfg.Protocol, dashboard.cfg.Consistency, dashboard.cfg.Cores, dashboard.spec.Seed, dashboard.style(resetColor), dashboard.steps, status)
+	writeDashboardState(builder, dashboard.system.State(), dashboard.Colors, dashboard.BusLines)
+	fmt.Fprintf(builder, "\n%s\n%s%s%s\n", dashboard.message, dashboard.style(dimText), dashboardKeys, dashboard.style(resetColor))
+	io.WriteString(dashboard.output, builder.String())
+}
+
+// Function to get an escape sequence only when the colours are enabled
+func (dashboard *Dashboard) style(sequence string) string {
+	if !dashboard.Colors {
+		return ""
+	}
+	return sequence
+}
+
+// Function to write the sections of the dashboard: PEs, caches, memory and the last lines of the bus log
+func writeDashboardState(writer io.Writer, state utils.MultiprocessingSystemState, colors bool, busLines int) {
+	fmt.Fprintf(writer, "\nProcessing Elements\n")
+	for _, pe := range state.PEs {
+		next := []string{}
+		for _, instruction := range pe.Instructions {
+			if len(next) == 3 {
+				next = append(next, "...")
+				break
+			}
+			next = append(next, instruction.Instruction)
+		}
+		if len(next) == 0 {
+			next = append(next, "-")
+		}
+		fmt.Fprintf(writer, "  PE%-2d R=%-4d %-14s next: %s", pe.ID, pe.Register, pe.Status, strings.Join(next, "; "))
+		if len(pe.StoreBuffer) > 0 {
+			fmt.Fprintf(writer, "  (%d buffered stores)", len(pe.StoreBuffer))
+		}
+		fmt.Fprintln(writer)
+	}
+
+	fmt.Fprintf(writer, "\nCaches\n")
+	for _, cc := range state.CCs {
+		blocks := []string{}
+		for _, block := range cc.Cache {
+			text := fmt.Sprintf("%s  --:--  ", block.State)
+			if block.Address != -1 {
+				text = fmt.Sprintf("%s %3d:%-4d", block.State, block.Address, block.Data)
+			}
+			if color, found := stateColors[block.State]; colors && found {
+				text = color + text + resetColor
+			}
+			blocks = append(blocks, text)
+		}
+		fmt.Fprintf(writer, "  CC%-2d %s\n", cc.ID, strings.Join(blocks, " | "))
+	}
+
+	fmt.Fprintf(writer, "\nMain Memory\n ")
+	for _, block := range state.MM.Blocks {
+		fmt.Fprintf(writer, " %2d:%-4d", block.Address, block.Data)
+		if block.Address%8 == 7 {
+			fmt.Fprintf(writer, "\n ")
+		}
+	}
+
+	fmt.Fprintf(writer, "\nBus log\n")
+	logs := state.IC.Logs
+	if len(logs) > busLines {
+		logs = logs[len(logs)-busLines:]
+	}
+	for _, log := range logs {
+		fmt.Fprintf(writer, "  %s\n", strings.TrimSpace(log.Log))
+	}
+}
+
+// Function to run the dashboard command, it takes the flags of the run command
+func dashboardCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	spec, err := parseRunSpec("dashboard", args, stderr)
+	if err == flag.ErrHelp {
+		return ExitSuccess
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "dashboard: %v\n", err)
+		return ExitUsage
+	}
+	cfg, err := spec.Config()
+	if err != nil {
+		fmt.Fprintf(stderr, "dashboard: %v\n", err)
+		return ExitUsage
+	}
+	cfg.LogDirectory = spec.Logs
+	if cfg.LogDirectory == "" {
+		logDirectory, err := os.MkdirTemp("", "dashboard-logs")
+		if err != nil {
+			fmt.Fprintf(stderr, "dashboard: %v\n", err)
+			return ExitFailure
+		}
+		defer os.RemoveAll(logDirectory)
+		cfg.LogDirectory = logDirectory
+	}
+
+	restore, err := rawTerminal()
+	if err != nil {
+		fmt.Fprintf(stderr, "dashboard: the standard input is not a terminal: %v\n", err)
+		return ExitFailure
+	}
+	defer restore()
+
+	dashboard := NewDashboard(stdout, spec, cfg)
+	dashboard.Colors = os.Getenv("NO_COLOR") == ""
+	if err := dashboard.Start(); err != nil {
+		fmt.Fprintf(stderr, "dashboard: %v\n", err)
+		return ExitFailure
+	}
+	defer dashboard.Stop()
+	fmt.Fprint(stdout, hideCursor)
+	defer fmt.Fprint(stdout, showCursor)
+
+	// The keys are read in their own goroutine, the steps of a run wait for the delay or for a key
+	keys := make(chan byte)
+	go func() {
+		buffer := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(buffer); err != nil {
+				close(keys)
+				return
+			}
+			keys <- buffer[0]
+		}
+	}()
+	// A signal sent from outside still leaves the dashboard through the deferred restore of the terminal
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	dashboard.Render()
+	for {
+		var tick <-chan time.Time
+		if dashboard.running {
+			tick = time.After(dashboardDelays[dashboard.speed])
+		}
+		select {
+		case key, open := <-keys:
+			if !open || !dashboard.Key(key) {
+				fmt.Fprint(stdout, clearScreen)
+				return ExitSuccess
+			}
+		case <-signals:
+			fmt.Fprint(stdout, clearScreen)
+			return ExitFailure
+		case <-tick:
+			dashboard.step()
+		}
+		dashboard.Render()
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"Backend/utils"
+)
+
+// Function to write the metrics of a run
+func writeResults(writer io.Writer, results utils.MultiprocessingSystemResults) {
+	fmt.Fprintf(writer, "  Cache hits:              %d\n", results.CacheHits)
+	fmt.Fprintf(writer, "  Cache misses:            %d\n", results.CacheMisses)
+	fmt.Fprintf(writer, "  Miss rate:               %.2f%%\n", results.MissRate)
+	fmt.Fprintf(writer, "  Read requests:           %d\n", results.ReadRequests)
+	fmt.Fprintf(writer, "  Read exclusive requests: %d\n", results.ReadExclusiveRequests)
+	fmt.Fprintf(writer, "  Data responses:          %d\n", results.DataResponses)
+	fmt.Fprintf(writer, "  Invalidates:             %d\n", results.Invalidates)
+	fmt.Fprintf(writer, "  Memory reads:            %d\n", results.MemoryReads)
+	fmt.Fprintf(writer, "  Memory writes:           %d\n", results.MemoryWrites)
+	fmt.Fprintf(writer, "  Power consumption:       %.2f\n", results.PowerConsumption)
+	fmt.Fprintf(writer, "  SC successes / failures: %d / %d\n", results.SCSuccesses, results.SCFailures)
+	fmt.Fprintf(writer, "  Misses by kind:          %s\n", missText(results.Misses))
+	for _, cache := range results.CacheMissCounts {
+		fmt.Fprintf(writer, "    CC%d %d hits, %d misses: %s\n", cache.Cache, cache.Hits, cache.Misses, missText(cache.MissCounts))
+		for _, address := range cache.Addresses {
+			fmt.Fprintf(writer, "      Address %d: %s\n", address.Address, missText(address.MissCounts))
+		}
+	}
+}
+
+// Function to write the misses of every kind in one line
+func missText(counts utils.MissCounts) string {
+	return fmt.Sprintf("compulsory %d, capacity %d, conflict %d, coherence %d (true sharing %d, false sharing %d)", counts.Compulsory, counts.Capacity, counts.Conflict, counts.Coherence, counts.TrueSharing, counts.FalseSharing)
+}
+
+// Function to write the register and the status of every PE
+func writeProcessingElements(writer io.Writer, pes utils.AboutProcessingElementList) {
+	for _, pe := range pes {
+		fmt.Fprintf(writer, "  PE%d register %d (%s)\n", pe.ID, pe.Register, pe.Status)
+	}
+}
+
+// Function to write the blocks of every cache as address:data state, "-" for the empty blocks
+func writeCaches(writer io.Writer, ccs utils.AboutCacheControllerList) {
+	for _, cc := range ccs {
+		blocks := []string{}
+		for _, block := range cc.Cache {
+			if block.Address == -1 {
+				blocks = append(blocks, "-")
+				continue
+			}
+			blocks = append(blocks, fmt.Sprintf("%d:%d %s", block.Address, block.Data, block.State))
+		}
+		fmt.Fprintf(writer, "  CC%d [%s]\n", cc.ID, strings.Join(blocks, ", "))
+	}
+}
+
+// Function to write the words of a memory in one line
+func writeMemory(writer io.Writer, label string, values []int) {
+	words := []string{}
+	for _, value := range values {
+		words = append(words, strconv.Itoa(value))
+	}
+	fmt.Fprintf(writer, "  %s: %s\n", label, strings.Join(words, " "))
+}
+
+// Function to write the report of the coherence invariants that failed and the access that broke them
+func writeViolation(writer io.Writer, violation *utils.InvariantViolation) {
+	access := violation.Access
+	fmt.Fprintf(writer, "  After the %s %d of CC%d", access.Kind, access.Address, access.Cache)
+	if access.Write {
+		fmt.Fprintf(writer, " storing %d", access.Value)
+	}
+	fmt.Fprintf(writer, "\n")
+	writeTransactions(writer, "  ", violation.Transactions)
+	writeFailures(writer, violation.Failures)
+}
+
+// Function to write the bus transactions of an access, one in every line
+func writeTransactions(writer io.Writer, indent string, transactions []utils.BusTransaction) {
+	for _, transaction := range transactions {
+		fmt.Fprintf(writer, "%sBus: CC%d %s %d (%s), remote %s, answered %s with %d\n", indent, transaction.Requester, transaction.Type, transaction.Address, transaction.AR, transaction.RemoteStatus, transaction.NewStatus, transaction.Data)
+	}
+}
+
+// Function to write the coherence invariants that failed with the copies of their address
+func writeFailures(writer io.Writer, failures []utils.InvariantFailure) {
+	for _, failure := range failures {
+		fmt.Fprintf(writer, "  %s: %s\n", failure.Invariant, failure.Detail)
+		fmt.Fprintf(writer, "    copies [%s], memory %d, last store %d\n", strings.Join(failure.Copies, ", "), failure.Memory, failure.Expected)
+	}
+}
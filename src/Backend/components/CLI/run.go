@@ -0,0 +1,373 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"Backend/components/Comparison"
+	"Backend/components/MultiprocessingSystem"
+	"Backend/components/ProgramLibrary"
+	"Backend/utils"
+)
+
+// Description of a headless run, read from a JSON configuration file and overridden by the flags
+type RunSpec struct {
+	Protocol        string   `json:"protocol"`
+	Consistency     string   `json:"consistency"`
+	Cores           int      `json:"cores"`
+	CacheLines      int      `json:"cacheLines"`
+	CacheWays       int      `json:"cacheWays"`
+	Instructions    int      `json:"instructions"`    // Instructions of every generated program
+	Workload        string   `json:"workload"`        // Preset of the generated programs
+	Programs        []string `json:"programs"`        // Files with the program of every PE, "" generates the program
+	Library         []string `json:"library"`         // Saved programs of every PE, used when there is no file
+	LibraryDir      string   `json:"libraryDir"`      // Folder of the saved programs
+	Memory          []uint32 `json:"memory"`          // Initial memory, the missing words get seeded random values
+	Seed            int64    `json:"seed"`            // Seed of the generated programs, the initial memory and the scheduler
+	MaxSteps        int      `json:"maxSteps"`        // Scheduler steps before the run is considered stuck
+	Logs            string   `json:"logs"`            // Folder of the log files, a temporary folder when empty
+	Output          string   `json:"output"`          // File where the report is written, the standard output when empty
+	Format          string   `json:"format"`          // json or text
+	CheckInvariants bool     `json:"checkInvariants"` // Check the coherence invariants after every access
+}
+
+// Function to get the run used when neither the file nor the flags give a value
+func DefaultRunSpec() RunSpec {
+	cfg := MultiprocessingSystem.DefaultConfig()
+	return RunSpec{
+		Protocol:     cfg.Protocol,
+		Consistency:  cfg.Consistency,
+		Cores:        cfg.Cores,
+		Instructions: 8,
+		Workload:     "uniform",
+		LibraryDir:   "programs",
+		Seed:         1,
+		MaxSteps:     10000,
+		Format:       "text",
+	}
+}
+
+// Report of a headless run
+type RunReport struct {
+	Protocol    string                             `json:"Protocol"`
+	Consistency string                             `json:"Consistency"`
+	Cores       int                                `json:"Cores"`
+	Seed        int64                              `json:"Seed"`
+	Complete    bool                               `json:"Complete"` // Every PE finished its program
+	Errors      []string                           `json:"Errors"`   // PEs that stopped on an invalid instruction
+	Programs    [][]string                         `json:"Programs"`
+	Memory      []int                              `json:"Memory"` // Final value of every address as the processors see it
+	Results     utils.MultiprocessingSystemResults `json:"Results"`
+	State       utils.MultiprocessingSystemState   `json:"State"`
+	Violation   *utils.InvariantViolation          `json:"Violation"` // Access that broke the coherence invariants, the run stopped there
+}
+
+// Function to read the flags of the run and dashboard commands, the configuration file is read first
+func parseRunSpec(name string, args []string, stderr io.Writer) (RunSpec, error) {
+	spec := DefaultRunSpec()
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	config := flags.String("config", "", "JSON file with the run, the other flags override it")
+	protocol := flags.String("protocol", spec.Protocol, "cache coherence protocol: MESI or MOESI")
+	consistency := flags.String("consistency", spec.Consistency, "memory consistency model: SC, TSO or PSO")
+	cores := flags.Int("cores", spec.Cores, "number of PEs")
+	cacheLines := flags.Int("cache-lines", 0, "blocks of every cache, 0 uses the default cache")
+	cacheWays := flags.Int("cache-ways", 0, "associativity of the caches, 0 is fully associative")
+	instructions := flags.Int("instructions", spec.Instructions, "instructions of every generated program")
+	workload := flags.String("workload", spec.Workload, "preset of the generated programs")
+	programs := flags.String("programs", "", "comma separated files with the program of every PE, an empty entry generates it")
+	library := flags.String("library", "", "comma separated names of saved programs for every PE")
+	libraryDir := flags.String("library-dir", spec.LibraryDir, "folder of the saved programs")
+	memory := flags.String("memory", "", "comma separated initial values of the Main Memory")
+	seed := flags.Int64("seed", spec.Seed, "seed of the programs, the initial memory and the scheduler")
+	maxSteps := flags.Int("max-steps", spec.MaxSteps, "steps before the run is considered stuck")
+	logs := flags.String("logs", "", "folder of the log files, a temporary folder when empty")
+	output := flags.String("output", "", "file for the report, the standard output when empty")
+	format := flags.String("format", spec.Format, "format of the report: json or text")
+	check := flags.Bool("check-invariants", spec.CheckInvariants, "check the coherence invariants after every access and stop at the first violation")
+	if err := flags.Parse(args); err != nil {
+		return spec, err
+	}
+	if flags.NArg() > 0 {
+		return spec, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	if *config != "" {
+		file, err := os.Open(*config)
+		if err != nil {
+			return spec, err
+		}
+		defer file.Close()
+		decoder := json.NewDecoder(file)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&spec); err != nil {
+			return spec, fmt.Errorf("%s: %v", *config, err)
+		}
+	}
+
+	// Only the flags given in the command line replace the values of the file
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "protocol":
+			spec.Protocol = *protocol
+		case "consistency":
+			spec.Consistency = *consistency
+		case "cores":
+			spec.Cores = *cores
+		case "cache-lines":
+			spec.CacheLines = *cacheLines
+		case "cache-ways":
+			spec.CacheWays = *cacheWays
+		case "instructions":
+			spec.Instructions = *instructions
+		case "workload":
+			spec.Workload = *workload
+		case "programs":
+			spec.Programs = splitList(*programs)
+		case "library":
+			spec.Library = splitList(*library)
+		case "library-dir":
+			spec.LibraryDir = *libraryDir
+		case "memory":
+			spec.Memory = nil
+			for _, text := range splitList(*memory) {
+				value, parseErr := strconv.ParseUint(text, 0, 32)
+				if parseErr != nil {
+					err = fmt.Errorf("invalid memory value %q", text)
+				}
+				spec.Memory = append(spec.Memory, uint32(value))
+			}
+		case "seed":
+			spec.Seed = *seed
+		case "max-steps":
+			spec.MaxSteps = *maxSteps
+		case "logs":
+			spec.Logs = *logs
+		case "output":
+			spec.Output = *output
+		case "format":
+			spec.Format = *format
+		case "check-invariants":
+			spec.CheckInvariants = *check
+		}
+	})
+	if err != nil {
+		return spec, err
+	}
+	if spec.Format != "json" && spec.Format != "text" {
+		return spec, fmt.Errorf("unknown format %q, expected json or text", spec.Format)
+	}
+	if spec.MaxSteps <= 0 {
+		return spec, fmt.Errorf("the maximum number of steps must be positive")
+	}
+	return spec, nil
+}
+
+// Config builds the configuration of the system described by the run.
+func (spec RunSpec) Config() (MultiprocessingSystem.Config, error) {
+	cfg := MultiprocessingSystem.DefaultConfig()
+	cfg.Protocol = spec.Protocol
+	cfg.Consistency = spec.Consistency
+	cfg.Cores = spec.Cores
+	cfg.CacheLines = spec.CacheLines
+	cfg.CacheWays = spec.CacheWays
+	cfg.InstructionsPerCore = spec.Instructions
+	cfg.CheckInvariants = spec.CheckInvariants
+	workload := utils.DefaultWorkloadOptions(spec.Workload)
+	workload.Instructions = spec.Instructions
+	cfg.Workload = &workload
+	if len(spec.Programs) > spec.Cores || len(spec.Library) > spec.Cores {
+		return cfg, fmt.Errorf("more programs than the %d PEs were given", spec.Cores)
+	}
+
+	// Every PE takes its file, or its saved program, or a generated program
+	setProgram := func(id int, name string, source string) {
+		if cfg.Programs == nil {
+			cfg.Programs = make([][]string, spec.Cores)
+			cfg.ProgramNames = make([]string, spec.Cores)
+		}
+		cfg.Programs[id] = strings.Split(source, "\n")
+		cfg.ProgramNames[id] = name
+	}
+	library := programLibrary.New(spec.LibraryDir)
+	for id := 0; id < spec.Cores; id++ {
+		switch {
+		case id < len(spec.Programs) && spec.Programs[id] != "":
+			source, err := os.ReadFile(spec.Programs[id])
+			if err != nil {
+				return cfg, err
+			}
+			setProgram(id, filepath.Base(spec.Programs[id]), string(source))
+		case id < len(spec.Library) && spec.Library[id] != "":
+			program, err := library.Get(spec.Library[id])
+			if err != nil {
+				return cfg, err
+			}
+			setProgram(id, program.Name, program.Source)
+		}
+	}
+	if spec.Memory != nil {
+		if len(spec.Memory) > MultiprocessingSystem.MemorySize {
+			return cfg, fmt.Errorf("the initial memory has %d words, the Main Memory only has %d", len(spec.Memory), MultiprocessingSystem.MemorySize)
+		}
+		// The missing words keep the values given by the seed
+		seeded, err := comparison.Prepare(cfg, spec.Seed)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.InitialMemory = seeded.InitialMemory
+		copy(cfg.InitialMemory, spec.Memory)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return comparison.Prepare(cfg, spec.Seed)
+}
+
+// Execute initializes the system built by Config, runs it with the seeded scheduler and collects the report.
+// The report is returned even when the run doesn't complete.
+func (spec RunSpec) Execute(cfg MultiprocessingSystem.Config) (*RunReport, error) {
+	cfg.LogDirectory = spec.Logs
+	if cfg.LogDirectory == "" {
+		logDirectory, err := os.MkdirTemp("", "headless-logs")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(logDirectory)
+		cfg.LogDirectory = logDirectory
+	}
+
+	mps, err := MultiprocessingSystem.StartWithConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer mps.Stop()
+	complete, err := mps.RunSeeded(spec.Seed, spec.MaxSteps)
+	// A violation stops the run, the report shows the state where it happened
+	violation := mps.Violation()
+	if err != nil && violation == nil {
+		return nil, err
+	}
+
+	report := &RunReport{
+		Protocol:    cfg.Protocol,
+		Consistency: cfg.Consistency,
+		Cores:       cfg.Cores,
+		Seed:        spec.Seed,
+		Complete:    complete,
+		Errors:      []string{},
+		Programs:    cfg.Programs,
+		Results:     mps.Results(),
+		State:       mps.State(),
+		Violation:   violation,
+	}
+	if violation != nil {
+		report.Complete = false
+	}
+	for _, pe := range mps.ProcessingElements {
+		if strings.HasPrefix(pe.GetStatus(), "Error") {
+			report.Complete = false
+			report.Errors = append(report.Errors, fmt.Sprintf("PE%d: %s", pe.ID, pe.GetStatus()))
+		}
+	}
+	for address := 0; address < MultiprocessingSystem.MemorySize; address++ {
+		report.Memory = append(report.Memory, mps.CoherentValue(address))
+	}
+	return report, nil
+}
+
+// Function to write the report as text
+func (report *RunReport) writeText(writer io.Writer) {
+	fmt.Fprintf(writer, "Protocol: %s, consistency: %s, cores: %d, seed: %d\n", report.Protocol, report.Consistency, report.Cores, report.Seed)
+	if report.Complete {
+		fmt.Fprintf(writer, "Status: complete\n")
+	} else {
+		fmt.Fprintf(writer, "Status: incomplete\n")
+	}
+	for _, message := range report.Errors {
+		fmt.Fprintf(writer, "  %s\n", message)
+	}
+	if report.Violation != nil {
+		fmt.Fprintf(writer, "\nCoherence violation:\n")
+		writeViolation(writer, report.Violation)
+	}
+
+	fmt.Fprintf(writer, "\nResults:\n")
+	writeResults(writer, report.Results)
+
+	fmt.Fprintf(writer, "\nFinal state:\n")
+	writeProcessingElements(writer, report.State.PEs)
+	writeCaches(writer, report.State.CCs)
+	memory := []int{}
+	for _, block := range report.State.MM.Blocks {
+		memory = append(memory, block.Data)
+	}
+	writeMemory(writer, "Main Memory", memory)
+	writeMemory(writer, "Coherent memory", report.Memory)
+}
+
+// Function to run the run command: exit status 0 when the run completes, 1 when it fails and 2 for usage errors
+func runCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	spec, err := parseRunSpec("run", args, stderr)
+	if err == flag.ErrHelp {
+		return ExitSuccess
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "run: %v\n", err)
+		return ExitUsage
+	}
+
+	cfg, err := spec.Config()
+	if err != nil {
+		fmt.Fprintf(stderr, "run: %v\n", err)
+		return ExitUsage
+	}
+	report, err := spec.Execute(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "run: %v\n", err)
+		return ExitFailure
+	}
+
+	writer := stdout
+	if spec.Output != "" {
+		file, err := os.Create(spec.Output)
+		if err != nil {
+			fmt.Fprintf(stderr, "run: %v\n", err)
+			return ExitFailure
+		}
+		defer file.Close()
+		writer = file
+	}
+	if spec.Format == "json" {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "    ")
+		err = encoder.Encode(report)
+	} else {
+		report.writeText(writer)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "run: %v\n", err)
+		return ExitFailure
+	}
+
+	switch {
+	case report.Violation != nil:
+		failure := report.Violation.Failures[0]
+		fmt.Fprintf(stderr, "run: the coherence invariant %s failed: %s\n", failure.Invariant, failure.Detail)
+		return ExitFailure
+	case len(report.Errors) > 0:
+		fmt.Fprintf(stderr, "run: %s\n", strings.Join(report.Errors, "; "))
+		return ExitFailure
+	case !report.Complete:
+		fmt.Fprintf(stderr, "run: the programs didn't finish after %d steps\n", spec.MaxSteps)
+		return ExitFailure
+	}
+	return ExitSuccess
+}
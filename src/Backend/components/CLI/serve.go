@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	restfulapi "Backend/components/RESTfulAPI"
+)
+
+// Function to read the configuration of the server, each source replaces the previous one: the defaults,
+// the file given by -config or SIMULATOR_CONFIG, the SIMULATOR_* environment variables and the flags
+func parseServerConfig(args []string, lookup func(string) (string, bool), stderr io.Writer) (restfulapi.ServerConfig, bool, error) {
+	cfg := restfulapi.DefaultServerConfig()
+	defaultFile, _ := lookup("SIMULATOR_CONFIG")
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	config := flags.String("config", defaultFile, "JSON file with the configuration of the server")
+	address := flags.String("address", cfg.Address, "address where the server listens")
+	tlsCert := flags.String("tls-cert", "", "certificate file, the server uses HTTPS with -tls-key")
+	tlsKey := flags.String("tls-key", "", "private key file of the certificate")
+	origins := flags.String("origins", "*", "comma separated origins allowed by CORS and the event WebSockets")
+	logs := flags.String("logs", cfg.LogDirectory, "folder of the log files of the frontend system")
+	libraryDir := flags.String("library-dir", cfg.LibraryDirectory, "folder of the saved programs")
+	generatedDir := flags.String("generated-dir", cfg.GeneratedDirectory, "folder of the generated programs")
+	readHeaderTimeout := flags.Duration("read-header-timeout", time.Duration(cfg.ReadHeaderTimeout), "time to read the headers of a request")
+	readTimeout := flags.Duration("read-timeout", time.Duration(cfg.ReadTimeout), "time to read a whole request, 0 has no limit")
+	writeTimeout := flags.Duration("write-timeout", time.Duration(cfg.WriteTimeout), "time to write a response, 0 has no limit")
+	idleTimeout := flags.Duration("idle-timeout", time.Duration(cfg.IdleTimeout), "time a connection is kept without requests")
+	shutdownTimeout := flags.Duration("shutdown-timeout", time.Duration(cfg.ShutdownTimeout), "time to finish the requests in progress when the server shuts down")
+	console := flags.Bool("console", true, "read console commands from the standard input")
+	if err := flags.Parse(args); err != nil {
+		return cfg, false, err
+	}
+	if flags.NArg() > 0 {
+		return cfg, false, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	if *config != "" {
+		if err := cfg.Load(*config); err != nil {
+			return cfg, false, err
+		}
+	}
+	if err := cfg.ApplyEnvironment(lookup); err != nil {
+		return cfg, false, err
+	}
+
+	// Only the flags given in the command line replace the values of the file and the environment
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "address":
+			cfg.Address = *address
+		case "tls-cert":
+			cfg.TLSCertFile = *tlsCert
+		case "tls-key":
+			cfg.TLSKeyFile = *tlsKey
+		case "origins":
+			cfg.AllowedOrigins = splitList(*origins)
+		case "logs":
+			cfg.LogDirectory = *logs
+		case "library-dir":
+			cfg.LibraryDirectory = *libraryDir
+		case "generated-dir":
+			cfg.GeneratedDirectory = *generatedDir
+		case "read-header-timeout":
+			cfg.ReadHeaderTimeout = restfulapi.Duration(*readHeaderTimeout)
+		case "read-timeout":
+			cfg.ReadTimeout = restfulapi.Duration(*readTimeout)
+		case "write-timeout":
+			cfg.WriteTimeout = restfulapi.Duration(*writeTimeout)
+		case "idle-timeout":
+			cfg.IdleTimeout = restfulapi.Duration(*idleTimeout)
+		case "shutdown-timeout":
+			cfg.ShutdownTimeout = restfulapi.Duration(*shutdownTimeout)
+		}
+	})
+	return cfg, *console, cfg.Validate()
+}
+
+// Function of the serve command, the server runs until the console, the /close route or a signal stops it
+func serveCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	cfg, withConsole, err := parseServerConfig(args, os.LookupEnv, stderr)
+	if err == flag.ErrHelp {
+		return ExitSuccess
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "serve: %v\n", err)
+		return ExitUsage
+	}
+	server, err := restfulapi.NewServer(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "serve: %v\n", err)
+		return ExitUsage
+	}
+	listener, err := net.Listen("tcp", cfg.Address)
+	if err != nil {
+		fmt.Fprintf(stderr, "serve: %v\n", err)
+		return ExitFailure
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	fmt.Fprintf(stdout, "Server listening on %s\n", listener.Addr())
+
+	// The console drives its own system, the frontend keeps using the server
+	if withConsole {
+		fmt.Fprintln(stdout, "Enter 'C' or 'quit' to end the server")
+		console := NewConsole(stdout)
+		var quit sync.Once
+		console.OnQuit = func() {
+			quit.Do(func() {
+				restfulapi.Close()
+				fmt.Fprintln(stdout, "Terminate server")
+			})
+		}
+		go console.Run(os.Stdin)
+		// The system of the console also writes its logs before the program ends
+		defer console.Close()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	select {
+	case err := <-served:
+		if err != nil {
+			fmt.Fprintf(stderr, "serve: %v\n", err)
+			return ExitFailure
+		}
+		return ExitSuccess
+	case <-server.Done():
+	case <-signals:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		fmt.Fprintf(stderr, "serve: %v\n", err)
+		return ExitFailure
+	}
+	<-served
+	fmt.Fprintln(stdout, "Server closed")
+	return ExitSuccess
+}
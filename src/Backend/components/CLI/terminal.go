@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ANSI escape sequences of the dashboard
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+	resetColor  = "\x1b[0m"
+	boldText    = "\x1b[1m"
+	dimText     = "\x1b[2m"
+)
+
+// Colour of every coherence state
+var stateColors = map[string]string{
+	"M": "\x1b[1;31m", // Red
+	"O": "\x1b[1;35m", // Magenta
+	"E": "\x1b[1;32m", // Green
+	"S": "\x1b[1;36m", // Cyan
+	"I": "\x1b[2;37m", // Grey
+}
+
+// Function to put the terminal of the standard input in raw mode, keys are read one by one without echo
+// and Ctrl+C arrives as the byte 3 instead of a SIGINT. The returned function restores the previous mode.
+func rawTerminal() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() { stty(strings.TrimSpace(saved)) }, nil
+}
+
+// Function to run stty on the terminal of the standard input
+func stty(args ...string) (string, error) {
+	command := exec.Command("stty", args...)
+	command.Stdin = os.Stdin
+	output, err := command.Output()
+	return string(output), err
+}
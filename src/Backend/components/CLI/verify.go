@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"Backend/components/Verifier"
+)
+
+// Function to read the flags of the verify command
+func parseVerifyOptions(args []string, stderr io.Writer) (verifier.Options, string, error) {
+	options := verifier.DefaultOptions()
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&options.Protocol, "protocol", options.Protocol, "cache coherence protocol: MESI or MOESI")
+	flags.IntVar(&options.Caches, "caches", options.Caches, "number of caches")
+	flags.IntVar(&options.Blocks, "blocks", options.Blocks, "addresses accessed by the processors")
+	flags.IntVar(&options.Lines, "lines", options.Lines, "blocks of every cache, 0 holds every address so no block is replaced")
+	flags.IntVar(&options.Ways, "ways", options.Ways, "associativity of the caches, 0 is fully associative")
+	flags.IntVar(&options.Values, "values", options.Values, "values stored by the writes")
+	flags.IntVar(&options.MaxStates, "max-states", options.MaxStates, "states explored before the search gives up")
+	flags.DurationVar(&options.Timeout, "timeout", options.Timeout, "time an access can take before it is reported as a deadlock")
+	format := flags.String("format", "text", "format of the report: json or text")
+	if err := flags.Parse(args); err != nil {
+		return options, "", err
+	}
+	if flags.NArg() > 0 {
+		return options, "", fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+	if *format != "json" && *format != "text" {
+		return options, "", fmt.Errorf("unknown format %q, expected json or text", *format)
+	}
+	return options, *format, nil
+}
+
+// Function to write the report of a verification as text, with the counterexample of a violation
+func writeVerification(writer io.Writer, report *verifier.Report) {
+	fmt.Fprintf(writer, "Protocol: %s, caches: %d, blocks: %d, lines: %d, ways: %d, values: %d\n", report.Protocol, report.Caches, report.Blocks, report.Lines, report.Ways, report.Values)
+	fmt.Fprintf(writer, "Verdict: %s\n", report.Verdict)
+	fmt.Fprintf(writer, "  States: %d, transitions: %d, depth: %d\n", report.States, report.Transitions, report.Depth)
+	if report.Violation == nil {
+		return
+	}
+
+	fmt.Fprintf(writer, "\nCounterexample:\n")
+	fmt.Fprintf(writer, "  Initial state\n")
+	for _, line := range report.Initial.Lines() {
+		fmt.Fprintf(writer, "    %s\n", line)
+	}
+	for i, step := range report.Violation.Trace {
+		fmt.Fprintf(writer, "  %d. %s\n", i+1, step.Event)
+		writeTransactions(writer, "    ", step.Transactions)
+		for _, line := range step.State.Lines() {
+			fmt.Fprintf(writer, "    %s\n", line)
+		}
+	}
+	fmt.Fprintf(writer, "\nFailed invariants:\n")
+	writeFailures(writer, report.Violation.Failures)
+}
+
+// Function to run the verify command: exit status 0 when every reachable state holds the invariants,
+// 1 for a violation or an incomplete search and 2 for usage errors
+func verifyCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	options, format, err := parseVerifyOptions(args, stderr)
+	if err == flag.ErrHelp {
+		return ExitSuccess
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "verify: %v\n", err)
+		return ExitUsage
+	}
+	report, err := verifier.Run(options)
+	if err != nil {
+		fmt.Fprintf(stderr, "verify: %v\n", err)
+		return ExitUsage
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "    ")
+		err = encoder.Encode(report)
+	} else {
+		writeVerification(stdout, report)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "verify: %v\n", err)
+		return ExitFailure
+	}
+
+	switch report.Verdict {
+	case verifier.VerdictViolation:
+		failure := report.Violation.Failures[0]
+		fmt.Fprintf(stderr, "verify: the invariant %s failed after %d accesses: %s\n", failure.Invariant, len(report.Violation.Trace), failure.Detail)
+		return ExitFailure
+	case verifier.VerdictIncomplete:
+		fmt.Fprintf(stderr, "verify: the search stopped after %d states without covering every reachable state\n", report.States)
+		return ExitFailure
+	}
+	return ExitSuccess
+}
@@ -1,17 +1,36 @@
 package CacheController
 
+// Number of lines and ways of the cache used by the frontend, a fully associative cache of 4 lines
+const DefaultCacheLines = 4
+const DefaultCacheWays = 4
+
+// Number of words of a block, every address is a block of its own, so every coherence miss is true sharing
+const WordsPerBlock = 1
+
 type Cache struct{
-	data	[4]int
-	address	[4]int
-	status	[4]string
+	data	[]int
+	address	[]int
+	status	[]string
+	ways	int
 }
 
 func NewCache() *Cache{
-	return &Cache{
-		data: [4]int{0, 0, 0, 0},
-		address: [4]int{-1, -1, -1, -1},
-		status: [4]string{"I", "I", "I", "I"},
+	return NewCacheWithGeometry(DefaultCacheLines, DefaultCacheWays)
+}
+
+// Function to create a cache of lines blocks grouped in sets of ways blocks, lines must be a multiple of ways
+func NewCacheWithGeometry(lines int, ways int) *Cache{
+	cache := &Cache{
+		data: make([]int, lines),
+		address: make([]int, lines),
+		status: make([]string, lines),
+		ways: ways,
 	}
+	for i := 0; i < lines; i++ {
+		cache.address[i] = -1
+		cache.status[i] = "I"
+	}
+	return cache
 }
 
 func (cache *Cache) SetData(pos int, res int){
@@ -37,3 +56,34 @@ func (cache *Cache) GetAddress(pos int) int{
 func (cache *Cache) GetState(pos int) string{
 	return cache.status[pos]
 }
+
+// Function to get the number of blocks of the cache
+func (cache *Cache) Lines() int{
+	return len(cache.address)
+}
+
+// Function to get the number of blocks of every set
+func (cache *Cache) Ways() int{
+	return cache.ways
+}
+
+// Function to get the number of sets of the cache
+func (cache *Cache) Sets() int{
+	return len(cache.address) / cache.ways
+}
+
+// Function to get the set where an address can be stored
+func (cache *Cache) SetOf(address int) int{
+	return address % cache.Sets()
+}
+
+// Function to get the block that holds an address, -1 when the address is not in the cache
+func (cache *Cache) Find(address int) int{
+	first := cache.SetOf(address) * cache.ways
+	for pos := first; pos < first + cache.ways; pos++ {
+		if (cache.address[pos] == address){
+			return pos
+		}
+	}
+	return -1
+}
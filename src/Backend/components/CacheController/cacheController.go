@@ -27,11 +27,20 @@ type CacheController struct {
 	Quit chan struct{}
 	Protocol string
 	Logger *log.Logger
-	ReplacementQueue utils.Queue
+	ReplacementQueues []utils.Queue
 	Status string
 	CacheHits int
 	CacheMisses int
 	MemoryAccesses int
+	linkedAddress int				// Address of the LL reservation, -1 when there is none
+	reservation sync.Mutex			// Protects the reservation, the broadcasts clear it while the PE requests use it
+	SCSuccesses int
+	SCFailures int
+	counters sync.Mutex				// Protects the hits, misses and SC counters, the metrics read them while the PE requests change them
+	Latency utils.Latencies
+	Events *utils.EventStream		// Receives the changes of the cache blocks, nil disables them
+	Checker func(utils.CacheAccess)	// Checks the caches after every access while the bus is still taken, nil disables it
+	Misses *MissClassifier			// Classifies the misses as compulsory, capacity, conflict or coherence
 }
 
 func New(
@@ -56,8 +65,8 @@ func New(
     // Initialize logger for the PE using its respective log file
     logger1 := log.New(logFile, "CC" + strconv.Itoa(id) + "_", log.Ldate|log.Ltime)
 
-	// Create a new queue to handle the cache lines replacement
-	myQueue := utils.Queue{}
+	// Create a queue for every set to handle the cache lines replacement
+	myQueues := make([]utils.Queue, DefaultCacheLines / DefaultCacheWays)
 	
 	return &CacheController{
 		ID: id,
@@ -72,10 +81,15 @@ func New(
 		Semaphore: semaphore,
 		Protocol: protocol,
 		Quit: quit,
-		ReplacementQueue: myQueue,
+		ReplacementQueues: myQueues,
 		Status: "Active",
 		CacheMisses: 0,
 		CacheHits: 0,
+		linkedAddress: -1,
+		SCSuccesses: 0,
+		SCFailures: 0,
+		Latency: utils.DefaultLatencies(),
+		Misses: NewMissClassifier(DefaultCacheLines),
     }, nil
 }
 
@@ -83,7 +97,7 @@ func New(
 func (cc *CacheController) About()(string, error){
 	// Create an empty CacheObjectList
 	cacheBlocks := utils.CacheObjectList{}
-    for i := 0; i <= 3; i++ {
+    for i := 0; i < cc.Cache.Lines(); i++ {
         // Create a new CacheObject instance
 		cacheObj := utils.CacheObject{
 			Block:   i,
@@ -114,16 +128,17 @@ func (cc *CacheController) About()(string, error){
 	return jsonString, nil
 }
 
+// Function to replace the local cache by an empty one with lines blocks grouped in sets of ways blocks
+func (cc *CacheController) SetCacheGeometry(lines int, ways int) {
+	cc.Cache = NewCacheWithGeometry(lines, ways)
+	cc.ReplacementQueues = make([]utils.Queue, cc.Cache.Sets())
+	cc.Misses = NewMissClassifier(lines)
+}
+
 // Function to return the status of an address in the local cache
 func (cc *CacheController) GetAddressStatus(address int) string{
-	pos := 0
-	for _, addr := range cc.Cache.address{
-		if(address == addr){
-			break
-		}
-		pos++
-	}
-	if(pos == 4){
+	pos := cc.Cache.Find(address)
+	if(pos == -1){
 		return "I"
 	}
 	return cc.Cache.status[pos]
@@ -131,49 +146,42 @@ func (cc *CacheController) GetAddressStatus(address int) string{
 
 // Function to know if a data is in the local cache
 func (cc *CacheController) DataInCache(address int) bool{
-	found := false
-	for _, addr := range cc.Cache.address{
-		if(address == addr){
-			found = true
-			break
-		}
-	}
-	return found 
+	return cc.Cache.Find(address) != -1
 }
 
-// Function to write a new data in the local cache, using FIFO for replacement policy
+// Function to write a new data in the local cache, using FIFO inside every set for replacement policy
 func (cc *CacheController) WriteDataToCache(address int, data int, status string){
 	cc.Logger.Printf(" - CC%d is storing the value %d into the local cache at the address %d.\n", cc.ID, data, address)
-	// Get the new block to replace
-	newLine := cc.ReplacementQueue.Size()
+	// Get the new block to replace, the free blocks of the set are used first
+	set := cc.Cache.SetOf(address)
+	queue := &cc.ReplacementQueues[set]
+	newLine := set * cc.Cache.Ways() + queue.Size()
 
 	// Check if the data is in the local cache
 	if (cc.DataInCache(address)){
 		cc.Logger.Printf(" - The address exists in the local cache.\n")
-		pos := 0
-		for _, addr := range cc.Cache.address{
-			if(address == addr){
-				break
-			}
-			pos++
-		}
-		newLine = pos
+		newLine = cc.Cache.Find(address)
 	}
 
 	if (!cc.DataInCache(address)){
 		cc.Logger.Printf(" - The address doesn't exist in the local cache.\n")
-		if (newLine > 3){
-			newLine = cc.ReplacementQueue.Dequeue()
+		if (queue.Size() == cc.Cache.Ways()){
+			newLine = queue.Dequeue()
 			cc.Logger.Printf(" - CC%d is replacing the the block %d.\n", cc.ID, newLine)
+			// Evicting the linked block loses the reservation
+			cc.ClearReservationOn(cc.Cache.GetAddress(newLine))
 		}
 		// Add the cache line to the queue
-		cc.ReplacementQueue.Enqueue(newLine)
+		queue.Enqueue(newLine)
 	}
 	
 	// Replace the contents of the cache line
+	previousAddress, previousState := cc.Cache.GetAddress(newLine), cc.Cache.GetState(newLine)
 	cc.Cache.SetData(newLine, data)
 	cc.Cache.SetAddress(newLine, address)
 	cc.Cache.SetState(newLine, status)
+	cc.publishBlock(newLine, previousAddress, previousState)
+	cc.Misses.Filled(address, status)
 
 	cc.Logger.Printf(" - CC%d stored the value %d at the memory address %d and the cache block %d.\n", cc.ID, data, address, newLine)
 	cc.Logger.Printf(" - The new state of address %d is '%s'.\n", address, status)
@@ -181,36 +189,117 @@ func (cc *CacheController) WriteDataToCache(address int, data int, status string
 
 // Function to change the status of a local cache line
 func (cc *CacheController) ChangeCacheLineStatus(address int, newStatus string) bool {
-	found := false
-	cacheLine := 0
-	for _, addr := range cc.Cache.address{
-		if(address == addr){
-			found = true
-			break
-		}
-		cacheLine++
-	}
+	cacheLine := cc.Cache.Find(address)
 	// If the address is not in the cache
-	if(!found){
+	if(cacheLine == -1){
 		return false
 	}
 
 	// Change the status of that cache line
+	previousState := cc.Cache.GetState(cacheLine)
 	cc.Cache.SetState(cacheLine, newStatus)
+	if (previousState != newStatus){
+		cc.publishBlock(cacheLine, address, previousState)
+	}
 	cc.Logger.Printf(" - CC%d changed the status of the cache block %d to %s.\n", cc.ID, cacheLine, newStatus)
 
 	return true
 }
 
+// Function to publish the new contents of a cache block with its previous address and state
+func (cc *CacheController) publishBlock(block int, previousAddress int, previousState string) {
+	cc.Events.Publish(utils.EventCacheState, utils.CacheStateEvent{
+		Cache: cc.ID,
+		Block: block,
+		Address: cc.Cache.GetAddress(block),
+		Data: cc.Cache.GetData(block),
+		State: cc.Cache.GetState(block),
+		PreviousAddress: previousAddress,
+		PreviousState: previousState,
+	})
+}
+
+// Counters of the accesses of a Cache Controller at one moment
+type Counters struct {
+	Hits        int
+	Misses      int
+	SCSuccesses int
+	SCFailures  int
+}
+
+// Function to add one to a counter of the accesses
+func (cc *CacheController) count(counter *int) {
+	cc.counters.Lock()
+	defer cc.counters.Unlock()
+	*counter++
+}
+
+// Function to read the counters of the accesses while the Cache Controller may be serving a request
+func (cc *CacheController) Counters() Counters {
+	cc.counters.Lock()
+	defer cc.counters.Unlock()
+	return Counters{Hits: cc.CacheHits, Misses: cc.CacheMisses, SCSuccesses: cc.SCSuccesses, SCFailures: cc.SCFailures}
+}
+
+// Function to get the address of the LL reservation, -1 when there is none
+func (cc *CacheController) LinkedAddress() int {
+	cc.reservation.Lock()
+	defer cc.reservation.Unlock()
+	return cc.linkedAddress
+}
+
+// Function to place the LL reservation on an address
+func (cc *CacheController) SetReservation(address int) {
+	cc.reservation.Lock()
+	defer cc.reservation.Unlock()
+	cc.linkedAddress = address
+	cc.Logger.Printf(" - CC%d placed a reservation on the address %d.\n", cc.ID, address)
+}
+
+// Function to replace the LL reservation by a saved one, -1 restores no reservation
+func (cc *CacheController) RestoreReservation(address int) {
+	cc.reservation.Lock()
+	defer cc.reservation.Unlock()
+	cc.linkedAddress = address
+}
+
+// Function to drop the LL reservation, the next SC will fail
+func (cc *CacheController) ClearReservation() {
+	cc.reservation.Lock()
+	defer cc.reservation.Unlock()
+	cc.clearReservation()
+}
+
+// Function to drop the LL reservation only when it is on an address
+func (cc *CacheController) ClearReservationOn(address int) {
+	cc.reservation.Lock()
+	defer cc.reservation.Unlock()
+	if (cc.linkedAddress == address){
+		cc.clearReservation()
+	}
+}
+
+// Function to drop the LL reservation for a SC, it tells if the reservation was on the address of the SC
+func (cc *CacheController) takeReservation(address int) bool {
+	cc.reservation.Lock()
+	defer cc.reservation.Unlock()
+	linked := cc.linkedAddress == address
+	cc.clearReservation()
+	return linked
+}
+
+// Function to drop the LL reservation, with the reservation mutex taken
+func (cc *CacheController) clearReservation() {
+	if (cc.linkedAddress == -1){
+		return
+	}
+	cc.Logger.Printf(" - CC%d lost the reservation on the address %d.\n", cc.ID, cc.linkedAddress)
+	cc.linkedAddress = -1
+}
+
 // Function to get a data from a local cache address
 func (cc *CacheController) GetDataFromCache(address int) int{
-	cacheLine := 0
-	for _, addr := range cc.Cache.address{
-		if(address == addr){
-			break
-		}
-		cacheLine++
-	}
+	cacheLine := cc.Cache.Find(address)
 
 	// Return the data at the required address
 	return cc.Cache.GetData(cacheLine)
@@ -224,8 +313,8 @@ func (cc *CacheController) RequestToInterconnect(requestType string, AR string,
 		AR: AR,
 		Address: address,
 	}
-	// Wait for 2 seconds
-	time.Sleep(2 * time.Second)
+	// Wait before using the bus
+	time.Sleep(cc.Latency.CacheToInterconnect)
 
 	// Send the request to the Interconnect
 	cc.Logger.Printf(" - CC%d is about to send a %s to the Interconnect.\n", cc.ID, requestType)
@@ -249,7 +338,7 @@ func (cc *CacheController) RespondToProcessingElement(Data int, Status bool) {
 		Data: Data,
 		Status: Status,
 	}
-	time.Sleep(time.Second)
+	time.Sleep(cc.Latency.CacheToProcessingElement)
 	cc.Logger.Printf(" - CC%d will send a response to the PE.\n", cc.ID)
 	cc.ResponseChannelProcessingElement <- peResponse
 	cc.Logger.Printf(" - CC%d sent the response to the PE.\n", cc.ID)
@@ -279,254 +368,254 @@ func (cc *CacheController) Run(wg *sync.WaitGroup) {
 			case <- cc.Quit:
 				return
 
-			case cc.Semaphore <- struct{}{}:
-					select {
-						// Listen for the requests from the Processing Element
-						case request := <-cc.RequestChannelProcessingElement:
-							cc.Logger.Printf(" - CC%d received a request from PE%d.\n", cc.ID, cc.ID)
-							requestAddress := request.Address
-							requestData := request.Data
-							cacheLineStatus := cc.GetAddressStatus(requestAddress)
-
-							switch request.Type {
-							// Read request from the Processing Element
-							case "READ":
-								cc.Logger.Printf(" - CC%d is processing a READ request.\n", cc.ID)
-								cc.Logger.Printf(" - The Address to read from is: %d.\n", request.Address)
-
-								// MESI protocol ******************************************************************************
-								if (cc.Protocol == "MESI") {
-									// The address is not in the local cache
-									if (cacheLineStatus == "I"){
-										cc.CacheMisses++
-										cc.Logger.Printf(" - The address %d is not in the local cache.\n", requestAddress)
+			// Listen for the requests from the Processing Element
+			case request, ok := <-cc.RequestChannelProcessingElement:
+				// The channel is closed when the system stops
+				if (!ok){
+					return
+				}
+				cc.Logger.Printf(" - CC%d received a request from PE%d.\n", cc.ID, cc.ID)
+
+				// Take the bus semaphore before looking at the cache, the broadcasts may change it while waiting
+				select {
+				case cc.Semaphore <- struct{}{}:
+				case <-cc.Quit:
+					return
+				}
+				requestAddress := request.Address
+				requestData := request.Data
+				cacheLineStatus := cc.GetAddressStatus(requestAddress)
+				// A SC only stores while the reservation holds
+				stores := request.Type == "WRITE" || (request.Type == "SC" && cc.LinkedAddress() == requestAddress)
+
+				switch request.Type {
+				// Read request from the Processing Element, a LL also links the address
+				case "READ", "LL":
+					if (request.Type == "LL"){
+						cc.SetReservation(requestAddress)
+					}
+					cc.Logger.Printf(" - CC%d is processing a %s request.\n", cc.ID, request.Type)
+					cc.Logger.Printf(" - The Address to read from is: %d.\n", request.Address)
+
+					// MESI protocol ******************************************************************************
+					if (cc.Protocol == "MESI") {
+						// The address is not in the local cache
+						if (cacheLineStatus == "I"){
+							cc.count(&cc.CacheMisses)
+							cc.Misses.Miss(requestAddress)
+							cc.Logger.Printf(" - The address %d is not in the local cache.\n", requestAddress)
 				
-										// Send a Read-Request to the Interconnect
-										Data, NewStatus := cc.RequestToInterconnect("ReadRequest", "DataResponse", requestAddress)
-								
-										// Update the cache line with the new data and line status
-										cc.WriteDataToCache(requestAddress, Data, NewStatus)
+							// Send a Read-Request to the Interconnect
+							Data, NewStatus := cc.RequestToInterconnect("ReadRequest", "DataResponse", requestAddress)
+					
+							// Update the cache line with the new data and line status
+							cc.WriteDataToCache(requestAddress, Data, NewStatus)
 				
-										// Send a response status to the Processing Element
-										cc.RespondToProcessingElement(Data, true)
-										
-										// Release the semaphore
-										<-cc.Semaphore
-									}
-									// The address is in the local cache
-									if (cacheLineStatus == "E" || cacheLineStatus == "M" || cacheLineStatus == "S") {
-										cc.CacheHits++
-										cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
-										cc.Logger.Printf(" - Communication with the Interconnect is no required.\n")
-
-										// Get the data from the local cache
-										DataFromCache := cc.GetDataFromCache(requestAddress)
-
-										// Send the local copy to the Processing Element
-										cc.RespondToProcessingElement(DataFromCache, true)
-							
-										// Release the semaphore
-										<-cc.Semaphore
-									}
-								}
-
-								// MOESI protocol *********************************************************************************
-								if (cc.Protocol == "MOESI") {
-									// The address is not in the local cache
-									if (cacheLineStatus == "I"){
-										cc.CacheMisses++
-										cc.Logger.Printf(" - The address %d is not in the local cache.\n", requestAddress)
+							// Send a response status to the Processing Element
+							cc.RespondToProcessingElement(Data, true)
+						}
+						// The address is in the local cache
+						if (cacheLineStatus == "E" || cacheLineStatus == "M" || cacheLineStatus == "S") {
+							cc.count(&cc.CacheHits)
+							cc.Misses.Hit(requestAddress)
+							cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
+							cc.Logger.Printf(" - Communication with the Interconnect is no required.\n")
+
+							// Get the data from the local cache
+							DataFromCache := cc.GetDataFromCache(requestAddress)
+
+							// Send the local copy to the Processing Element
+							cc.RespondToProcessingElement(DataFromCache, true)
+						}
+					}
+
+					// MOESI protocol *********************************************************************************
+					if (cc.Protocol == "MOESI") {
+						// The address is not in the local cache
+						if (cacheLineStatus == "I"){
+							cc.count(&cc.CacheMisses)
+							cc.Misses.Miss(requestAddress)
+							cc.Logger.Printf(" - The address %d is not in the local cache.\n", requestAddress)
 				
-										// Send a Read-Request to the Interconnect
-										Data, NewStatus := cc.RequestToInterconnect("ReadRequest", "DataResponse", requestAddress)
-								
-										// Update the cache line with the new data and line status
-										cc.WriteDataToCache(requestAddress, Data, NewStatus)
+							// Send a Read-Request to the Interconnect
+							Data, NewStatus := cc.RequestToInterconnect("ReadRequest", "DataResponse", requestAddress)
+					
+							// Update the cache line with the new data and line status
+							cc.WriteDataToCache(requestAddress, Data, NewStatus)
 				
-										// Send a response status to the Processing Element
-										cc.RespondToProcessingElement(Data, true)
-										
-										// Release the semaphore
-										<-cc.Semaphore
-									}
-									// The address is in the local cache
-									if (cacheLineStatus == "E" || cacheLineStatus == "M" || cacheLineStatus == "O") {
-										cc.CacheHits++
-										cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
-										cc.Logger.Printf(" - Communication with the Interconnect is no required.\n")
-
-										// Get the data from the local cache
-										DataFromCache := cc.GetDataFromCache(requestAddress)
-
-										// Send the local copy to the Processing Element
-										cc.RespondToProcessingElement(DataFromCache, true)
-							
-										// Release the semaphore
-										<-cc.Semaphore
-									}
+							// Send a response status to the Processing Element
+							cc.RespondToProcessingElement(Data, true)
+						}
+						// The address is in the local cache
+						if (cacheLineStatus == "E" || cacheLineStatus == "M" || cacheLineStatus == "O") {
+							cc.count(&cc.CacheHits)
+							cc.Misses.Hit(requestAddress)
+							cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
+							cc.Logger.Printf(" - Communication with the Interconnect is no required.\n")
+
+							// Get the data from the local cache
+							DataFromCache := cc.GetDataFromCache(requestAddress)
+
+							// Send the local copy to the Processing Element
+							cc.RespondToProcessingElement(DataFromCache, true)
+						}
 
-									// If the address status is Shared
-									if (cacheLineStatus == "S") {
-										cc.CacheHits++
-										cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
+						// If the address status is Shared
+						if (cacheLineStatus == "S") {
+							cc.count(&cc.CacheHits)
+							cc.Misses.Hit(requestAddress)
+							cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
 
-										// Get the data from the local cache
-										DataFromCache := cc.GetDataFromCache(requestAddress)
-							
-										// Send the local copy to the Processing Element
-										cc.RespondToProcessingElement(DataFromCache, true)
+							// Get the data from the local cache
+							DataFromCache := cc.GetDataFromCache(requestAddress)
 				
-										// Release the semaphore
-										<-cc.Semaphore
-									}
-								}
+							// Send the local copy to the Processing Element
+							cc.RespondToProcessingElement(DataFromCache, true)
+						}
+					}
 			
-							// Write request from the Processing Element
-							case "WRITE":
-								cc.Logger.Printf(" - CC%d is processing a WRITE request.\n", cc.ID)
-								cc.Logger.Printf(" - The Address to write to is: %d.\n", request.Address)
-							
-								// MESI protocol ************************************************************************************
-								if (cc.Protocol == "MESI"){
-									// The address is not in the local cache
-									if (cacheLineStatus == "I"){
-										cc.CacheMisses++
-										cc.Logger.Printf(" - The address %d is not in the local cache.\n", requestAddress)
+				// Write request from the Processing Element, a SC only writes while the reservation holds
+				case "WRITE", "SC":
+					if (request.Type == "SC"){
+						if (!cc.takeReservation(requestAddress)){
+							cc.count(&cc.SCFailures)
+							cc.Logger.Printf(" - CC%d has no reservation on the address %d, the SC fails.\n", cc.ID, requestAddress)
+
+							// Send the failure status to the Processing Element
+							cc.RespondToProcessingElement(0, false)
+							break
+						}
+						cc.count(&cc.SCSuccesses)
+					}
+					cc.Logger.Printf(" - CC%d is processing a %s request.\n", cc.ID, request.Type)
+					cc.Logger.Printf(" - The Address to write to is: %d.\n", request.Address)
 				
-										// Send a Read-Exclusive-Request to the Interconnect
-										Data, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
-									
-										// Update the cache line with the new data and line status
-										cc.WriteDataToCache(requestAddress, requestData, NewStatus)
+					// MESI protocol ************************************************************************************
+					if (cc.Protocol == "MESI"){
+						// The address is not in the local cache
+						if (cacheLineStatus == "I"){
+							cc.count(&cc.CacheMisses)
+							cc.Misses.Miss(requestAddress)
+							cc.Logger.Printf(" - The address %d is not in the local cache.\n", requestAddress)
 				
-										// Send the the status to the Processing Element
-										cc.RespondToProcessingElement(Data, true)
-							
-										// Release the semaphore
-										<-cc.Semaphore
-									}
+							// Send a Read-Exclusive-Request to the Interconnect
+							Data, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
+						
+							// Update the cache line with the new data and line status
+							cc.WriteDataToCache(requestAddress, requestData, NewStatus)
 				
+							// Send the the status to the Processing Element
+							cc.RespondToProcessingElement(Data, true)
+						}
 				
-									// The address is in the local cache and its status is 'Shared'
-									if (cacheLineStatus == "S"){
-										cc.CacheHits++
-										cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
 				
-										// Send a Read-Exclusive-Request to the Interconnect
-										Data, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
-									
-										// Update the cache line with the new data and line status
-										cc.WriteDataToCache(requestAddress, requestData, NewStatus)
+						// The address is in the local cache and its status is 'Shared', the other copies must be invalidated
+						if (cacheLineStatus == "S"){
+							cc.count(&cc.CacheMisses)
+							cc.Misses.Upgrade(requestAddress)
+							cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
 				
-										// Send the the status to the Processing Element
-										cc.RespondToProcessingElement(Data, true)
-							
-										// Release the semaphore
-										<-cc.Semaphore
-									}
+							// Send a Read-Exclusive-Request to the Interconnect
+							Data, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
+						
+							// Update the cache line with the new data and line status
+							cc.WriteDataToCache(requestAddress, requestData, NewStatus)
 				
-									// The address is in the local cache and its status is 'Exclusive'
-									if (cacheLineStatus == "E" || cacheLineStatus == "M"){
-										cc.CacheHits++
-										cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
-										cc.Logger.Printf(" - The new data can be writen without using the Interconnect.\n")
-
-										// Write the data in the local cache
-										cc.WriteDataToCache(requestAddress, requestData, "M")
+							// Send the the status to the Processing Element
+							cc.RespondToProcessingElement(Data, true)
+						}
 				
-										// Send the the status to the Processing Element
-										cc.RespondToProcessingElement(requestData, true)
-							
-										// Release the semaphore
-										<-cc.Semaphore
-									}
-								}
-
-								// MOESI protocol **********************************************************************************************
-								if (cc.Protocol == "MOESI") {
-									// The address is not in the local cache
-									if (cacheLineStatus == "I"){
-										cc.CacheMisses++
-										cc.Logger.Printf(" - The address %d is not in the local cache.\n", requestAddress)
+						// The address is in the local cache and its status is 'Exclusive'
+						if (cacheLineStatus == "E" || cacheLineStatus == "M"){
+							cc.count(&cc.CacheHits)
+							cc.Misses.Hit(requestAddress)
+							cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
+							cc.Logger.Printf(" - The new data can be writen without using the Interconnect.\n")
+
+							// Write the data in the local cache
+							cc.WriteDataToCache(requestAddress, requestData, "M")
+				
+							// Send the the status to the Processing Element
+							cc.RespondToProcessingElement(requestData, true)
+						}
+					}
+
+					// MOESI protocol **********************************************************************************************
+					if (cc.Protocol == "MOESI") {
+						// The address is not in the local cache
+						if (cacheLineStatus == "I"){
+							cc.count(&cc.CacheMisses)
+							cc.Misses.Miss(requestAddress)
+							cc.Logger.Printf(" - The address %d is not in the local cache.\n", requestAddress)
 				
-										// Send a Read-Exclusive-Request to the Interconnect
-										Data, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
-									
-										// Update the cache line with the new data and line status
-										cc.WriteDataToCache(requestAddress, requestData, NewStatus)
+							// Send a Read-Exclusive-Request to the Interconnect
+							Data, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
+						
+							// Update the cache line with the new data and line status
+							cc.WriteDataToCache(requestAddress, requestData, NewStatus)
 				
-										// Send the the status to the Processing Element
-										cc.RespondToProcessingElement(Data, true)
-							
-										// Release the semaphore
-										<-cc.Semaphore
-									}
+							// Send the the status to the Processing Element
+							cc.RespondToProcessingElement(Data, true)
+						}
 				
 				
-									// The address is in the local cache and its status is 'Shared'
-									if (cacheLineStatus == "S"){
-										cc.CacheHits++
-										cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
+						// The address is in the local cache and its status is 'Shared', the other copies must be invalidated
+						if (cacheLineStatus == "S"){
+							cc.count(&cc.CacheMisses)
+							cc.Misses.Upgrade(requestAddress)
+							cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
 				
-										// Send a Read-Exclusive-Request to the Interconnect
-										_, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
-									
-										// Update the cache line with the new data and line status
-										cc.WriteDataToCache(requestAddress, requestData, NewStatus)
+							// Send a Read-Exclusive-Request to the Interconnect
+							_, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
+						
+							// Update the cache line with the new data and line status
+							cc.WriteDataToCache(requestAddress, requestData, NewStatus)
 				
-										// Send the the status to the Processing Element
-										cc.RespondToProcessingElement(requestData, true)
-							
-										// Release the semaphore
-										<-cc.Semaphore
-									}
+							// Send the the status to the Processing Element
+							cc.RespondToProcessingElement(requestData, true)
+						}
 				
-									// The address is in the local cache and its status is 'Exclusive'
-									if (cacheLineStatus == "E" || cacheLineStatus == "M"){
-										cc.CacheHits++
-										cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
-										cc.Logger.Printf(" - The new data can be writen without using the Interconnect.\n")
-
-										// Write the data in the local cache
-										cc.WriteDataToCache(requestAddress, requestData, "M")
+						// The address is in the local cache and its status is 'Exclusive'
+						if (cacheLineStatus == "E" || cacheLineStatus == "M"){
+							cc.count(&cc.CacheHits)
+							cc.Misses.Hit(requestAddress)
+							cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
+							cc.Logger.Printf(" - The new data can be writen without using the Interconnect.\n")
+
+							// Write the data in the local cache
+							cc.WriteDataToCache(requestAddress, requestData, "M")
 				
-										// Send the the status to the Processing Element
-										cc.RespondToProcessingElement(requestData, true)
-							
-										// Release the semaphore
-										<-cc.Semaphore
-									}
+							// Send the the status to the Processing Element
+							cc.RespondToProcessingElement(requestData, true)
+						}
 
-									// The address is in the local cache and its status is 'Exclusive'
-									if (cacheLineStatus == "O"){
-										cc.CacheHits++
-										cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
-										cc.Logger.Printf(" - The new data can be writen without using the Interconnect.\n")
+						// The address is in the local cache and its status is 'Exclusive'
+						if (cacheLineStatus == "O"){
+							cc.count(&cc.CacheMisses)
+							cc.Misses.Upgrade(requestAddress)
+							cc.Logger.Printf(" - The address %d is in the local cache.\n", requestAddress)
+							cc.Logger.Printf(" - The new data can be writen without using the Interconnect.\n")
 
-										// Send a Upgrade-Request to the Interconnect
-										_, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
+							// Send a Upgrade-Request to the Interconnect
+							_, NewStatus := cc.RequestToInterconnect("ReadExclusiveRequest", "Invalidate", requestAddress)
 
-										// Update the cache line with the new data and line status
-										cc.WriteDataToCache(requestAddress, requestData, NewStatus)
+							// Update the cache line with the new data and line status
+							cc.WriteDataToCache(requestAddress, requestData, NewStatus)
 				
-										// Send the the status to the Processing Element
-										cc.RespondToProcessingElement(requestData, true)
-							
-										// Release the semaphore
-										<-cc.Semaphore
-									}
-								}
+							// Send the the status to the Processing Element
+							cc.RespondToProcessingElement(requestData, true)
+						}
+					}
 
-							}
+				}
 
-						// Release the semaphore if the Cache Controller acquired it but didn't use the Interconnect
-						case <-time.After(time.Millisecond * 200):
-							<-cc.Semaphore
-			
-						case <-cc.Quit:
-							return
-					}
+				// The checker sees the caches before another access can change them
+				if (cc.Checker != nil){
+					cc.Checker(utils.CacheAccess{Cache: cc.ID, Kind: request.Type, Address: requestAddress, Value: requestData, Write: stores})
+				}
 
+				// Release the semaphore
+				<-cc.Semaphore
 			}
 	
 		}
@@ -544,7 +633,9 @@ func (cc *CacheController) Run(wg *sync.WaitGroup) {
 				cc.Logger.Printf(" - CC%d received a broadcast message from Interconnect for the address %d.\n", cc.ID, address)
 
 				cc.Logger.Printf(" - The type of request is %s.\n", Type)
+				cc.Misses.Remote(address, Type, addressStatus != "I")
 
+				// The new state is stored before answering, the requester may use the bus again right after the answer
 				// Check if the request from the broadcast is read-request
 				if (Type == "ReadRequest"){
 					// MESI protocol ********************************************************************************
@@ -554,16 +645,14 @@ func (cc *CacheController) Run(wg *sync.WaitGroup) {
 							cc.Logger.Printf(" - The data is not in the local cache.\n")
 							// Tell the Interconnect that this cache does not have the data
 							cc.RespondToBroadcast(false, addressStatus, -1)
-						}
-						// The data is in the local cache
-						if (dataInCache) {
+						}else if (dataInCache) {
+							// The data is in the local cache
 							Data := cc.GetDataFromCache(address)
 							cc.Logger.Printf(" - The data is in the local cache.\n")
-							// Tell the Interconnect that this cache has the data
-							cc.RespondToBroadcast(true, addressStatus, Data)
-							
 							// Change the cache line status to Invalid
 							cc.ChangeCacheLineStatus(address, "S")
+							// Tell the Interconnect that this cache has the data
+							cc.RespondToBroadcast(true, addressStatus, Data)
 						
 						}
 					}
@@ -582,17 +671,17 @@ func (cc *CacheController) Run(wg *sync.WaitGroup) {
 							
 							// Check if the addres status is Modified
 							if (addressStatus == "M") {
-								cc.RespondToBroadcast(true, addressStatus, Data)				
 								// Change the cache line status to Owned
 								cc.ChangeCacheLineStatus(address, "O")
+								cc.RespondToBroadcast(true, addressStatus, Data)
 								
 							} else if (addressStatus == "O") {
 								cc.RespondToBroadcast(true, addressStatus, Data)
 					
 							} else if (addressStatus == "E") {
-								cc.RespondToBroadcast(true, addressStatus, Data)
 								// Change the cache line status to Shared
 								cc.ChangeCacheLineStatus(address, "S")
+								cc.RespondToBroadcast(true, addressStatus, Data)
 					
 							} else if (addressStatus == "S") {
 								cc.RespondToBroadcast(true, addressStatus, Data)
@@ -604,22 +693,23 @@ func (cc *CacheController) Run(wg *sync.WaitGroup) {
 
 				// Check if the request from the broadcast is read-exclusive-request
 				if (Type == "ReadExclusiveRequest"){
+					// Another cache is about to write the linked block
+					cc.ClearReservationOn(address)
+
 					// MESI protocol ********************************************************************************************
 					if (cc.Protocol == "MESI"){
 						// Verify if the data does not exist in the local cache
 						if (addressStatus == "I"){
 							// Tell the Interconnect that this cache does not have the data
 							cc.RespondToBroadcast(false, addressStatus, -1)
-						}
-						// Verify if the data is in the local cache
-						if (dataInCache) {
+						}else if (dataInCache) {
+							// Verify if the data is in the local cache
 							Data := cc.GetDataFromCache(address)
 							cc.Logger.Printf(" - The data is in the local cache.\n")
-							// Tell the Interconnect that this cache has the data
-							cc.RespondToBroadcast(true, addressStatus, Data)
-
 							// Change the cache line status to Invalid
 							cc.ChangeCacheLineStatus(address, "I")
+							// Tell the Interconnect that this cache has the data
+							cc.RespondToBroadcast(true, addressStatus, Data)
 						}
 					}
 
@@ -633,11 +723,10 @@ func (cc *CacheController) Run(wg *sync.WaitGroup) {
 						}else if (dataInCache) {
 							Data := cc.GetDataFromCache(address)
 							cc.Logger.Printf(" - The data is in the local cache.\n")
-							// Tell the Interconnect that this cache has the data
-							cc.RespondToBroadcast(true, addressStatus, Data)
-
 							// Change the cache line status to Invalid
 							cc.ChangeCacheLineStatus(address, "I")
+							// Tell the Interconnect that this cache has the data
+							cc.RespondToBroadcast(true, addressStatus, Data)
 						}
 					}
 		
@@ -0,0 +1,237 @@
+package CacheController
+
+import (
+	"sort"
+	"sync"
+
+	"Backend/utils"
+)
+
+// Kinds of the misses of a cache
+const (
+	MissCompulsory = "Compulsory"
+	MissCapacity   = "Capacity"
+	MissConflict   = "Conflict"
+	MissCoherence  = "Coherence"
+)
+
+// What a cache knows about its blocks to classify its misses, saved in the checkpoints
+type MissState struct {
+	Referenced  map[int]bool             `json:"Referenced"`  // Blocks accessed at least once
+	Invalidated map[int]bool             `json:"Invalidated"` // Blocks lost to an invalidation since their last fill
+	Shared      map[int][]int            `json:"Shared"`      // Words of every block used by other caches while this one shared it, or written since it lost it
+	Shadow      []int                    `json:"Shadow"`      // Blocks of a fully associative cache of the same size and FIFO replacement
+	Addresses   map[int]utils.MissCounts `json:"Addresses"`   // Misses of every address
+}
+
+// Classifier of the misses of a cache, the access goroutine and the broadcast goroutine both use it
+type MissClassifier struct {
+	mutex sync.Mutex
+	lines int
+	state MissState
+}
+
+// Function to create the classifier of a cache of lines blocks that was never accessed
+func NewMissClassifier(lines int) *MissClassifier {
+	classifier := &MissClassifier{lines: lines}
+	classifier.Restore(MissState{})
+	return classifier
+}
+
+// Function to get the block of an address
+func blockOf(address int) int {
+	return address / WordsPerBlock
+}
+
+// Function to count an access that found its block in the cache
+func (classifier *MissClassifier) Hit(address int) {
+	classifier.mutex.Lock()
+	defer classifier.mutex.Unlock()
+	classifier.touch(blockOf(address))
+}
+
+// Function to classify and count an access that didn't find its block, before the block is filled
+func (classifier *MissClassifier) Miss(address int) string {
+	classifier.mutex.Lock()
+	defer classifier.mutex.Unlock()
+	state := &classifier.state
+	block := blockOf(address)
+
+	kind := MissCapacity
+	switch {
+	case !state.Referenced[block]:
+		kind = MissCompulsory
+	case state.Invalidated[block]:
+		kind = MissCoherence
+	case classifier.inShadow(block):
+		kind = MissConflict
+	}
+	classifier.count(address, kind)
+	delete(state.Invalidated, block)
+	classifier.touch(block)
+	return kind
+}
+
+// Function to count a write to a shared copy, it is a coherence miss because the other copies must be invalidated
+func (classifier *MissClassifier) Upgrade(address int) {
+	classifier.mutex.Lock()
+	defer classifier.mutex.Unlock()
+	classifier.count(address, MissCoherence)
+	classifier.touch(blockOf(address))
+}
+
+// Function to record the new state of a block after a fill or a write, a block filled in S is shared with another cache
+func (classifier *MissClassifier) Filled(address int, status string) {
+	classifier.mutex.Lock()
+	defer classifier.mutex.Unlock()
+	block := blockOf(address)
+	delete(classifier.state.Shared, block)
+	if status == "S" {
+		classifier.state.Shared[block] = []int{address}
+	}
+}
+
+// Function to record a request of another cache seen in a broadcast, held tells if this cache has a valid copy
+func (classifier *MissClassifier) Remote(address int, requestType string, held bool) {
+	classifier.mutex.Lock()
+	defer classifier.mutex.Unlock()
+	state := &classifier.state
+	block := blockOf(address)
+	switch {
+	case requestType == "ReadExclusiveRequest" && held:
+		// The copy is lost, only the words written from now on make the next miss true sharing
+		state.Invalidated[block] = true
+		state.Shared[block] = []int{address}
+		for i, shadowBlock := range state.Shadow {
+			if shadowBlock == block {
+				state.Shadow = append(state.Shadow[:i], state.Shadow[i+1:]...)
+				break
+			}
+		}
+	case requestType == "ReadExclusiveRequest" && state.Invalidated[block], requestType == "ReadRequest" && held:
+		classifier.share(block, address)
+	}
+}
+
+// Function to add a word used by another cache to the shared words of a block
+func (classifier *MissClassifier) share(block int, address int) {
+	for _, word := range classifier.state.Shared[block] {
+		if word == address {
+			return
+		}
+	}
+	classifier.state.Shared[block] = append(classifier.state.Shared[block], address)
+}
+
+// Function to add a miss of a kind to the counts of its address
+func (classifier *MissClassifier) count(address int, kind string) {
+	counts := classifier.state.Addresses[address]
+	switch kind {
+	case MissCompulsory:
+		counts.Compulsory++
+	case MissCapacity:
+		counts.Capacity++
+	case MissConflict:
+		counts.Conflict++
+	case MissCoherence:
+		counts.Coherence++
+		// The miss is true sharing when another cache used the word itself, not only its block
+		trueSharing := false
+		for _, word := range classifier.state.Shared[blockOf(address)] {
+			trueSharing = trueSharing || word == address
+		}
+		if trueSharing {
+			counts.TrueSharing++
+		} else {
+			counts.FalseSharing++
+		}
+	}
+	classifier.state.Addresses[address] = counts
+}
+
+// Function to know if the fully associative cache holds a block
+func (classifier *MissClassifier) inShadow(block int) bool {
+	for _, shadowBlock := range classifier.state.Shadow {
+		if shadowBlock == block {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to mark a block as accessed and bring it to the fully associative cache, replacing its oldest block when it is full
+func (classifier *MissClassifier) touch(block int) {
+	state := &classifier.state
+	state.Referenced[block] = true
+	if classifier.inShadow(block) {
+		return
+	}
+	if len(state.Shadow) == classifier.lines {
+		state.Shadow = state.Shadow[1:]
+	}
+	state.Shadow = append(state.Shadow, block)
+}
+
+// Function to get the misses of every address with misses, in order, and their sum
+func (classifier *MissClassifier) Counts() ([]utils.AddressMisses, utils.MissCounts) {
+	classifier.mutex.Lock()
+	defer classifier.mutex.Unlock()
+	addresses := []utils.AddressMisses{}
+	total := utils.MissCounts{}
+	for address, counts := range classifier.state.Addresses {
+		addresses = append(addresses, utils.AddressMisses{Address: address, MissCounts: counts})
+		total = AddMissCounts(total, counts)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].Address < addresses[j].Address })
+	return addresses, total
+}
+
+// Function to add two counts of misses
+func AddMissCounts(a utils.MissCounts, b utils.MissCounts) utils.MissCounts {
+	return utils.MissCounts{
+		Compulsory:   a.Compulsory + b.Compulsory,
+		Capacity:     a.Capacity + b.Capacity,
+		Conflict:     a.Conflict + b.Conflict,
+		Coherence:    a.Coherence + b.Coherence,
+		TrueSharing:  a.TrueSharing + b.TrueSharing,
+		FalseSharing: a.FalseSharing + b.FalseSharing,
+	}
+}
+
+// Function to get a copy of the state of the classifier
+func (classifier *MissClassifier) State() MissState {
+	classifier.mutex.Lock()
+	defer classifier.mutex.Unlock()
+	return copyMissState(classifier.state)
+}
+
+// Function to replace the state of the classifier, the missing maps of an older checkpoint start empty
+func (classifier *MissClassifier) Restore(state MissState) {
+	classifier.mutex.Lock()
+	defer classifier.mutex.Unlock()
+	classifier.state = copyMissState(state)
+}
+
+// Function to copy a state of a classifier, the copy can be changed without changing the original
+func copyMissState(state MissState) MissState {
+	copied := MissState{
+		Referenced:  map[int]bool{},
+		Invalidated: map[int]bool{},
+		Shared:      map[int][]int{},
+		Shadow:      append([]int{}, state.Shadow...),
+		Addresses:   map[int]utils.MissCounts{},
+	}
+	for block, referenced := range state.Referenced {
+		copied.Referenced[block] = referenced
+	}
+	for block, invalidated := range state.Invalidated {
+		copied.Invalidated[block] = invalidated
+	}
+	for block, words := range state.Shared {
+		copied.Shared[block] = append([]int{}, words...)
+	}
+	for address, counts := range state.Addresses {
+		copied.Addresses[address] = counts
+	}
+	return copied
+}
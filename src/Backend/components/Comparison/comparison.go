@@ -0,0 +1,334 @@
+package comparison
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Configuration compared against the others
+type Variant struct {
+	Name        string `json:"Name"`
+	Protocol    string `json:"Protocol"`
+	Consistency string `json:"Consistency"`
+}
+
+// Options of a comparison
+type Options struct {
+	Base     MultiprocessingSystem.Config // Cores, programs or traces, workload and initial memory shared by every variant
+	Variants []Variant                    // Configurations to compare, the first one is the baseline
+	Seed     int64                        // Seed of the scheduler, the programs and the initial memory
+	MaxSteps int                          // Scheduler steps before a run is abandoned
+}
+
+// Function to get the options that compare MESI and MOESI under SC
+func DefaultOptions() Options {
+	base := MultiprocessingSystem.DefaultConfig()
+	return Options{
+		Base: base,
+		Variants: []Variant{
+			{Name: "MESI", Protocol: "MESI", Consistency: "SC"},
+			{Name: "MOESI", Protocol: "MOESI", Consistency: "SC"},
+		},
+		Seed:     1,
+		MaxSteps: 10000,
+	}
+}
+
+// Metrics of a run
+type Metrics struct {
+	CacheHits             int     `json:"CacheHits"`
+	CacheMisses           int     `json:"CacheMisses"`
+	MissRate              float64 `json:"MissRate"`
+	BusTransactions       int     `json:"BusTransactions"`
+	ReadRequests          int     `json:"ReadRequests"`
+	ReadExclusiveRequests int     `json:"ReadExclusiveRequests"`
+	DataResponses         int     `json:"DataResponses"`
+	Invalidates           int     `json:"Invalidates"`
+	MemoryReads           int     `json:"MemoryReads"`
+	MemoryWrites          int     `json:"MemoryWrites"`
+	MemoryAccesses        int     `json:"MemoryAccesses"` // Reads and writes of the Main Memory
+	Energy                float64 `json:"Energy"`
+	SCFailures            int     `json:"SCFailures"`
+}
+
+// Function to get the metrics of a run from the results of the system
+func NewMetrics(results utils.MultiprocessingSystemResults) Metrics {
+	return Metrics{
+		CacheHits:             results.CacheHits,
+		CacheMisses:           results.CacheMisses,
+		MissRate:              results.MissRate,
+		BusTransactions:       results.ReadRequests + results.ReadExclusiveRequests,
+		ReadRequests:          results.ReadRequests,
+		ReadExclusiveRequests: results.ReadExclusiveRequests,
+		DataResponses:         results.DataResponses,
+		Invalidates:           results.Invalidates,
+		MemoryReads:           results.MemoryReads,
+		MemoryWrites:          results.MemoryWrites,
+		MemoryAccesses:        results.MemoryReads + results.MemoryWrites,
+		Energy:                results.PowerConsumption,
+		SCFailures:            results.SCFailures,
+	}
+}
+
+// Name and value of a metric, in the order of the report
+type metricValue struct {
+	Name  string
+	Value float64
+}
+
+// Function to list the metrics in the order they are reported
+func (metrics Metrics) values() []metricValue {
+	return []metricValue{
+		{"CacheHits", float64(metrics.CacheHits)},
+		{"CacheMisses", float64(metrics.CacheMisses)},
+		{"MissRate", metrics.MissRate},
+		{"BusTransactions", float64(metrics.BusTransactions)},
+		{"ReadRequests", float64(metrics.ReadRequests)},
+		{"ReadExclusiveRequests", float64(metrics.ReadExclusiveRequests)},
+		{"DataResponses", float64(metrics.DataResponses)},
+		{"Invalidates", float64(metrics.Invalidates)},
+		{"MemoryReads", float64(metrics.MemoryReads)},
+		{"MemoryWrites", float64(metrics.MemoryWrites)},
+		{"MemoryAccesses", float64(metrics.MemoryAccesses)},
+		{"Energy", metrics.Energy},
+		{"SCFailures", float64(metrics.SCFailures)},
+	}
+}
+
+// Function to get the names of the metrics in the order they are reported
+func MetricNames() []string {
+	names := []string{}
+	for _, metric := range (Metrics{}).values() {
+		names = append(names, metric.Name)
+	}
+	return names
+}
+
+// Function to get every metric by its name
+func (metrics Metrics) Map() map[string]float64 {
+	values := map[string]float64{}
+	for _, metric := range metrics.values() {
+		values[metric.Name] = metric.Value
+	}
+	return values
+}
+
+// Results of a variant
+type Row struct {
+	Variant      Variant            `json:"Variant"`
+	Metrics      Metrics            `json:"Metrics"`
+	Delta        map[string]float64 `json:"Delta"`        // Difference with the baseline
+	DeltaPercent map[string]float64 `json:"DeltaPercent"` // Difference with the baseline as a percentage, 0 when the baseline is 0
+	FinalMemory  []int              `json:"FinalMemory"`  // Value of every address as the processors see it
+}
+
+// Side-by-side results of every variant
+type Report struct {
+	Baseline        string     `json:"Baseline"`
+	Cores           int        `json:"Cores"`
+	Seed            int64      `json:"Seed"`
+	Programs        [][]string `json:"Programs"`
+	InitialMemory   []uint32   `json:"InitialMemory"`
+	Rows            []Row      `json:"Rows"`
+	SameFinalMemory bool       `json:"SameFinalMemory"` // Every variant ended with the same memory
+}
+
+// Prepare fixes the programs and the initial memory from a seed, so every run of the configuration
+// gets the same inputs, and removes the latencies and the console output of the initialization.
+func Prepare(base MultiprocessingSystem.Config, seed int64) (MultiprocessingSystem.Config, error) {
+	generator := rand.New(rand.NewSource(seed))
+	initialWords := map[int]uint32{}
+	// The PEs without a program get a generated one, never the files of the last run
+	if base.Traces == nil && base.UsesProgramFiles() {
+		workload := utils.DefaultWorkloadOptions("uniform")
+		workload.Instructions = base.InstructionsPerCore
+		if base.Workload != nil {
+			workload = *base.Workload
+		}
+		workload.Seed = seed
+		instructions, err := utils.GenerateWorkload(base.Cores, workload)
+		if err != nil {
+			return base, err
+		}
+		generated := ProgramsOf(instructions)
+		initialWords = utils.WorkloadInitialMemory(base.Cores, workload)
+		if base.Programs == nil {
+			base.Programs = make([][]string, base.Cores)
+		}
+		base.Programs = append([][]string{}, base.Programs...)
+		for id := range base.Programs {
+			if base.Programs[id] == nil {
+				base.Programs[id] = generated[id]
+			}
+		}
+	}
+	if base.InitialMemory == nil {
+		base.InitialMemory = make([]uint32, MultiprocessingSystem.MemorySize)
+		for address := range base.InitialMemory {
+			base.InitialMemory[address] = uint32(generator.Intn(51))
+		}
+		// The generated workload needs some words with a known value, like its free locks
+		for address, value := range initialWords {
+			base.InitialMemory[address] = value
+		}
+	}
+	base.Latency = utils.NoLatencies()
+	base.Quiet = true
+	return base, nil
+}
+
+// Function to write generated instructions as the text of the programs
+func ProgramsOf(instructions [][]utils.Instruction) [][]string {
+	programs := [][]string{}
+	for _, program := range instructions {
+		lines := []string{}
+		for _, instruction := range program {
+			if instruction.Type == "INC" || instruction.Type == "FENCE" {
+				lines = append(lines, instruction.Type)
+			} else {
+				lines = append(lines, fmt.Sprintf("%s %d", instruction.Type, instruction.Address))
+			}
+		}
+		programs = append(programs, lines)
+	}
+	return programs
+}
+
+// RunConfig runs a system to completion with a seeded scheduler and returns its metrics and final memory.
+func RunConfig(cfg MultiprocessingSystem.Config, seed int64, maxSteps int) (Metrics, []int, error) {
+	// The log files go to a temporary folder
+	logDirectory, err := os.MkdirTemp("", "comparison-logs")
+	if err != nil {
+		return Metrics{}, nil, err
+	}
+	defer os.RemoveAll(logDirectory)
+	cfg.LogDirectory = logDirectory
+
+	if err := cfg.Validate(); err != nil {
+		return Metrics{}, nil, err
+	}
+	mps, err := MultiprocessingSystem.StartWithConfig(cfg)
+	if err != nil {
+		return Metrics{}, nil, err
+	}
+	defer mps.Stop()
+
+	complete, err := mps.RunSeeded(seed, maxSteps)
+	if err != nil {
+		return Metrics{}, nil, err
+	}
+	if !complete {
+		return Metrics{}, nil, fmt.Errorf("the programs didn't finish after %d steps", maxSteps)
+	}
+	memory := []int{}
+	for address := 0; address < MultiprocessingSystem.MemorySize; address++ {
+		memory = append(memory, mps.CoherentValue(address))
+	}
+	return NewMetrics(mps.Results()), memory, nil
+}
+
+// Compare runs the same programs and initial memory under every variant and reports the differences
+// with the first one.
+func Compare(options Options) (*Report, error) {
+	if len(options.Variants) == 0 {
+		return nil, fmt.Errorf("there are no configurations to compare")
+	}
+	if options.MaxSteps <= 0 {
+		options.MaxSteps = DefaultOptions().MaxSteps
+	}
+	base, err := Prepare(options.Base, options.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Baseline:        options.Variants[0].Name,
+		Cores:           base.Cores,
+		Seed:            options.Seed,
+		Programs:        base.Programs,
+		InitialMemory:   base.InitialMemory,
+		SameFinalMemory: true,
+	}
+	for _, variant := range options.Variants {
+		if variant.Name == "" {
+			variant.Name = variant.Protocol + "/" + variant.Consistency
+		}
+		cfg := base
+		cfg.Protocol = variant.Protocol
+		cfg.Consistency = variant.Consistency
+		metrics, memory, err := RunConfig(cfg, options.Seed, options.MaxSteps)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", variant.Name, err)
+		}
+		report.Rows = append(report.Rows, Row{Variant: variant, Metrics: metrics, FinalMemory: memory})
+	}
+
+	// Differences with the baseline
+	baseline := report.Rows[0].Metrics.Map()
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		row.Delta = map[string]float64{}
+		row.DeltaPercent = map[string]float64{}
+		for name, value := range row.Metrics.Map() {
+			row.Delta[name] = value - baseline[name]
+			if baseline[name] != 0 {
+				row.DeltaPercent[name] = (value - baseline[name]) / baseline[name] * 100
+			} else {
+				row.DeltaPercent[name] = 0
+			}
+		}
+		if fmt.Sprint(row.FinalMemory) != fmt.Sprint(report.Rows[0].FinalMemory) {
+			report.SameFinalMemory = false
+		}
+	}
+	return report, nil
+}
+
+// WriteTable writes the report as a table, one column per variant with the difference to the baseline.
+func (report *Report) WriteTable(writer io.Writer) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := []string{"Metric"}
+	for _, row := range report.Rows {
+		header = append(header, row.Variant.Name)
+	}
+	fmt.Fprintln(table, strings.Join(header, "\t")+"\t")
+	for i, metric := range report.Rows[0].Metrics.values() {
+		cells := []string{metric.Name}
+		for r, row := range report.Rows {
+			value := row.Metrics.values()[i].Value
+			cell := formatValue(value)
+			if r > 0 {
+				cell += fmt.Sprintf(" (%+g", math.Round(row.Delta[metric.Name]*100)/100)
+				if report.Rows[0].Metrics.values()[i].Value != 0 {
+					cell += fmt.Sprintf(", %+.1f%%", row.DeltaPercent[metric.Name])
+				}
+				cell += ")"
+			}
+			cells = append(cells, cell)
+		}
+		fmt.Fprintln(table, strings.Join(cells, "\t")+"\t")
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	if !report.SameFinalMemory {
+		fmt.Fprintln(writer, "Warning: the variants ended with different memory contents")
+	}
+	return nil
+}
+
+// Function to write a metric without useless decimals
+func formatValue(value float64) string {
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%.2f", value)
+}
@@ -3,6 +3,7 @@ package interconnect
 import (
     "log"
     "os"
+    "reflect"
     "sync"
 	"time"
 	"encoding/json"
@@ -27,13 +28,17 @@ type Interconnect struct {
 	Logs		utils.QueueS
 	Status string
 	PowerConsumption float64
+	counters sync.Mutex				// Protects the counters and the power consumption, the metrics read them while the bus is in use
 	ReadRequests int
 	ReadExclusiveRequests int
 	DataResponses int
 	Invalidates int
 	MemoryReads int
 	MemoryWrites int
-
+	Latency utils.Latencies
+	Recorder *utils.TraceRecorder		// Keeps the bus transactions as a trace, nil disables it
+	Events *utils.EventStream			// Receives the bus transactions and the memory accesses, nil disables them
+	pending utils.BusTransaction	// Transaction being served, recorded with its response
 }
 
 func New(
@@ -59,8 +64,10 @@ func New(
 	// Create a new queue to store the Bus Transactions
 	transactionsQueue := utils.QueueS{}
 
-	// Create a new queue to store the Bus logs
+	// Create a new queue to store the Bus logs, it starts with the entry of the bus ready before Run reads it.
+	// An Interconnect restored from a checkpoint replaces it with its saved bus log, that already has it.
 	busQueue := utils.QueueS{}
+	busQueue.Enqueue(fmt.Sprintf("%s - Ready to handle bus requests.", time.Now().Format("15:04:05")))
 
     return &Interconnect{
         RequestChannelsCacheController: requestChannelsCC,
@@ -80,6 +87,7 @@ func New(
 		ReadExclusiveRequests: 0,
 		DataResponses: 0,
 		Invalidates: 0,
+		Latency: utils.DefaultLatencies(),
     }, nil
 }
 
@@ -115,6 +123,43 @@ func (ic *Interconnect) About()(string, error){
 
 	return jsonString, nil
 }
+
+// Counters of the bus transactions of the Interconnect at one moment
+type Counters struct {
+	ReadRequests          int
+	ReadExclusiveRequests int
+	DataResponses         int
+	Invalidates           int
+	MemoryReads           int
+	MemoryWrites          int
+	PowerConsumption      float64
+}
+
+// Function to add one to a counter of the bus and its power consumption, a nil counter only adds the power
+func (ic *Interconnect) count(counter *int, power float64) {
+	ic.counters.Lock()
+	defer ic.counters.Unlock()
+	if counter != nil {
+		*counter++
+	}
+	ic.PowerConsumption += power
+}
+
+// Function to read the counters of the bus while the Interconnect may be serving a transaction
+func (ic *Interconnect) Counters() Counters {
+	ic.counters.Lock()
+	defer ic.counters.Unlock()
+	return Counters{
+		ReadRequests:          ic.ReadRequests,
+		ReadExclusiveRequests: ic.ReadExclusiveRequests,
+		DataResponses:         ic.DataResponses,
+		Invalidates:           ic.Invalidates,
+		MemoryReads:           ic.MemoryReads,
+		MemoryWrites:          ic.MemoryWrites,
+		PowerConsumption:      ic.PowerConsumption,
+	}
+}
+
 // Function to send a write request to Main Memory
 func (ic *Interconnect) WriteToMainMemory(address int, data int) bool{
 	// Create a struct for the request
@@ -125,8 +170,7 @@ func (ic *Interconnect) WriteToMainMemory(address int, data int) bool{
 	}
 	ic.Logs.Enqueue(fmt.Sprintf("%s - Writing the value %d to memory address %d.", time.Now().Format("15:04:05"), data, address))
 	ic.Transactions.Enqueue(time.Now().Format("15:04:05") + "-write-to-memory")
-	ic.MemoryWrites++
-	ic.PowerConsumption += 3.0
+	ic.count(&ic.MemoryWrites, 3.0)
 
 	// Send the request to the Main Memory
 	ic.RequestChannelMainMemory <- requestMainMemory
@@ -137,6 +181,7 @@ func (ic *Interconnect) WriteToMainMemory(address int, data int) bool{
 	responseMainMemory := <- ic.ResponseChannelMainMemory
 	requestStatus := responseMainMemory.Status
 	ic.Logs.Enqueue(fmt.Sprintf("%s - Finished writing in memory address %d.", time.Now().Format("15:04:05"), address))
+	ic.Events.Publish(utils.EventMemoryAccess, utils.MemoryAccessEvent{Address: address, Value: data, Write: true})
 	
 	return requestStatus
 }
@@ -151,8 +196,7 @@ func (ic *Interconnect) ReadFromMainMemory(address int) int{
 	}
 	ic.Logs.Enqueue(fmt.Sprintf("%s - Reading address %d from memory...", time.Now().Format("15:04:05"), address))
 	ic.Transactions.Enqueue(time.Now().Format("15:04:05") + "-read-from-memory")
-	ic.MemoryReads++
-	ic.PowerConsumption += 2.0
+	ic.count(&ic.MemoryReads, 2.0)
 
 	// Send the request to the Main Memory
 	ic.RequestChannelMainMemory <- requestMainMemory
@@ -162,6 +206,7 @@ func (ic *Interconnect) ReadFromMainMemory(address int) int{
 	responseMainMemory := <- ic.ResponseChannelMainMemory
 	dataResponse := int(responseMainMemory.Value)
 	ic.Logs.Enqueue(fmt.Sprintf("%s - Received the value %d from memory.", time.Now().Format("15:04:05"), dataResponse))
+	ic.Events.Publish(utils.EventMemoryAccess, utils.MemoryAccessEvent{Address: address, Value: dataResponse})
 
 	ic.Logger.Printf(" - IC received the value %d from Main Memory.\n", dataResponse)
 	return dataResponse
@@ -175,17 +220,29 @@ func (ic *Interconnect) SendDataResponseToCacheController(ccID int, data int, st
 		NewStatus: status,
 	}
 
-	// Send it to the Cache Controller who requested the data
-	ic.ResponseChannelsCacheController[ccID] <- dataResponse
+	// Count the response before sending it, the requester may finish its step right after it
 	ic.Logs.Enqueue(fmt.Sprintf("%s - Sent data response to CC%d.", time.Now().Format("15:04:05"), ccID))
 	ic.Transactions.Enqueue(time.Now().Format("15:04:05") + "-data-response")
-	ic.DataResponses++
-	ic.PowerConsumption += 0.8
+	ic.count(&ic.DataResponses, 0.8)
+	ic.recordResponse(dataResponse)
+
+	// Send it to the Cache Controller who requested the data
+	ic.ResponseChannelsCacheController[ccID] <- dataResponse
 
 	ic.Logger.Printf(" - IC sent a data response back to CC%d.\n", ccID)
 	ic.Logger.Printf(" - Sent the value %d to CC%d.\n", dataResponse.Data, ccID)
 }
 
+// Function to record the transaction being served with the response sent to the requester
+func (ic *Interconnect) recordResponse(response utils.ResponseInterconnect) {
+	transaction := ic.pending
+	transaction.Data = response.Data
+	transaction.NewStatus = response.NewStatus
+	transaction.MemoryReads = ic.MemoryReads - transaction.MemoryReads
+	transaction.MemoryWrites = ic.MemoryWrites - transaction.MemoryWrites
+	ic.Events.Publish(utils.EventBusTransaction, ic.Recorder.RecordBusTransaction(transaction))
+}
+
 // Function to send a Status to an specific Cache Controller
 func (ic *Interconnect) SendStatusResponseToCacheController(ccID int, status string) {
 	// Prepare the data response struct
@@ -194,6 +251,7 @@ func (ic *Interconnect) SendStatusResponseToCacheController(ccID int, status str
 	}
 
 	// Send it to the Cache Controller who requested the data
+	ic.recordResponse(dataResponse)
 	ic.ResponseChannelsCacheController[ccID] <- dataResponse
 	ic.Logger.Printf(" - IC sent a status response back to CC%d.\n", ccID)
 	ic.Logger.Printf(" - Sent a confirmation status to CC%d.\n", ccID)
@@ -239,6 +297,8 @@ func (ic *Interconnect) BroadcastMessage(ccID int, requestType string, AR string
 	}
 
 	var wg sync.WaitGroup
+	// Bus log entries of every CC, they are added to the bus logs in order once every CC answered
+	entries := make([][]string, len(ic.RequestChannelsBroadcast))
 
 	for cc := range ic.RequestChannelsBroadcast {
 		// Ask everyone exept the CC the IC is attending
@@ -254,7 +314,7 @@ func (ic *Interconnect) BroadcastMessage(ccID int, requestType string, AR string
 			// Send the broadcast message to all the Cache Controllers
 			ic.RequestChannelsBroadcast[cc] <- broadcastRequest
 			ic.Logger.Printf(" - IC sent a broadcast %s to CC%d.\n", requestType, cc)
-			ic.Logs.Enqueue(fmt.Sprintf("%s - IC sent a broadcast %s to CC%d.", time.Now().Format("15:04:05"), requestType, cc))
+			entries[cc] = append(entries[cc], fmt.Sprintf("%s - IC sent a broadcast %s to CC%d.", time.Now().Format("15:04:05"), requestType, cc))
 		
 			// Wait for a response from the Cache Controller
 			// Update counters inside a critical section
@@ -268,37 +328,37 @@ func (ic *Interconnect) BroadcastMessage(ccID int, requestType string, AR string
 
 
 			// For each core broadcast message, sum the request power consumption
-			ic.PowerConsumption += BPC1
-			ic.PowerConsumption += BPC2
+			ic.count(nil, BPC1)
+			ic.count(nil, BPC2)
 
 			if !Matched {
 				ic.Logger.Printf(" - CC%d doesn't have the data.\n", cc)
-				ic.Logs.Enqueue(fmt.Sprintf("%s - CC%d doesn't have the data.", time.Now().Format("15:04:05"), cc))
+				entries[cc] = append(entries[cc], fmt.Sprintf("%s - CC%d doesn't have the data.", time.Now().Format("15:04:05"), cc))
 				return
 			}
 
 			switch BlockStatus {
 			case "M":
 				ic.Logger.Printf(" - CC%d has the data and its status is Modified.\n", cc)
-				ic.Logs.Enqueue(fmt.Sprintf("%s - CC%d has the data and its status is 'Modified'.", time.Now().Format("15:04:05"), cc))
+				entries[cc] = append(entries[cc], fmt.Sprintf("%s - CC%d has the data and its status is 'Modified'.", time.Now().Format("15:04:05"), cc))
 				Found = true
 				Data = broadcastResponse.Data
 				M++
 			case "O":
 				ic.Logger.Printf(" - CC%d has the data and its status is Owned.\n", cc)
-				ic.Logs.Enqueue(fmt.Sprintf("%s - CC%d has the data and its status is 'Owned'.", time.Now().Format("15:04:05"), cc))
+				entries[cc] = append(entries[cc], fmt.Sprintf("%s - CC%d has the data and its status is 'Owned'.", time.Now().Format("15:04:05"), cc))
 				Found = true
 				Data = broadcastResponse.Data
 				O++
 			case "E":
 				ic.Logger.Printf(" - CC%d has the data and its status is Exclusive.\n", cc)
-				ic.Logs.Enqueue(fmt.Sprintf("%s - CC%d has the data and its status is 'Exclusive'.", time.Now().Format("15:04:05"), cc))
+				entries[cc] = append(entries[cc], fmt.Sprintf("%s - CC%d has the data and its status is 'Exclusive'.", time.Now().Format("15:04:05"), cc))
 				Found = true
 				Data = broadcastResponse.Data
 				E++
 			case "S":
 				ic.Logger.Printf(" - CC%d has the data and its status is Shared.\n", cc)
-				ic.Logs.Enqueue(fmt.Sprintf("%s - CC%d has the data and its status is 'Shared'.", time.Now().Format("15:04:05"), cc))
+				entries[cc] = append(entries[cc], fmt.Sprintf("%s - CC%d has the data and its status is 'Shared'.", time.Now().Format("15:04:05"), cc))
 				Found = true
 				Data = broadcastResponse.Data
 				S++
@@ -308,6 +368,11 @@ func (ic *Interconnect) BroadcastMessage(ccID int, requestType string, AR string
 
 	// Wait for all goroutines to finish
 	wg.Wait()
+	for _, ccEntries := range entries {
+		for _, entry := range ccEntries {
+			ic.Logs.Enqueue(entry)
+		}
+	}
 
 	// Handle the statuses
 	if Found {
@@ -344,15 +409,29 @@ func (ic *Interconnect) handleRequestFromCC(ccID int, request utils.RequestInter
 	// Send a broadcast message to the IDLE Cache Controllers
 	RemoteFound, RemoteStatus, RemoteData := ic.BroadcastMessage(ccID, requestType, requestAR, requestAddress)
 	ic.Logger.Printf(" - IC finished broadcast.\n")
+
+	// The transaction is recorded with its response, the memory counters keep their values at the start
+	remoteStatus := "I"
+	if (RemoteFound) {
+		remoteStatus = RemoteStatus
+	}
+	ic.pending = utils.BusTransaction{
+		Requester: ccID,
+		Type: requestType,
+		AR: requestAR,
+		Address: requestAddress,
+		RemoteStatus: remoteStatus,
+		MemoryReads: ic.MemoryReads,
+		MemoryWrites: ic.MemoryWrites,
+	}
 	switch requestType {
 	// Handle Read-Request
 	case "ReadRequest":
 		ic.Transactions.Enqueue(timeString + "-read-request")
-		ic.ReadRequests++
-		// Add the power consumption for the requesting cache
-		ic.PowerConsumption += 1.0
+		// Count the request with the power consumption for the requesting cache
+		ic.count(&ic.ReadRequests, 1.0)
 
-		time.Sleep(3 * time.Second)
+		time.Sleep(ic.Latency.BusTransaction)
 		// MESI protocol ***************************************************************************************************************
 		if (ic.Protocol == "MESI"){
 			// The data was found in a remote cache
@@ -412,11 +491,10 @@ func (ic *Interconnect) handleRequestFromCC(ccID int, request utils.RequestInter
 	// Handle Read-Request
 	case "ReadExclusiveRequest":
 		ic.Transactions.Enqueue(timeString + "-read-exclusive-request")
-		ic.ReadExclusiveRequests++
-		// Add the power consumption for the requesting cache
-		ic.PowerConsumption += 1.2
+		// Count the request with the power consumption for the requesting cache
+		ic.count(&ic.ReadExclusiveRequests, 1.2)
 
-		time.Sleep(3 * time.Second)
+		time.Sleep(ic.Latency.BusTransaction)
 		// MESI protocol *****************************************************************************************************************
 		if (ic.Protocol == "MESI"){
 			// The data was found in a remote cache
@@ -439,7 +517,7 @@ func (ic *Interconnect) handleRequestFromCC(ccID int, request utils.RequestInter
 				// The Action Required is Invalidate
 				if (requestAR == "Invalidate"){
 					ic.Transactions.Enqueue(timeString + "-invalidate")
-					ic.Invalidates++
+					ic.count(&ic.Invalidates, 0)
 					ic.Logs.Enqueue(fmt.Sprintf("%s - Sent Invalidate.", time.Now().Format("15:04:05")))
 					// The data was found with a 'S' status
 					if (RemoteStatus == "S" || RemoteStatus == "E"){
@@ -485,15 +563,16 @@ func (ic *Interconnect) handleRequestFromCC(ccID int, request utils.RequestInter
 						// Send the Data provided by the remote cache back to the requesting Cache Controller
 						ic.SendDataResponseToCacheController(ccID, RemoteData, "M")
 					}
+					// The data was found with a 'S' status
 					if (RemoteStatus == "S"){
 						// Send the Data provided by the remote cache back to the requesting Cache Controller
-						ic.SendDataResponseToCacheController(ccID, RemoteData, "E")
+						ic.SendDataResponseToCacheController(ccID, RemoteData, "M")
 					}
 				}
 				// The Action Required is Invalidate
 				if (requestAR == "Invalidate"){
 					ic.Transactions.Enqueue(timeString + "-invalidate")
-					ic.Invalidates++
+					ic.count(&ic.Invalidates, 0)
 					ic.Logs.Enqueue(fmt.Sprintf("%s - Sent Invalidate.", time.Now().Format("15:04:05")))
 					// The data was found with a 'S' status
 					if (RemoteStatus == "S" || RemoteStatus == "O" || RemoteStatus == "M" || RemoteStatus == "E"){
@@ -519,29 +598,25 @@ func (ic *Interconnect) handleRequestFromCC(ccID int, request utils.RequestInter
 
 func (ic *Interconnect) Run(wg *sync.WaitGroup) {
 	ic.Logger.Printf(" - IC is running.\n")
-	ic.Logs.Enqueue(fmt.Sprintf("%s - Ready to handle bus requests.", time.Now().Format("15:04:05")))
 
-	for {
-		select {
-		case request, ok := <-ic.RequestChannelsCacheController[0]:
-			if ok {
-				ic.handleRequestFromCC(0, request)
-			}
-
-		case request, ok := <-ic.RequestChannelsCacheController[1]:
-			if ok {
-				ic.handleRequestFromCC(1, request)
-			}
+	// Listen to every Cache Controller and to the termination signal at the same time
+	cases := make([]reflect.SelectCase, len(ic.RequestChannelsCacheController)+1)
+	for cc, channel := range ic.RequestChannelsCacheController {
+		cases[cc] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channel)}
+	}
+	quitCase := len(ic.RequestChannelsCacheController)
+	cases[quitCase] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ic.Quit)}
 
-		case request, ok := <-ic.RequestChannelsCacheController[2]:
-			if ok {
-				ic.handleRequestFromCC(2, request)
-			}
+	for {
+		chosen, value, ok := reflect.Select(cases)
 
 		// Wait for termination
-		case <-ic.Quit:
+		if chosen == quitCase {
 			ic.Logger.Printf(" - IC has received an external signal to terminate.\n")
 			return
 		}
+		if ok {
+			ic.handleRequestFromCC(chosen, value.Interface().(utils.RequestInterconnect))
+		}
 	}
 }
\ No newline at end of file
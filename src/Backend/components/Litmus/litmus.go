@@ -0,0 +1,321 @@
+package litmus
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	assembler "Backend/components/Assembler"
+)
+
+// Litmus test: small programs for several cores, the initial memory and a condition on the final state
+type Test struct {
+	Name      string         // Short name of the test (SB, MP, ...)
+	Doc       string         // One line description
+	Locations []string       // Symbolic memory locations, in order of appearance
+	Addresses map[string]int // Memory address of every location
+	Init      map[string]int // Initial value of the locations, the rest of the memory starts at 0
+	Threads   [][]string     // Program of every core, with the locations replaced by addresses
+	Condition []Term         // Terms of the "exists" condition, all of them must hold
+}
+
+// Term of a condition: a load of a thread ("1:r0=0") or a final memory value ("x=1")
+type Term struct {
+	Thread   int    // Thread of the load, -1 for a memory location
+	Load     int    // Position of the load in the thread (r0 is the first READ or LL)
+	Location string // Memory location when Thread is -1
+	Value    int    // Expected value
+}
+
+// Built-in tests, written in the same text format accepted by Parse
+var catalog = []string{
+	`name SB
+doc Store buffering: each core writes a flag and then reads the flag of the other core
+init x=0 y=0
+P0: INC; WRITE x; READ y
+P1: INC; WRITE y; READ x
+exists 0:r0=0 /\ 1:r0=0`,
+
+	`name MP
+doc Message passing: the data is written before the flag, the reader sees the flag but not the data
+init x=0 y=0
+P0: INC; WRITE x; WRITE y
+P1: READ y; READ x
+exists 1:r0=1 /\ 1:r1=0`,
+
+	`name LB
+doc Load buffering: each core reads before writing, both reads see the other write
+init x=0 y=0
+P0: READ x; INC; WRITE y
+P1: READ y; INC; WRITE x
+exists 0:r0=1 /\ 1:r0=1`,
+
+	`name IRIW
+doc Independent reads of independent writes: two readers see two writes in opposite orders
+init x=0 y=0
+P0: INC; WRITE x
+P1: INC; WRITE y
+P2: READ x; READ y
+P3: READ y; READ x
+exists 2:r0=1 /\ 2:r1=0 /\ 3:r0=1 /\ 3:r1=0`,
+
+	`name 2+2W
+doc Two writes per core to two locations in opposite orders, the first writes win
+init x=0 y=0
+P0: INC; WRITE x; INC; WRITE y
+P1: INC; WRITE y; INC; WRITE x
+exists x=1 /\ y=1`,
+}
+
+// Function to get the built-in litmus tests
+func Catalog() []Test {
+	tests := []Test{}
+	for _, source := range catalog {
+		test, err := Parse(source)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in litmus test: %v", err))
+		}
+		tests = append(tests, test)
+	}
+	return tests
+}
+
+// Function to find a built-in litmus test by its name
+func Find(name string) (Test, bool) {
+	for _, test := range Catalog() {
+		if strings.EqualFold(test.Name, name) {
+			return test, true
+		}
+	}
+	return Test{}, false
+}
+
+// Parse reads a litmus test written as:
+//
+//	name SB
+//	doc Store buffering
+//	init x=0 y=0
+//	P0: INC; WRITE x; READ y
+//	P1: INC; WRITE y; READ x
+//	exists 0:r0=0 /\ 1:r0=0
+//
+// Lines starting with '#' are comments. The memory operands can be symbolic locations or addresses.
+func Parse(source string) (Test, error) {
+	test := Test{
+		Addresses: map[string]int{},
+		Init:      map[string]int{},
+	}
+	threads := map[int][]string{}
+	condition := ""
+
+	for number, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keyword, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+		switch {
+		case keyword == "name":
+			test.Name = rest
+		case keyword == "doc":
+			test.Doc = rest
+		case keyword == "init":
+			for _, assignment := range strings.Fields(rest) {
+				location, value, err := parseAssignment(assignment)
+				if err != nil {
+					return test, fmt.Errorf("line %d: %v", number+1, err)
+				}
+				if address, err := strconv.Atoi(location); err == nil && (address < 0 || address >= 16) {
+					return test, fmt.Errorf("line %d: the address %d is outside the memory of 16 words", number+1, address)
+				}
+				test.addLocation(location)
+				test.Init[location] = value
+			}
+		case keyword == "exists":
+			condition = rest
+		case strings.HasPrefix(line, "P"):
+			header, program, found := strings.Cut(line, ":")
+			thread, err := strconv.Atoi(strings.TrimPrefix(header, "P"))
+			if !found || err != nil || thread < 0 {
+				return test, fmt.Errorf("line %d: expected a thread like 'P0: READ x'", number+1)
+			}
+			if _, exists := threads[thread]; exists {
+				return test, fmt.Errorf("line %d: P%d is defined twice", number+1, thread)
+			}
+			instructions := []string{}
+			for _, instruction := range strings.Split(program, ";") {
+				instruction = strings.TrimSpace(instruction)
+				if instruction != "" {
+					instructions = append(instructions, instruction)
+				}
+			}
+			threads[thread] = instructions
+		default:
+			return test, fmt.Errorf("line %d: unknown keyword %q", number+1, keyword)
+		}
+	}
+
+	if test.Name == "" {
+		return test, fmt.Errorf("the test has no name")
+	}
+	if len(threads) == 0 {
+		return test, fmt.Errorf("the test has no threads")
+	}
+
+	// The threads must be numbered from P0 without gaps
+	for thread := 0; thread < len(threads); thread++ {
+		program, found := threads[thread]
+		if !found {
+			return test, fmt.Errorf("P%d is missing", thread)
+		}
+		for _, instruction := range program {
+			if words := strings.Fields(instruction); len(words) == 2 && words[0] != "BEQZ" {
+				if _, err := strconv.Atoi(words[1]); err != nil {
+					test.addLocation(words[1])
+				}
+			}
+		}
+		test.Threads = append(test.Threads, program)
+	}
+
+	// Parse the condition once all the locations are known
+	if condition == "" {
+		return test, fmt.Errorf("the test has no exists condition")
+	}
+	for _, text := range strings.Split(condition, `/\`) {
+		term, err := test.parseTerm(strings.Trim(strings.TrimSpace(text), "()"))
+		if err != nil {
+			return test, err
+		}
+		test.Condition = append(test.Condition, term)
+	}
+
+	// Give an address to every symbolic location, far apart so they never share a cache block
+	stride := 4
+	if len(test.Locations)*stride > 16 {
+		stride = 1
+	}
+	if len(test.Locations) > 16 {
+		return test, fmt.Errorf("the test uses %d locations, the memory only has 16 words", len(test.Locations))
+	}
+	for i, location := range test.Locations {
+		if address, err := strconv.Atoi(location); err == nil {
+			test.Addresses[location] = address
+		} else {
+			test.Addresses[location] = i * stride
+		}
+	}
+	for thread, program := range test.Threads {
+		for i, instruction := range program {
+			words := strings.Fields(instruction)
+			if address, found := test.Addresses[words[len(words)-1]]; found && len(words) == 2 && words[0] != "BEQZ" {
+				test.Threads[thread][i] = fmt.Sprintf("%s %d", words[0], address)
+			}
+		}
+
+		// Every thread must be a valid program for the Processing Elements
+		if _, err := assembler.Assemble(fmt.Sprintf("P%d", thread), strings.Join(test.Threads[thread], "\n")); err != nil {
+			return test, err
+		}
+	}
+	return test, nil
+}
+
+// Function to remember a new location of the test
+func (test *Test) addLocation(location string) {
+	if _, found := test.Addresses[location]; found {
+		return
+	}
+	test.Addresses[location] = -1
+	test.Locations = append(test.Locations, location)
+}
+
+// Function to read a "location=value" assignment
+func parseAssignment(text string) (string, int, error) {
+	location, valueText, found := strings.Cut(text, "=")
+	if !found || location == "" {
+		return "", 0, fmt.Errorf("expected 'location=value', got %q", text)
+	}
+	value, err := strconv.Atoi(valueText)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid value in %q", text)
+	}
+	return location, value, nil
+}
+
+// Function to read a term of the condition
+func (test *Test) parseTerm(text string) (Term, error) {
+	target, value, err := parseAssignment(strings.ReplaceAll(text, " ", ""))
+	if err != nil {
+		return Term{}, err
+	}
+	threadText, register, isLoad := strings.Cut(target, ":")
+	if !isLoad {
+		if _, found := test.Addresses[target]; !found {
+			return Term{}, fmt.Errorf("the condition uses the unknown location %q", target)
+		}
+		return Term{Thread: -1, Location: target, Value: value}, nil
+	}
+	thread, err := strconv.Atoi(threadText)
+	if err != nil || thread < 0 || thread >= len(test.Threads) {
+		return Term{}, fmt.Errorf("the condition uses the unknown thread %q", threadText)
+	}
+	load, err := strconv.Atoi(strings.TrimPrefix(register, "r"))
+	if err != nil || !strings.HasPrefix(register, "r") {
+		return Term{}, fmt.Errorf("expected a load like 'r0', got %q", register)
+	}
+	return Term{Thread: thread, Load: load, Value: value}, nil
+}
+
+// Function to get the memory of the test, ready for the Main Memory
+func (test Test) InitialMemory() []uint32 {
+	memory := make([]uint32, 16)
+	for location, value := range test.Init {
+		memory[test.Addresses[location]] = uint32(value)
+	}
+	return memory
+}
+
+// Final state of a run: the values obtained by every load and the final value of every location
+type Outcome struct {
+	Loads  [][]int
+	Memory map[string]int
+}
+
+// Function to write an outcome in the herd style, "0:r0=0; 1:r0=1; x=1; y=1;"
+func (outcome Outcome) String() string {
+	parts := []string{}
+	for thread, loads := range outcome.Loads {
+		for load, value := range loads {
+			parts = append(parts, fmt.Sprintf("%d:r%d=%d;", thread, load, value))
+		}
+	}
+	locations := []string{}
+	for location := range outcome.Memory {
+		locations = append(locations, location)
+	}
+	sort.Strings(locations)
+	for _, location := range locations {
+		parts = append(parts, fmt.Sprintf("%s=%d;", location, outcome.Memory[location]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Function to know if an outcome satisfies the condition of the test
+func (test Test) Satisfies(outcome Outcome) bool {
+	for _, term := range test.Condition {
+		if term.Thread == -1 {
+			if outcome.Memory[term.Location] != term.Value {
+				return false
+			}
+			continue
+		}
+		loads := outcome.Loads[term.Thread]
+		if term.Load >= len(loads) || loads[term.Load] != term.Value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+package litmus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"Backend/utils"
+)
+
+// Steps explored by the reference model before giving up on a path, it bounds programs with loops
+const maxReferenceSteps = 64
+
+// State of a core in the reference model
+type referenceThread struct {
+	PC          int
+	Register    int
+	Loads       []int
+	Buffer      []utils.StoreBufferEntry
+	Reservation int
+}
+
+// Global state of the reference model
+type referenceState struct {
+	Threads []referenceThread
+	Memory  [16]int
+}
+
+// Function to copy a state before applying an action, the slices are not shared
+func (state referenceState) clone() referenceState {
+	copied := referenceState{Memory: state.Memory}
+	for _, thread := range state.Threads {
+		thread.Loads = append([]int{}, thread.Loads...)
+		thread.Buffer = append([]utils.StoreBufferEntry{}, thread.Buffer...)
+		copied.Threads = append(copied.Threads, thread)
+	}
+	return copied
+}
+
+// Function to write a value in the shared memory, it breaks the reservations of the other cores
+func (state *referenceState) store(thread int, address int, value int) {
+	state.Memory[address] = value
+	for other := range state.Threads {
+		if other != thread && state.Threads[other].Reservation == address {
+			state.Threads[other].Reservation = -1
+		}
+	}
+}
+
+// Function to know if a buffered store can reach the memory under the consistency model
+func canDrain(buffer []utils.StoreBufferEntry, index int, model string) bool {
+	if model != "PSO" {
+		return index == 0
+	}
+	for _, entry := range buffer[:index] {
+		if entry.Address == buffer[index].Address {
+			return false
+		}
+	}
+	return true
+}
+
+// Allowed enumerates every outcome of the test permitted by a consistency model (SC, TSO or PSO).
+// It follows the same store buffer rules as the Processing Elements: stores wait in a buffer under
+// TSO and PSO, loads are forwarded from it, and FENCE, LL and SC need an empty buffer.
+// The second result is false when a path was cut at maxReferenceSteps, some permitted outcomes
+// may be missing then.
+func Allowed(test Test, model string, storeBufferSize int) (map[string]Outcome, bool) {
+	outcomes := map[string]Outcome{}
+	visited := map[string]int{}
+	complete := true
+
+	initial := referenceState{}
+	for address, value := range test.InitialMemory() {
+		initial.Memory[address] = int(value)
+	}
+	for range test.Threads {
+		initial.Threads = append(initial.Threads, referenceThread{Reservation: -1})
+	}
+
+	var explore func(state referenceState, steps int)
+	explore = func(state referenceState, steps int) {
+		// Skip the states already explored with at least as many steps left
+		key := fmt.Sprint(state)
+		if previous, found := visited[key]; found && previous <= steps {
+			return
+		}
+		if steps > maxReferenceSteps {
+			complete = false
+			return
+		}
+		visited[key] = steps
+
+		finished := true
+		for t, thread := range state.Threads {
+			program := test.Threads[t]
+
+			// Drain one of the buffered stores
+			for index := range thread.Buffer {
+				finished = false
+				if !canDrain(thread.Buffer, index, model) {
+					continue
+				}
+				next := state.clone()
+				entry := next.Threads[t].Buffer[index]
+				next.Threads[t].Buffer = append(next.Threads[t].Buffer[:index:index], next.Threads[t].Buffer[index+1:]...)
+				next.store(t, entry.Address, entry.Data)
+				explore(next, steps+1)
+			}
+
+			if thread.PC >= len(program) {
+				continue
+			}
+			finished = false
+
+			// Execute the next instruction
+			words := strings.Fields(program[thread.PC])
+			operand := 0
+			if len(words) > 1 {
+				operand, _ = strconv.Atoi(words[1])
+			}
+			emptyBuffer := len(thread.Buffer) == 0
+			next := state.clone()
+			current := &next.Threads[t]
+			current.PC++
+			switch words[0] {
+			case "INC":
+				current.Register++
+			case "READ":
+				value := next.Memory[operand]
+				for i := len(current.Buffer) - 1; i >= 0; i-- {
+					if current.Buffer[i].Address == operand {
+						value = current.Buffer[i].Data
+						break
+					}
+				}
+				current.Register = value
+				current.Loads = append(current.Loads, value)
+			case "WRITE":
+				if model == "SC" {
+					next.store(t, operand, current.Register)
+					break
+				}
+				if len(current.Buffer) >= storeBufferSize {
+					continue
+				}
+				current.Buffer = append(current.Buffer, utils.StoreBufferEntry{Address: operand, Data: current.Register})
+			case "FENCE":
+				if !emptyBuffer {
+					continue
+				}
+			case "LL":
+				if !emptyBuffer {
+					continue
+				}
+				current.Register = next.Memory[operand]
+				current.Loads = append(current.Loads, current.Register)
+				current.Reservation = operand
+			case "SC":
+				if !emptyBuffer {
+					continue
+				}
+				if current.Reservation == operand {
+					next.store(t, operand, current.Register)
+					current.Register = 1
+				} else {
+					current.Register = 0
+				}
+				current.Reservation = -1
+			case "BEQZ":
+				if current.Register == 0 {
+					current.PC = operand
+				}
+			}
+			explore(next, steps+1)
+		}
+
+		// Every program finished and every store reached the memory
+		if finished {
+			outcome := test.outcomeOf(state)
+			outcomes[outcome.String()] = outcome
+		}
+	}
+	explore(initial, 0)
+	return outcomes, complete
+}
+
+// Function to build the outcome of a final state of the reference model
+func (test Test) outcomeOf(state referenceState) Outcome {
+	outcome := Outcome{Memory: map[string]int{}}
+	for _, thread := range state.Threads {
+		outcome.Loads = append(outcome.Loads, thread.Loads)
+	}
+	for _, location := range test.Locations {
+		outcome.Memory[location] = state.Memory[test.Addresses[location]]
+	}
+	return outcome
+}
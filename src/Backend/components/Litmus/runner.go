@@ -0,0 +1,187 @@
+package litmus
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Options of a litmus campaign
+type Options struct {
+	Protocol    string // Cache coherence protocol: MESI or MOESI
+	Consistency string // Memory consistency model of the PEs: SC, TSO or PSO
+	Runs        int    // Number of seeded interleavings to simulate
+	Seed        int64  // Seed of the first run, run i uses Seed+i
+	MaxSteps    int    // Scheduler steps before a run is abandoned (programs with loops)
+}
+
+// Function to get the options used when a request leaves them empty
+func DefaultOptions() Options {
+	return Options{
+		Protocol:    "MESI",
+		Consistency: "SC",
+		Runs:        100,
+		Seed:        1,
+		MaxSteps:    1000,
+	}
+}
+
+// Number of times an outcome was observed
+type OutcomeCount struct {
+	Outcome   string `json:"Outcome"`
+	Count     int    `json:"Count"`
+	Allowed   bool   `json:"Allowed"`   // The consistency model permits this outcome
+	Satisfies bool   `json:"Satisfies"` // The outcome satisfies the exists condition
+}
+
+// Results of a litmus campaign
+type Report struct {
+	Test              string          `json:"Test"`
+	Doc               string          `json:"Doc"`
+	Protocol          string          `json:"Protocol"`
+	Consistency       string          `json:"Consistency"`
+	Runs              int             `json:"Runs"`
+	Seed              int64           `json:"Seed"`
+	Incomplete        int             `json:"Incomplete"`
+	Outcomes          []OutcomeCount  `json:"Outcomes"`
+	ConditionObserved bool            `json:"ConditionObserved"`
+	ConditionAllowed  bool            `json:"ConditionAllowed"`
+	AllowedBy         map[string]bool `json:"AllowedBy"` // The exists condition under every model
+	Unexpected        []string        `json:"Unexpected"`
+	ReferenceComplete bool            `json:"ReferenceComplete"` // The reference model enumerated every path of the selected model
+	Verdict           string          `json:"Verdict"`           // Ok, Violation, or Inconclusive when an outcome is missing from a truncated enumeration
+}
+
+// Run simulates the test under many seeded interleavings and compares the observed outcomes
+// with the outcomes the selected consistency model permits.
+func Run(test Test, options Options) (*Report, error) {
+	if options.Runs <= 0 {
+		return nil, fmt.Errorf("the number of runs must be positive")
+	}
+	if options.MaxSteps <= 0 {
+		options.MaxSteps = DefaultOptions().MaxSteps
+	}
+
+	// The log files of every run go to a temporary folder
+	logDirectory, err := os.MkdirTemp("", "litmus-logs")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(logDirectory)
+
+	cfg := MultiprocessingSystem.DefaultConfig()
+	cfg.Protocol = options.Protocol
+	cfg.Consistency = options.Consistency
+	cfg.Cores = len(test.Threads)
+	cfg.Programs = test.Threads
+	cfg.InitialMemory = test.InitialMemory()
+	cfg.Latency = utils.NoLatencies()
+	cfg.LogDirectory = logDirectory
+	cfg.Quiet = true
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Test:        test.Name,
+		Doc:         test.Doc,
+		Protocol:    options.Protocol,
+		Consistency: options.Consistency,
+		Runs:        options.Runs,
+		Seed:        options.Seed,
+		AllowedBy:   map[string]bool{},
+		Unexpected:  []string{},
+	}
+
+	// Outcomes permitted by every model, the selected one decides the verdict
+	allowed := map[string]Outcome{}
+	for _, model := range []string{"SC", "TSO", "PSO"} {
+		outcomes, complete := Allowed(test, model, 4)
+		for _, outcome := range outcomes {
+			if test.Satisfies(outcome) {
+				report.AllowedBy[model] = true
+			}
+		}
+		if model == options.Consistency {
+			allowed = outcomes
+			report.ReferenceComplete = complete
+		}
+	}
+	report.ConditionAllowed = report.AllowedBy[options.Consistency]
+
+	counts := map[string]int{}
+	observed := map[string]Outcome{}
+	for run := 0; run < options.Runs; run++ {
+		outcome, complete, err := runOnce(test, cfg, options.Seed+int64(run), options.MaxSteps)
+		if err != nil {
+			return nil, err
+		}
+		if !complete {
+			report.Incomplete++
+			continue
+		}
+		counts[outcome.String()]++
+		observed[outcome.String()] = outcome
+	}
+
+	// List the outcomes from the most to the least frequent
+	for key, count := range counts {
+		_, isAllowed := allowed[key]
+		satisfies := test.Satisfies(observed[key])
+		report.Outcomes = append(report.Outcomes, OutcomeCount{
+			Outcome:   key,
+			Count:     count,
+			Allowed:   isAllowed,
+			Satisfies: satisfies,
+		})
+		if satisfies {
+			report.ConditionObserved = true
+		}
+		if !isAllowed {
+			report.Unexpected = append(report.Unexpected, key)
+		}
+	}
+	sort.Slice(report.Outcomes, func(i, j int) bool {
+		if report.Outcomes[i].Count != report.Outcomes[j].Count {
+			return report.Outcomes[i].Count > report.Outcomes[j].Count
+		}
+		return report.Outcomes[i].Outcome < report.Outcomes[j].Outcome
+	})
+	sort.Strings(report.Unexpected)
+
+	// An outcome the truncated enumeration didn't reach may still be permitted, it is not a violation
+	report.Verdict = "Ok"
+	if len(report.Unexpected) > 0 && report.ReferenceComplete {
+		report.Verdict = "Violation"
+	} else if len(report.Unexpected) > 0 {
+		report.Verdict = "Inconclusive"
+	}
+	return report, nil
+}
+
+// Function to simulate one interleaving, a seeded scheduler picks every step
+func runOnce(test Test, cfg MultiprocessingSystem.Config, seed int64, maxSteps int) (Outcome, bool, error) {
+	mps, err := MultiprocessingSystem.StartWithConfig(cfg)
+	if err != nil {
+		return Outcome{}, false, err
+	}
+	defer mps.Stop()
+
+	complete, err := mps.RunSeeded(seed, maxSteps)
+	if err != nil || !complete {
+		return Outcome{}, false, err
+	}
+
+	// Every PE finished, read the final state
+	outcome := Outcome{Memory: map[string]int{}}
+	for _, pe := range mps.ProcessingElements {
+		outcome.Loads = append(outcome.Loads, append([]int{}, pe.Loads...))
+	}
+	for _, location := range test.Locations {
+		outcome.Memory[location] = mps.CoherentValue(test.Addresses[location])
+	}
+	return outcome, true, nil
+}
@@ -18,6 +18,7 @@ type MainMemory struct {
 	Quit            chan struct{}
 	Status 			string
 	Logger          *log.Logger
+	Latency         utils.Latencies
 }
 
 func New(
@@ -51,6 +52,7 @@ func New(
 		Quit:            quit,
 		Logger:          logger,
 		Status: "Active",
+		Latency: utils.DefaultLatencies(),
 	}, nil
 }
 
@@ -120,7 +122,7 @@ func (mm *MainMemory) Run(wg *sync.WaitGroup) {
 			case "READ":
 				mm.Logger.Printf(" - MM is processing a READ request.\n")
 				mm.Logger.Printf(" - Address: %d.\n", request.Address)
-				time.Sleep(3 * time.Second)
+				time.Sleep(mm.Latency.MemoryRead)
 				response.Value = mm.Read(request.Address)
 				response.Time = READTIMECOST
 				response.Status = true
@@ -128,7 +130,7 @@ func (mm *MainMemory) Run(wg *sync.WaitGroup) {
 			case "WRITE":
 				mm.Logger.Printf(" - MM is processing a WRITE request.\n")
 				mm.Logger.Printf(" - Address: %d, Data: %d.\n", request.Address, request.Value)
-				time.Sleep(5 * time.Second)
+				time.Sleep(mm.Latency.MemoryWrite)
 				mm.Write(request.Address, request.Value)
 				response.Value = request.Value
 				response.Time = WRITETIMECOST
@@ -0,0 +1,240 @@
+package MultiprocessingSystem
+
+import (
+	"fmt"
+	"strings"
+
+	processingElement "Backend/components/ProcessingElement"
+	"Backend/utils"
+)
+
+// Kinds of breakpoints of the free-running execution
+const (
+	BreakPosition    = "position"    // Before a PE executes the instruction at a position of its program
+	BreakInstruction = "instruction" // Before a PE executes an instruction of a type
+	BreakWatch       = "watch"       // After an access or a coherence state change of an address
+	BreakBus         = "bus"         // After the Interconnect serves a type of transaction
+)
+
+// Instructions, accesses and transactions accepted by the breakpoints
+var (
+	breakInstructions = []string{"READ", "WRITE", "INC", "LL", "SC", "FENCE", "BEQZ", "IDLE"}
+	breakAccesses     = []string{"read", "write", "state"}
+	breakTransactions = []string{"ReadRequest", "ReadExclusiveRequest", "DataResponse", "Invalidate"}
+)
+
+// Function to add a breakpoint, it returns the breakpoint with its ID
+func (mps *MultiprocessingSystem) AddBreakpoint(breakpoint utils.Breakpoint) (utils.Breakpoint, error) {
+	breakpoint.Kind = strings.ToLower(breakpoint.Kind)
+	switch breakpoint.Kind {
+	case BreakPosition, BreakInstruction:
+		if breakpoint.Core < -1 || breakpoint.Core >= len(mps.ProcessingElements) {
+			return breakpoint, fmt.Errorf("invalid PE number %d, use -1 for every PE", breakpoint.Core)
+		}
+		if breakpoint.Kind == BreakPosition && breakpoint.Position < 0 {
+			return breakpoint, fmt.Errorf("the position can't be negative")
+		}
+		if breakpoint.Kind == BreakInstruction {
+			name, found := findName(breakInstructions, breakpoint.Instruction)
+			if !found {
+				return breakpoint, fmt.Errorf("unknown instruction %q, use one of %s", breakpoint.Instruction, strings.Join(breakInstructions, ", "))
+			}
+			breakpoint.Instruction = name
+		}
+	case BreakWatch:
+		if breakpoint.Address < 0 || breakpoint.Address >= MemorySize {
+			return breakpoint, fmt.Errorf("the address must be between 0 and %d", MemorySize-1)
+		}
+		access, found := findName(breakAccesses, breakpoint.Access)
+		if !found {
+			return breakpoint, fmt.Errorf("unknown access %q, use one of %s", breakpoint.Access, strings.Join(breakAccesses, ", "))
+		}
+		breakpoint.Access = access
+	case BreakBus:
+		if breakpoint.Address < -1 || breakpoint.Address >= MemorySize {
+			return breakpoint, fmt.Errorf("the address must be between 0 and %d, or -1 for any address", MemorySize-1)
+		}
+		transaction, found := findName(breakTransactions, breakpoint.Transaction)
+		if !found {
+			return breakpoint, fmt.Errorf("unknown transaction %q, use one of %s", breakpoint.Transaction, strings.Join(breakTransactions, ", "))
+		}
+		breakpoint.Transaction = transaction
+	default:
+		return breakpoint, fmt.Errorf("unknown kind of breakpoint %q, use position, instruction, watch or bus", breakpoint.Kind)
+	}
+
+	exec := &mps.execution
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	exec.lastBreakpoint++
+	breakpoint.ID = exec.lastBreakpoint
+	breakpoint.Hits = 0
+	exec.breakpoints = append(exec.breakpoints, breakpoint)
+	return breakpoint, nil
+}
+
+// Function to find a name in a list without caring about the case
+func findName(names []string, name string) (string, bool) {
+	for _, candidate := range names {
+		if strings.EqualFold(candidate, name) {
+			return candidate, true
+		}
+	}
+	return name, false
+}
+
+// Function to remove a breakpoint by its ID
+func (mps *MultiprocessingSystem) RemoveBreakpoint(id int) error {
+	exec := &mps.execution
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	for i, breakpoint := range exec.breakpoints {
+		if breakpoint.ID == id {
+			exec.breakpoints = append(exec.breakpoints[:i], exec.breakpoints[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("there is no breakpoint %d", id)
+}
+
+// Function to get a copy of the breakpoints
+func (mps *MultiprocessingSystem) Breakpoints() []utils.Breakpoint {
+	exec := &mps.execution
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	return append([]utils.Breakpoint{}, exec.breakpoints...)
+}
+
+// Function to get the position and the text of the next instruction of a PE
+func nextInstruction(pe *processingElement.ProcessingElement) (int, string) {
+	pc, instructions := pe.GetInstructions()
+	if len(instructions) == 0 {
+		return pc, ""
+	}
+	return pc, instructions[0]
+}
+
+// Function to pause the execution before the next instruction of a PE when a breakpoint matches it.
+// The instruction where the execution stopped runs when it continues, the breakpoint doesn't fire again.
+func (mps *MultiprocessingSystem) breakBefore(generation int, id int) bool {
+	position, instruction := nextInstruction(mps.ProcessingElements[id])
+	fields := strings.Fields(instruction)
+	if len(fields) == 0 {
+		return false
+	}
+
+	exec := &mps.execution
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	if exec.stoppedAt != nil && exec.stoppedAt.Core == id {
+		stopped := exec.stoppedAt.Position == position
+		exec.stoppedAt = nil
+		if stopped {
+			return false
+		}
+	}
+	for i := range exec.breakpoints {
+		breakpoint := &exec.breakpoints[i]
+		if breakpoint.Core != -1 && breakpoint.Core != id {
+			continue
+		}
+		if (breakpoint.Kind == BreakPosition && breakpoint.Position == position) ||
+			(breakpoint.Kind == BreakInstruction && breakpoint.Instruction == fields[0]) {
+			hit := &utils.BreakpointHit{Core: id, Position: position, Instruction: instruction, Detail: "Before the instruction"}
+			if exec.trigger(generation, breakpoint, hit) {
+				exec.stoppedAt = hit
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Memory operations, bus transactions and cache states before an instruction, to find what it changed
+type breakMarks struct {
+	operations   int
+	transactions int
+	states       map[int][]string // States of each watched address in every cache
+}
+
+// Function to take the marks before an instruction of the execution
+func (mps *MultiprocessingSystem) markBreakpoints() breakMarks {
+	marks := breakMarks{states: map[int][]string{}}
+	marks.operations, marks.transactions = mps.Recorder.Lengths()
+	for _, breakpoint := range mps.Breakpoints() {
+		if breakpoint.Kind == BreakWatch && breakpoint.Access == "state" {
+			marks.states[breakpoint.Address] = mps.addressStates(breakpoint.Address)
+		}
+	}
+	return marks
+}
+
+// Function to get the state of an address in every cache
+func (mps *MultiprocessingSystem) addressStates(address int) []string {
+	states := []string{}
+	for _, cc := range mps.CacheControllers {
+		states = append(states, cc.GetAddressStatus(address))
+	}
+	return states
+}
+
+// Function to pause the execution after an instruction when it accessed a watched address, changed its
+// state in a cache or caused a bus transaction with a breakpoint
+func (mps *MultiprocessingSystem) breakAfter(generation int, id int, position int, instruction string, marks breakMarks) bool {
+	operations, transactions := mps.Recorder.Since(marks.operations, marks.transactions)
+	states := map[int][]string{}
+	for address := range marks.states {
+		states[address] = mps.addressStates(address)
+	}
+
+	exec := &mps.execution
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	for i := range exec.breakpoints {
+		breakpoint := &exec.breakpoints[i]
+		detail := ""
+		switch {
+		case breakpoint.Kind == BreakWatch && breakpoint.Access == "state":
+			for cc, state := range states[breakpoint.Address] {
+				if before := marks.states[breakpoint.Address][cc]; before != state {
+					detail = fmt.Sprintf("CC%d changed the address %d from %s to %s", cc, breakpoint.Address, before, state)
+					break
+				}
+			}
+		case breakpoint.Kind == BreakWatch:
+			for _, operation := range operations {
+				if operation.Address == breakpoint.Address && operation.Write == (breakpoint.Access == "write") {
+					detail = fmt.Sprintf("PE%d %s %d with the value %d", operation.Core, operation.Kind, operation.Address, operation.Value)
+					break
+				}
+			}
+		case breakpoint.Kind == BreakBus:
+			for _, transaction := range transactions {
+				if (transaction.Type == breakpoint.Transaction || transaction.AR == breakpoint.Transaction) &&
+					(breakpoint.Address == -1 || transaction.Address == breakpoint.Address) {
+					detail = fmt.Sprintf("CC%d %s %d answered with %s", transaction.Requester, transaction.Type, transaction.Address, transaction.AR)
+					break
+				}
+			}
+		}
+		if detail != "" {
+			hit := &utils.BreakpointHit{Core: id, Position: position, Instruction: instruction, Detail: detail}
+			if exec.trigger(generation, breakpoint, hit) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Function to pause the running execution at a breakpoint, with the mutex taken
+func (exec *execution) trigger(generation int, breakpoint *utils.Breakpoint, hit *utils.BreakpointHit) bool {
+	if exec.generation != generation || exec.state.State != ExecutionRunning {
+		return false
+	}
+	breakpoint.Hits++
+	hit.Breakpoint = *breakpoint
+	exec.state.State = ExecutionPaused
+	exec.state.Breakpoint = hit
+	return true
+}
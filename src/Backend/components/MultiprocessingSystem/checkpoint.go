@@ -0,0 +1,459 @@
+package MultiprocessingSystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"Backend/components/CacheController"
+	interconnect "Backend/components/Interconnect"
+	mainMemory "Backend/components/MainMemory"
+	processingElement "Backend/components/ProcessingElement"
+	"Backend/utils"
+)
+
+// Version of the checkpoints written by this simulator, a checkpoint of another version can't be restored
+const CheckpointVersion = 1
+
+// Whole state of a system between two steps, a system restored from it continues as the original would have.
+// No transaction is on the bus between steps, the stores still waiting are kept in the store buffers.
+type Checkpoint struct {
+	Version            int                           `json:"Version"`
+	Config             Config                        `json:"Config"` // The programs of the PEs are always included
+	ProcessingElements []ProcessingElementCheckpoint `json:"ProcessingElements"`
+	CacheControllers   []CacheControllerCheckpoint   `json:"CacheControllers"`
+	Interconnect       InterconnectCheckpoint        `json:"Interconnect"`
+	MainMemory         MainMemoryCheckpoint          `json:"MainMemory"`
+	Trace              utils.RecorderState           `json:"Trace"`
+	Execution          ExecutionCheckpoint           `json:"Execution"`
+}
+
+// State of a Processing Element in a checkpoint
+type ProcessingElementCheckpoint struct {
+	Register        int                      `json:"Register"`
+	PC              int                      `json:"PC"`
+	IsDone          bool                     `json:"IsDone"`
+	Status          string                   `json:"Status"`
+	StoreBuffer     []utils.StoreBufferEntry `json:"StoreBuffer"`
+	StoreBufferSize int                      `json:"StoreBufferSize"`
+	Loads           []int                    `json:"Loads"`
+	Log             string                   `json:"Log"`
+}
+
+// State of a Cache Controller in a checkpoint
+type CacheControllerCheckpoint struct {
+	Cache             utils.CacheObjectList     `json:"Cache"`
+	ReplacementQueues [][]int                   `json:"ReplacementQueues"` // Blocks of every set from the next one to replace
+	Status            string                    `json:"Status"`
+	CacheHits         int                       `json:"CacheHits"`
+	CacheMisses       int                       `json:"CacheMisses"`
+	MemoryAccesses    int                       `json:"MemoryAccesses"`
+	LinkedAddress     int                       `json:"LinkedAddress"`
+	SCSuccesses       int                       `json:"SCSuccesses"`
+	SCFailures        int                       `json:"SCFailures"`
+	Misses            CacheController.MissState `json:"Misses"` // What the cache knows to classify its next misses
+	Log               string                    `json:"Log"`
+}
+
+// State of the Interconnect in a checkpoint
+type InterconnectCheckpoint struct {
+	Transactions          []string `json:"Transactions"`
+	Logs                  []string `json:"Logs"`
+	Status                string   `json:"Status"`
+	PowerConsumption      float64  `json:"PowerConsumption"`
+	ReadRequests          int      `json:"ReadRequests"`
+	ReadExclusiveRequests int      `json:"ReadExclusiveRequests"`
+	DataResponses         int      `json:"DataResponses"`
+	Invalidates           int      `json:"Invalidates"`
+	MemoryReads           int      `json:"MemoryReads"`
+	MemoryWrites          int      `json:"MemoryWrites"`
+	Log                   string   `json:"Log"`
+}
+
+// State of the Main Memory in a checkpoint
+type MainMemoryCheckpoint struct {
+	Data   []uint32 `json:"Data"`
+	Status string   `json:"Status"`
+	Log    string   `json:"Log"`
+}
+
+// State of the free-running execution in a checkpoint, a paused execution is paused again after the restore
+type ExecutionCheckpoint struct {
+	State          utils.ExecutionState `json:"State"`
+	Turn           int                  `json:"Turn"`  // PE that gets the next turn of the cycle
+	Delay          time.Duration        `json:"Delay"` // Time between cycles
+	Breakpoints    []utils.Breakpoint   `json:"Breakpoints"`
+	LastBreakpoint int                  `json:"LastBreakpoint"`
+	StoppedAt      *utils.BreakpointHit `json:"StoppedAt"` // Instruction where a breakpoint stopped the execution before it ran
+}
+
+// Function to save the whole state of the system, it fails while the system is running or a PE is executing an instruction
+func (mps *MultiprocessingSystem) Checkpoint() (*Checkpoint, error) {
+	// No instruction of the execution is in progress while the step is held
+	exec := &mps.execution
+	exec.step.Lock()
+	defer exec.step.Unlock()
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	if exec.state.State == ExecutionRunning {
+		return nil, fmt.Errorf("the system is running, pause it first")
+	}
+	for _, pe := range mps.ProcessingElements {
+		if pe.Executing() {
+			return nil, fmt.Errorf("PE%d is executing an instruction, wait until it finishes", pe.ID)
+		}
+	}
+
+	checkpoint := mps.save(true)
+	checkpoint.Execution = ExecutionCheckpoint{
+		State:          exec.state,
+		Turn:           exec.turn,
+		Delay:          exec.delay,
+		Breakpoints:    append([]utils.Breakpoint{}, exec.breakpoints...),
+		LastBreakpoint: exec.lastBreakpoint,
+		StoppedAt:      exec.stoppedAt,
+	}
+	return checkpoint, nil
+}
+
+// Function to save the components of the system between two steps, without the execution.
+// The logs and the trace are only saved when complete is true, the snapshots of the history leave them out.
+func (mps *MultiprocessingSystem) save(complete bool) *Checkpoint {
+	readLog := func(logger *log.Logger) string {
+		if !complete {
+			return ""
+		}
+		return readLog(logger)
+	}
+
+	// The programs already assembled are saved, the checkpoint doesn't need the program files
+	cfg := mps.Config
+	cfg.Checkpoint = nil
+	cfg.CodeGenerator = false
+	cfg.Workload = nil
+	cfg.InitialMemory = nil
+	cfg.Programs, cfg.ProgramNames = nil, nil
+	programs := [][]string{}
+	names := []string{}
+	for _, pe := range mps.ProcessingElements {
+		programs = append(programs, append([]string{}, pe.Program...))
+		names = append(names, pe.Filename)
+	}
+	if cfg.Traces != nil {
+		cfg.Traces = programs
+	} else {
+		cfg.Programs, cfg.ProgramNames = programs, names
+	}
+
+	checkpoint := &Checkpoint{
+		Version: CheckpointVersion,
+		Config:  cfg,
+		Trace:   utils.RecorderState{Gaps: mps.Recorder.Gaps()},
+	}
+	if complete {
+		checkpoint.Trace = mps.Recorder.State()
+	}
+	for _, pe := range mps.ProcessingElements {
+		pc, _ := pe.GetInstructions()
+		checkpoint.ProcessingElements = append(checkpoint.ProcessingElements, ProcessingElementCheckpoint{
+			Register:        pe.GetRegister(),
+			PC:              pc,
+			IsDone:          pe.Finished(),
+			Status:          pe.GetStatus(),
+			StoreBuffer:     pe.GetStoreBuffer(),
+			StoreBufferSize: pe.StoreBufferSize,
+			Loads:           append([]int{}, pe.Loads...),
+			Log:             readLog(pe.Logger),
+		})
+	}
+	for _, cc := range mps.CacheControllers {
+		counters := cc.Counters()
+		saved := CacheControllerCheckpoint{
+			Cache:          utils.CacheObjectList{},
+			Status:         cc.Status,
+			CacheHits:      counters.Hits,
+			CacheMisses:    counters.Misses,
+			MemoryAccesses: cc.MemoryAccesses,
+			LinkedAddress:  cc.LinkedAddress(),
+			SCSuccesses:    counters.SCSuccesses,
+			SCFailures:     counters.SCFailures,
+			Misses:         cc.Misses.State(),
+			Log:            readLog(cc.Logger),
+		}
+		for i := 0; i < cc.Cache.Lines(); i++ {
+			saved.Cache = append(saved.Cache, utils.CacheObject{
+				Block:   i,
+				Address: cc.Cache.GetAddress(i),
+				Data:    cc.Cache.GetData(i),
+				State:   cc.Cache.GetState(i),
+			})
+		}
+		for _, queue := range cc.ReplacementQueues {
+			saved.ReplacementQueues = append(saved.ReplacementQueues, queue.Items())
+		}
+		checkpoint.CacheControllers = append(checkpoint.CacheControllers, saved)
+	}
+	ic := mps.Interconnect
+	bus := ic.Counters()
+	checkpoint.Interconnect = InterconnectCheckpoint{
+		Transactions:          append([]string{}, ic.Transactions.Items...),
+		Logs:                  append([]string{}, ic.Logs.Items...),
+		Status:                ic.Status,
+		PowerConsumption:      bus.PowerConsumption,
+		ReadRequests:          bus.ReadRequests,
+		ReadExclusiveRequests: bus.ReadExclusiveRequests,
+		DataResponses:         bus.DataResponses,
+		Invalidates:           bus.Invalidates,
+		MemoryReads:           bus.MemoryReads,
+		MemoryWrites:          bus.MemoryWrites,
+		Log:                   readLog(ic.Logger),
+	}
+	checkpoint.MainMemory = MainMemoryCheckpoint{
+		Data:   append([]uint32{}, mps.MainMemory.Data[:]...),
+		Status: mps.MainMemory.Status,
+		Log:    readLog(mps.MainMemory.Logger),
+	}
+	return checkpoint
+}
+
+// Function to write a checkpoint of the system to a JSON file
+func (mps *MultiprocessingSystem) SaveCheckpoint(filename string) error {
+	checkpoint, err := mps.Checkpoint()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(checkpoint, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Function to read a checkpoint saved with SaveCheckpoint
+func LoadCheckpoint(filename string) (*Checkpoint, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("%s is not a checkpoint: %v", filename, err)
+	}
+	if err := checkpoint.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return checkpoint, nil
+}
+
+// Function to get the configuration that restores the checkpoint, the folders and the events can still be changed
+func (checkpoint *Checkpoint) RestoreConfig() Config {
+	cfg := checkpoint.Config
+	cfg.Checkpoint = checkpoint
+	return cfg
+}
+
+// Function to check that a checkpoint can be restored with its own configuration
+func (checkpoint *Checkpoint) Validate() error {
+	if err := checkpoint.Config.Validate(); err != nil {
+		return err
+	}
+	programs, err := checkpoint.Config.LoadPrograms()
+	if err != nil {
+		return err
+	}
+	return checkpoint.validate(checkpoint.Config, programs)
+}
+
+// Function to check that a checkpoint describes the system of a configuration with the programs given
+func (checkpoint *Checkpoint) validate(cfg Config, programs [][]string) error {
+	if checkpoint.Version != CheckpointVersion {
+		return fmt.Errorf("the checkpoint has version %d, only version %d can be restored", checkpoint.Version, CheckpointVersion)
+	}
+	saved := checkpoint.Config
+	if cfg.Protocol != saved.Protocol || cfg.Consistency != saved.Consistency || cfg.Cores != saved.Cores {
+		return fmt.Errorf("the checkpoint was saved from a %s %s system with %d cores", saved.Protocol, saved.Consistency, saved.Cores)
+	}
+	lines, ways := cfg.CacheGeometry()
+	if savedLines, savedWays := saved.CacheGeometry(); lines != savedLines || ways != savedWays {
+		return fmt.Errorf("the checkpoint was saved with caches of %d lines and %d ways", savedLines, savedWays)
+	}
+	if len(checkpoint.ProcessingElements) != cfg.Cores || len(checkpoint.CacheControllers) != cfg.Cores {
+		return fmt.Errorf("the checkpoint doesn't have the %d PEs and caches of the system", cfg.Cores)
+	}
+	for id, pe := range checkpoint.ProcessingElements {
+		if pe.PC < 0 || pe.PC > len(programs[id]) {
+			return fmt.Errorf("the PC %d of PE%d is outside its program", pe.PC, id)
+		}
+		if pe.StoreBufferSize < 1 || len(pe.StoreBuffer) > pe.StoreBufferSize {
+			return fmt.Errorf("the store buffer of PE%d has %d stores and room for %d", id, len(pe.StoreBuffer), pe.StoreBufferSize)
+		}
+		for _, entry := range pe.StoreBuffer {
+			if entry.Address < 0 || entry.Address >= MemorySize {
+				return fmt.Errorf("the store buffer of PE%d has the address %d outside of the Main Memory", id, entry.Address)
+			}
+		}
+	}
+	for id, cc := range checkpoint.CacheControllers {
+		if err := cc.validate(cfg.Protocol, lines, ways); err != nil {
+			return fmt.Errorf("the cache of CC%d %v", id, err)
+		}
+	}
+	if len(checkpoint.MainMemory.Data) != MemorySize {
+		return fmt.Errorf("the Main Memory of the checkpoint doesn't have %d words", MemorySize)
+	}
+	if checkpoint.Execution.State.State == ExecutionRunning {
+		return fmt.Errorf("the checkpoint was saved while the system was running")
+	}
+	if checkpoint.Execution.Turn < 0 || checkpoint.Execution.Turn > cfg.Cores {
+		return fmt.Errorf("the execution of the checkpoint gives the turn to PE%d", checkpoint.Execution.Turn)
+	}
+	return nil
+}
+
+// Function to check that the blocks, the replacement queues and the reservation of a saved cache can be restored
+func (saved CacheControllerCheckpoint) validate(protocol string, lines int, ways int) error {
+	if len(saved.Cache) != lines || len(saved.ReplacementQueues) != lines/ways {
+		return fmt.Errorf("doesn't have %d lines in %d sets", lines, lines/ways)
+	}
+	states := map[string]bool{"M": true, "E": true, "S": true, "I": true}
+	if protocol == "MOESI" {
+		states["O"] = true
+	}
+	for i, block := range saved.Cache {
+		if block.Block != i {
+			return fmt.Errorf("has the block %d in the position %d", block.Block, i)
+		}
+		// An empty block has the address -1, the others belong to the set of the block
+		if block.Address < -1 || block.Address >= MemorySize {
+			return fmt.Errorf("has the address %d outside of the Main Memory in the block %d", block.Address, i)
+		}
+		if block.Address != -1 && block.Address%(lines/ways) != i/ways {
+			return fmt.Errorf("has the address %d in the block %d of another set", block.Address, i)
+		}
+		if !states[block.State] {
+			return fmt.Errorf("has the unknown %s state %q in the block %d", protocol, block.State, i)
+		}
+	}
+	// The free blocks of a set are used in order, a queue holds the first blocks of its set once each
+	for set, items := range saved.ReplacementQueues {
+		if len(items) > ways {
+			return fmt.Errorf("has %d blocks in the replacement queue of the set %d with %d ways", len(items), set, ways)
+		}
+		used := map[int]bool{}
+		for _, block := range items {
+			if block < set*ways || block >= set*ways+len(items) || used[block] {
+				return fmt.Errorf("has a replacement queue of the set %d that isn't an order of its blocks: %v", set, items)
+			}
+			used[block] = true
+		}
+	}
+	if saved.LinkedAddress < -1 || saved.LinkedAddress >= MemorySize {
+		return fmt.Errorf("has the reservation on the address %d outside of the Main Memory", saved.LinkedAddress)
+	}
+	return nil
+}
+
+// Function to restore a Processing Element before it starts
+func (checkpoint *Checkpoint) restoreProcessingElement(pe *processingElement.ProcessingElement) {
+	saved := checkpoint.ProcessingElements[pe.ID]
+	pe.Register = saved.Register
+	pe.PC = saved.PC
+	pe.Instructions = utils.QueueS{Items: append([]string{}, pe.Program[saved.PC:]...)}
+	pe.IsDone = saved.IsDone
+	pe.SetStatus(saved.Status)
+	pe.StoreBuffer = append([]utils.StoreBufferEntry{}, saved.StoreBuffer...)
+	pe.StoreBufferSize = saved.StoreBufferSize
+	pe.Loads = append([]int{}, saved.Loads...)
+	restoreLog(pe.Logger, saved.Log)
+}
+
+// Function to restore a Cache Controller before it starts
+func (checkpoint *Checkpoint) restoreCacheController(cc *CacheController.CacheController) {
+	saved := checkpoint.CacheControllers[cc.ID]
+	for _, block := range saved.Cache {
+		cc.Cache.SetAddress(block.Block, block.Address)
+		cc.Cache.SetData(block.Block, block.Data)
+		cc.Cache.SetState(block.Block, block.State)
+	}
+	for set, items := range saved.ReplacementQueues {
+		cc.ReplacementQueues[set] = utils.NewQueue(items)
+	}
+	cc.Status = saved.Status
+	cc.CacheHits = saved.CacheHits
+	cc.CacheMisses = saved.CacheMisses
+	cc.MemoryAccesses = saved.MemoryAccesses
+	cc.RestoreReservation(saved.LinkedAddress)
+	cc.SCSuccesses = saved.SCSuccesses
+	cc.SCFailures = saved.SCFailures
+	cc.Misses.Restore(saved.Misses)
+	restoreLog(cc.Logger, saved.Log)
+}
+
+// Function to restore the Interconnect before it starts
+func (checkpoint *Checkpoint) restoreInterconnect(ic *interconnect.Interconnect) {
+	saved := checkpoint.Interconnect
+	ic.Transactions = utils.QueueS{Items: append([]string{}, saved.Transactions...)}
+	ic.Logs = utils.QueueS{Items: append([]string{}, saved.Logs...)}
+	ic.Status = saved.Status
+	ic.PowerConsumption = saved.PowerConsumption
+	ic.ReadRequests = saved.ReadRequests
+	ic.ReadExclusiveRequests = saved.ReadExclusiveRequests
+	ic.DataResponses = saved.DataResponses
+	ic.Invalidates = saved.Invalidates
+	ic.MemoryReads = saved.MemoryReads
+	ic.MemoryWrites = saved.MemoryWrites
+	restoreLog(ic.Logger, saved.Log)
+}
+
+// Function to restore the Main Memory before it starts
+func (checkpoint *Checkpoint) restoreMainMemory(mm *mainMemory.MainMemory) {
+	copy(mm.Data[:], checkpoint.MainMemory.Data)
+	mm.Status = checkpoint.MainMemory.Status
+	restoreLog(mm.Logger, checkpoint.MainMemory.Log)
+}
+
+// Function to restore the execution and its breakpoints, a paused execution waits for Resume or Run again
+func (mps *MultiprocessingSystem) restoreExecution(checkpoint *Checkpoint) {
+	saved := checkpoint.Execution
+	exec := &mps.execution
+	exec.mutex.Lock()
+	exec.state = saved.State
+	exec.turn = saved.Turn
+	exec.delay = saved.Delay
+	exec.breakpoints = append([]utils.Breakpoint{}, saved.Breakpoints...)
+	exec.lastBreakpoint = saved.LastBreakpoint
+	exec.stoppedAt = saved.StoppedAt
+	paused := exec.state.State == ExecutionPaused
+	if paused {
+		exec.wake = make(chan struct{}, 1)
+		exec.generation++
+	}
+	generation, wake, turn := exec.generation, exec.wake, exec.turn
+	exec.mutex.Unlock()
+
+	if paused {
+		go mps.execute(generation, wake, turn)
+	}
+}
+
+// Function to read everything written to a log file
+func readLog(logger *log.Logger) string {
+	file, ok := logger.Writer().(*os.File)
+	if !ok {
+		return ""
+	}
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Function to write the saved lines of a log before the new ones
+func restoreLog(logger *log.Logger, text string) {
+	if text != "" {
+		logger.Writer().Write([]byte(text))
+	}
+}
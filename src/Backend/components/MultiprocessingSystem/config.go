@@ -0,0 +1,170 @@
+package MultiprocessingSystem
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	assembler "Backend/components/Assembler"
+	"Backend/components/CacheController"
+	"Backend/utils"
+)
+
+// Limits of the simulated hardware
+const (
+	MaxCores      = 8                // Maximum number of Processing Elements
+	MemorySize    = utils.MemorySize // Number of words in the Main Memory
+	MaxCacheLines = MemorySize       // Maximum number of blocks of a cache, every word fits in one block
+)
+
+// Configuration used to initialize a new Multiprocessing System
+type Config struct {
+	Protocol            string                 // Cache coherence protocol: MESI or MOESI
+	Consistency         string                 // Memory consistency model of the PEs: SC, TSO or PSO
+	Cores               int                    // Number of Processing Elements and Cache Controllers
+	CacheLines          int                    // Blocks of every cache, 0 uses the default cache
+	CacheWays           int                    // Associativity of the caches, blocks of every set
+	CodeGenerator       bool                   // Generate new random programs instead of reusing the last ones
+	InstructionsPerCore int                    // Number of instructions of every generated program
+	Workload            *utils.WorkloadOptions // Sharing pattern of the generated programs, nil picks random instructions
+	Programs            [][]string             // Programs for every PE, a nil program uses its generated-programs file
+	ProgramNames        []string               // Name shown for every given program, like the file name of the generated ones
+	Traces              [][]string             // Operations replayed by every PE in the trace-driven mode, they replace the programs
+	InitialMemory       []uint32               // Initial values of the Main Memory, random values are used when it is nil
+	Latency             utils.Latencies        // Time costs of the components
+	LogDirectory        string                 // Folder where the CC, PE, IC and MM log folders are created
+	ProgramDirectory    string                 // Folder of the generated program files, generated-programs when empty
+	Quiet               bool                   // Don't print the progress of the initialization to the console
+	CheckInvariants     bool                   // Check the coherence invariants after every access, a violation stops the system
+	Events              *utils.EventStream     `json:"-"` // Receives the events of the simulation, nil disables them
+	Checkpoint          *Checkpoint            `json:"-"` // Saved system that the new one continues, nil starts from the beginning
+}
+
+// Function to get the configuration used by the frontend
+func DefaultConfig() Config {
+	return Config{
+		Protocol:            "MESI",
+		Consistency:         "SC",
+		Cores:               3,
+		CodeGenerator:       true,
+		InstructionsPerCore: 4,
+		Latency:             utils.DefaultLatencies(),
+		LogDirectory:        "logs",
+		ProgramDirectory:    "generated-programs",
+	}
+}
+
+// Function to check that a configuration describes a valid system
+func (cfg Config) Validate() error {
+	if cfg.Protocol != "MESI" && cfg.Protocol != "MOESI" {
+		return fmt.Errorf("unknown protocol %q, expected MESI or MOESI", cfg.Protocol)
+	}
+	if cfg.Consistency != "SC" && cfg.Consistency != "TSO" && cfg.Consistency != "PSO" {
+		return fmt.Errorf("unknown consistency model %q, expected SC, TSO or PSO", cfg.Consistency)
+	}
+	if cfg.Cores < 1 || cfg.Cores > MaxCores {
+		return fmt.Errorf("the number of cores must be between 1 and %d", MaxCores)
+	}
+	lines, ways := cfg.CacheGeometry()
+	if lines < 1 || lines > MaxCacheLines {
+		return fmt.Errorf("the number of cache lines must be between 1 and %d", MaxCacheLines)
+	}
+	if ways < 1 || lines%ways != 0 {
+		return fmt.Errorf("the cache associativity must divide the %d cache lines", lines)
+	}
+	if cfg.InstructionsPerCore < 0 {
+		return fmt.Errorf("the number of instructions per core can't be negative")
+	}
+	if cfg.Workload != nil {
+		if err := cfg.Workload.ValidateFor(cfg.Cores); err != nil {
+			return err
+		}
+	}
+	if cfg.Programs != nil && len(cfg.Programs) != cfg.Cores {
+		return fmt.Errorf("%d programs were given for %d cores", len(cfg.Programs), cfg.Cores)
+	}
+	if cfg.Traces != nil && len(cfg.Traces) != cfg.Cores {
+		return fmt.Errorf("%d traces were given for %d cores", len(cfg.Traces), cfg.Cores)
+	}
+	if cfg.ProgramNames != nil && len(cfg.ProgramNames) != cfg.Cores {
+		return fmt.Errorf("%d program names were given for %d cores", len(cfg.ProgramNames), cfg.Cores)
+	}
+	if len(cfg.InitialMemory) > MemorySize {
+		return fmt.Errorf("the initial memory has %d words, the Main Memory only has %d", len(cfg.InitialMemory), MemorySize)
+	}
+	return nil
+}
+
+// Function to get the number of lines and ways of the caches, the zero values use the default cache
+func (cfg Config) CacheGeometry() (int, int) {
+	lines, ways := cfg.CacheLines, cfg.CacheWays
+	if lines == 0 {
+		lines = CacheController.DefaultCacheLines
+	}
+	if ways == 0 {
+		// Without associativity the cache is fully associative
+		ways = lines
+	}
+	return lines, ways
+}
+
+// Function to get the file with the program of a PE when the configuration doesn't give one
+func (cfg Config) ProgramFile(id int) string {
+	directory := cfg.ProgramDirectory
+	if directory == "" {
+		directory = "generated-programs"
+	}
+	return filepath.Join(directory, fmt.Sprintf("program%d.txt", id))
+}
+
+// Function to assemble the program of every PE, any invalid program stops the initialization
+func (cfg Config) LoadPrograms() ([][]string, error) {
+	// The traces are already checked when they are loaded
+	if cfg.Traces != nil {
+		return cfg.Traces, nil
+	}
+	programs := [][]string{}
+	for id := 0; id < cfg.Cores; id++ {
+		var program []string
+		var err error
+		if cfg.HasProgram(id) {
+			program, err = assembler.Assemble(cfg.ProgramName(id), strings.Join(cfg.Programs[id], "\n"))
+		} else {
+			program, err = assembler.AssembleFile(cfg.ProgramFile(id))
+		}
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, program)
+	}
+	return programs, nil
+}
+
+// Function to know if the configuration gives the program of a PE
+func (cfg Config) HasProgram(id int) bool {
+	return cfg.Traces != nil || (cfg.Programs != nil && cfg.Programs[id] != nil)
+}
+
+// Function to know if some PE runs the program of its generated-programs file
+func (cfg Config) UsesProgramFiles() bool {
+	for id := 0; id < cfg.Cores; id++ {
+		if !cfg.HasProgram(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to get the name of the program of a PE
+func (cfg Config) ProgramName(id int) string {
+	if !cfg.HasProgram(id) {
+		return cfg.ProgramFile(id)
+	}
+	if cfg.Traces != nil {
+		return "trace"
+	}
+	if cfg.ProgramNames != nil && cfg.ProgramNames[id] != "" {
+		return cfg.ProgramNames[id]
+	}
+	return fmt.Sprintf("P%d", id)
+}
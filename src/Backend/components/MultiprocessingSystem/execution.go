@@ -0,0 +1,341 @@
+package MultiprocessingSystem
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	processingElement "Backend/components/ProcessingElement"
+	"Backend/utils"
+)
+
+// States of the free-running execution of the PEs
+const (
+	ExecutionIdle      = "Idle"
+	ExecutionRunning   = "Running"
+	ExecutionPaused    = "Paused"
+	ExecutionFinished  = "Finished"
+	ExecutionCancelled = "Cancelled"
+	ExecutionStopped   = "Stopped" // A coherence invariant failed, the PEs can't continue
+)
+
+// Limits of a free-running execution, it pauses when it reaches one of them. A limit of 0 doesn't stop it.
+type RunLimit struct {
+	Instructions int           // Instructions executed by all the PEs
+	Cycles       int           // Rounds in which every PE that hasn't finished executes one instruction
+	Delay        time.Duration // Time between cycles, so a client can follow the execution
+}
+
+// State of the free-running execution of a system, the zero value is an idle execution
+type execution struct {
+	mutex            sync.Mutex
+	state            utils.ExecutionState
+	untilInstruction int           // Instruction count where the execution pauses, 0 without limit
+	untilCycle       int           // Cycle count where the execution pauses, 0 without limit
+	delay            time.Duration // Time between cycles
+	wake             chan struct{} // Wakes the execution when the state changes while it waits
+	step             sync.RWMutex  // Read held during every instruction, pause, cancel and the snapshots wait for them
+	generation       int           // Grows with every new execution, an old goroutine that sees a new one ends
+	breakpoints      []utils.Breakpoint
+	lastBreakpoint   int                  // ID of the last breakpoint added
+	stoppedAt        *utils.BreakpointHit // Instruction where a breakpoint stopped the execution before it ran
+	turn             int                  // PE that gets the next turn of the cycle, kept by the checkpoints
+}
+
+// Function to run the PEs in turns until they finish, the call returns at once and the execution
+// goes on in the background. A paused execution continues with the new limits.
+func (mps *MultiprocessingSystem) Run(limit RunLimit) error {
+	if limit.Instructions < 0 || limit.Cycles < 0 || limit.Delay < 0 {
+		return fmt.Errorf("the limits of the execution can't be negative")
+	}
+	exec := &mps.execution
+	exec.mutex.Lock()
+	switch {
+	case exec.state.State == ExecutionRunning:
+		exec.mutex.Unlock()
+		return fmt.Errorf("the system is already running")
+	case mps.AreWeFinished():
+		exec.mutex.Unlock()
+		return fmt.Errorf("every PE already finished")
+	case mps.Violation() != nil:
+		exec.mutex.Unlock()
+		return mps.violationError()
+	}
+	// A new execution counts from zero, a paused one keeps its counters
+	paused := exec.state.State == ExecutionPaused
+	if !paused {
+		exec.state = utils.ExecutionState{}
+		exec.wake = make(chan struct{}, 1)
+		exec.generation++
+		exec.stoppedAt = nil
+		exec.turn = 0
+	}
+	exec.state.State = ExecutionRunning
+	exec.state.Breakpoint = nil
+	exec.setLimits(limit)
+	if paused {
+		exec.signal()
+	}
+	state, generation, wake := exec.state, exec.generation, exec.wake
+	exec.mutex.Unlock()
+
+	if !paused {
+		go mps.execute(generation, wake, 0)
+	}
+	mps.Events.Publish(utils.EventExecution, state)
+	return nil
+}
+
+// Function to pause the execution, it returns when the instruction in progress finishes
+func (mps *MultiprocessingSystem) Pause() error {
+	return mps.changeExecution(ExecutionPaused, ExecutionRunning)
+}
+
+// Function to continue a paused execution without limits
+func (mps *MultiprocessingSystem) Resume() error {
+	return mps.changeExecution(ExecutionRunning, ExecutionPaused)
+}
+
+// Function to end the execution, the PEs keep their state and can still be stepped
+func (mps *MultiprocessingSystem) Cancel() error {
+	return mps.changeExecution(ExecutionCancelled, ExecutionRunning, ExecutionPaused)
+}
+
+// Function to move the execution to a new state from one of the states given
+func (mps *MultiprocessingSystem) changeExecution(next string, from ...string) error {
+	exec := &mps.execution
+	exec.mutex.Lock()
+	current := exec.state.State
+	if current == "" {
+		current = ExecutionIdle
+	}
+	allowed := false
+	for _, state := range from {
+		allowed = allowed || current == state
+	}
+	if !allowed {
+		exec.mutex.Unlock()
+		return fmt.Errorf("the execution is %s, it can't change to %s", current, next)
+	}
+	exec.state.State = next
+	if next == ExecutionRunning {
+		exec.setLimits(RunLimit{Delay: exec.delay})
+		exec.state.Breakpoint = nil
+	}
+	exec.signal()
+	state := exec.state
+	exec.mutex.Unlock()
+
+	// The instruction in progress finishes before the execution stops
+	if next != ExecutionRunning {
+		exec.step.Lock()
+		exec.step.Unlock()
+	}
+	mps.Events.Publish(utils.EventExecution, state)
+	return nil
+}
+
+// Function to get the state of the execution, a system whose PEs finished is Finished unless it's running
+func (mps *MultiprocessingSystem) Execution() utils.ExecutionState {
+	exec := &mps.execution
+	exec.mutex.Lock()
+	state := exec.state
+	exec.mutex.Unlock()
+	if state.State == "" {
+		state.State = ExecutionIdle
+	}
+	if state.State != ExecutionRunning && mps.AreWeFinished() {
+		state.State = ExecutionFinished
+	}
+	return state
+}
+
+// Function to collect the state of the system between two instructions, a free-running execution
+// doesn't change it while it's read
+func (mps *MultiprocessingSystem) StateSnapshot() utils.MultiprocessingSystemState {
+	exec := &mps.execution
+	exec.step.Lock()
+	defer exec.step.Unlock()
+	return mps.State()
+}
+
+// Function to check if the execution is driving the PEs, the PEs can't be stepped by hand meanwhile
+func (mps *MultiprocessingSystem) Running() bool {
+	exec := &mps.execution
+	exec.mutex.Lock()
+	defer exec.mutex.Unlock()
+	return exec.state.State == ExecutionRunning
+}
+
+// Function to get the status of a PE, a PE waiting for a paused execution shows it
+func (mps *MultiprocessingSystem) processingElementStatus(pe *processingElement.ProcessingElement) string {
+	exec := &mps.execution
+	exec.mutex.Lock()
+	paused := exec.state.State == ExecutionPaused
+	exec.mutex.Unlock()
+	if paused && !pe.Finished() && !pe.Executing() {
+		return "Paused"
+	}
+	return pe.GetStatus()
+}
+
+// Function to keep the limits of the execution as counts from the current one
+func (exec *execution) setLimits(limit RunLimit) {
+	exec.state.InstructionLimit = limit.Instructions
+	exec.state.CycleLimit = limit.Cycles
+	exec.untilInstruction, exec.untilCycle = 0, 0
+	if limit.Instructions > 0 {
+		exec.untilInstruction = exec.state.Instructions + limit.Instructions
+	}
+	if limit.Cycles > 0 {
+		exec.untilCycle = exec.state.Cycles + limit.Cycles
+	}
+	exec.delay = limit.Delay
+}
+
+// Function to wake the execution if it's waiting, with the mutex taken
+func (exec *execution) signal() {
+	select {
+	case exec.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Function of the goroutine of an execution, every cycle gives one instruction to each PE that hasn't finished.
+// The first cycle starts at the PE given, a restored execution goes on from the turn where it was saved.
+func (mps *MultiprocessingSystem) execute(generation int, wake chan struct{}, first int) {
+	exec := &mps.execution
+	for {
+		for id := first; id < len(mps.ProcessingElements); id++ {
+			if !mps.waitForTurn(generation, wake) {
+				return
+			}
+			// A PE stepped by hand while paused may still be executing its instruction
+			pe := mps.ProcessingElements[id]
+			if pe.Finished() || pe.Executing() {
+				continue
+			}
+			// A breakpoint on the instruction pauses before it, the same PE goes on when the execution continues
+			if mps.breakBefore(generation, id) {
+				mps.Events.Publish(utils.EventExecution, mps.Execution())
+				id--
+				continue
+			}
+			position, instruction := nextInstruction(pe)
+			marks := mps.markBreakpoints()
+
+			// The instruction is counted before pause and cancel return
+			exec.step.RLock()
+			err := mps.stepAndWait(id)
+			hit := false
+			if err == nil {
+				mps.count(generation, func(state *utils.ExecutionState) bool {
+					exec.turn = id + 1
+					state.Instructions++
+					return state.Instructions == exec.untilInstruction
+				})
+				hit = mps.breakAfter(generation, id, position, instruction, marks)
+			}
+			exec.step.RUnlock()
+			if err != nil {
+				final := ExecutionCancelled
+				if mps.Violation() != nil {
+					final = ExecutionStopped
+				}
+				mps.endExecution(generation, final)
+				return
+			}
+			if hit {
+				mps.Events.Publish(utils.EventExecution, mps.Execution())
+			}
+		}
+		first = 0
+
+		// The end of the cycle is also counted before pause and cancel return
+		exec.step.Lock()
+		finished := mps.AreWeFinished()
+		if !finished {
+			mps.count(generation, func(state *utils.ExecutionState) bool {
+				exec.turn = 0
+				state.Cycles++
+				return state.Cycles == exec.untilCycle
+			})
+		}
+		exec.step.Unlock()
+		if finished {
+			mps.endExecution(generation, ExecutionFinished)
+			return
+		}
+
+		exec.mutex.Lock()
+		delay := exec.delay
+		exec.mutex.Unlock()
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-wake:
+			case <-mps.Terminate:
+				return
+			}
+		}
+	}
+}
+
+// Function to update a counter of the execution, it pauses when the counter reaches its limit
+func (mps *MultiprocessingSystem) count(generation int, update func(state *utils.ExecutionState) bool) {
+	exec := &mps.execution
+	exec.mutex.Lock()
+	if exec.generation != generation {
+		exec.mutex.Unlock()
+		return
+	}
+	reached := update(&exec.state) && exec.state.State == ExecutionRunning
+	if reached {
+		exec.state.State = ExecutionPaused
+	}
+	state := exec.state
+	exec.mutex.Unlock()
+	if reached {
+		mps.Events.Publish(utils.EventExecution, state)
+	}
+}
+
+// Function to wait while the execution is paused, it returns false when the execution ends
+func (mps *MultiprocessingSystem) waitForTurn(generation int, wake chan struct{}) bool {
+	exec := &mps.execution
+	for {
+		exec.mutex.Lock()
+		state, current := exec.state.State, exec.generation
+		exec.mutex.Unlock()
+		switch {
+		case current != generation:
+			return false
+		case state == ExecutionRunning:
+			return true
+		case state == ExecutionPaused:
+			select {
+			case <-wake:
+			case <-mps.Terminate:
+				return false
+			}
+		default:
+			return false
+		}
+	}
+}
+
+// Function to finish the execution with a final state
+func (mps *MultiprocessingSystem) endExecution(generation int, final string) {
+	exec := &mps.execution
+	exec.mutex.Lock()
+	if exec.generation != generation {
+		exec.mutex.Unlock()
+		return
+	}
+	if exec.state.State == ExecutionRunning || exec.state.State == ExecutionPaused {
+		exec.state.State = final
+	}
+	state := exec.state
+	exec.mutex.Unlock()
+	mps.Events.Publish(utils.EventExecution, state)
+}
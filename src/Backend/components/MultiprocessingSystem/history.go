@@ -0,0 +1,241 @@
+package MultiprocessingSystem
+
+import (
+	"fmt"
+	"sync"
+
+	processingElement "Backend/components/ProcessingElement"
+	"Backend/utils"
+)
+
+// Maximum number of steps kept by the history, the oldest ones are forgotten
+const HistoryLimit = 100000
+
+// Changes of the registers, the caches and the memory at every step, to go back to any earlier step
+type history struct {
+	mutex   sync.Mutex
+	first   utils.HistoryState  // State at the oldest step kept
+	current utils.HistoryState  // State after the last step
+	steps   []utils.HistoryStep // Changes of every step after the oldest one
+
+	// Snapshots of the whole system with the steps after them, the oldest one is at or before the first state
+	snapshots []rewindSnapshot
+}
+
+// Function to get the registers, the caches and the memory of the system, only while no PE is executing
+func (mps *MultiprocessingSystem) historyState() utils.HistoryState {
+	state := utils.HistoryState{PEs: []utils.HistoryProcessingElement{}, Blocks: []utils.HistoryBlock{}, Memory: utils.BlockObjectList{}}
+	for _, pe := range mps.ProcessingElements {
+		pc, _ := pe.GetInstructions()
+		state.PEs = append(state.PEs, utils.HistoryProcessingElement{
+			ID:          pe.ID,
+			Register:    pe.GetRegister(),
+			PC:          pc,
+			Done:        pe.Finished(),
+			StoreBuffer: pe.AboutStoreBuffer(),
+		})
+	}
+	for _, cc := range mps.CacheControllers {
+		for i := 0; i < cc.Cache.Lines(); i++ {
+			state.Blocks = append(state.Blocks, utils.HistoryBlock{
+				Cache:   cc.ID,
+				Block:   i,
+				Address: cc.Cache.GetAddress(i),
+				Data:    cc.Cache.GetData(i),
+				State:   cc.Cache.GetState(i),
+			})
+		}
+	}
+	for address, data := range mps.MainMemory.Data {
+		state.Memory = append(state.Memory, utils.BlockObject{Address: address, Data: int(data)})
+	}
+	return state
+}
+
+// Function to get the description of the next step of a PE before it executes it
+func stepDescription(pe *processingElement.ProcessingElement) (int, string) {
+	position, instruction := nextInstruction(pe)
+	// A PE without instructions drains the oldest store of its store buffer
+	if instruction == "" && len(pe.StoreBuffer) > 0 {
+		return drainDescription(pe, 0)
+	}
+	return position, instruction
+}
+
+// Function to get the description of the drain of a store before the PE sends it to its Cache Controller
+func drainDescription(pe *processingElement.ProcessingElement, index int) (int, string) {
+	if index < 0 || index >= len(pe.StoreBuffer) {
+		return pe.PC, "Drain"
+	}
+	return pe.PC, fmt.Sprintf("Drain WRITE %d", pe.StoreBuffer[index].Address)
+}
+
+// Function to record a step sent by the legacy API once the PE finishes it, then release the lock of the instructions
+func (mps *MultiprocessingSystem) recordWhenCompleted(pe *processingElement.ProcessingElement, drain int, position int, instruction string) {
+	defer mps.execution.step.RUnlock()
+	if mps.waitForCompletion(pe) == nil {
+		mps.waitForChecker()
+		mps.recordStep(pe.ID, drain, position, instruction)
+	}
+}
+
+// Function to start the history at the current state of the system
+func (mps *MultiprocessingSystem) resetHistory() {
+	h := &mps.history
+	state := mps.historyState()
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.first = state
+	h.current = copyHistoryState(state)
+	h.steps = nil
+	h.snapshots = []rewindSnapshot{mps.snapshot(state.Step)}
+}
+
+// Function to add a step finished by a PE to the history with the components it changed,
+// drain is the store drained from its store buffer or -1 for the next step of the PE
+func (mps *MultiprocessingSystem) recordStep(core int, drain int, position int, instruction string) {
+	h := &mps.history
+	state := mps.historyState()
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	step := utils.HistoryStep{
+		Step:        h.current.Step + 1,
+		Core:        core,
+		Position:    position,
+		Instruction: instruction,
+		PEs:         []utils.HistoryProcessingElement{},
+		Blocks:      []utils.HistoryBlock{},
+		Memory:      utils.BlockObjectList{},
+	}
+	for i, pe := range state.PEs {
+		if !samePE(pe, h.current.PEs[i]) {
+			step.PEs = append(step.PEs, pe)
+		}
+	}
+	for i, block := range state.Blocks {
+		if block != h.current.Blocks[i] {
+			step.Blocks = append(step.Blocks, block)
+		}
+	}
+	for i, word := range state.Memory {
+		if word != h.current.Memory[i] {
+			step.Memory = append(step.Memory, word)
+		}
+	}
+	state.Step = step.Step
+	h.current = state
+	h.steps = append(h.steps, step)
+	mps.keepStep(rewindAction{core: core, drain: drain}, step.Step)
+
+	// The oldest step becomes part of the first state kept
+	if len(h.steps) > HistoryLimit {
+		applyStep(&h.first, h.steps[0])
+		h.steps = h.steps[1:]
+		h.forgetSnapshots()
+	}
+}
+
+// Function to get the steps kept by the history
+func (mps *MultiprocessingSystem) History() []utils.HistoryStep {
+	h := &mps.history
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]utils.HistoryStep{}, h.steps...)
+}
+
+// Function to get the registers, the caches and the memory as they were after a step, 0 is the initial state
+func (mps *MultiprocessingSystem) StateAtStep(step int) (utils.HistoryState, error) {
+	h := &mps.history
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if step < h.first.Step || step > h.current.Step {
+		return utils.HistoryState{}, fmt.Errorf("the history goes from step %d to step %d", h.first.Step, h.current.Step)
+	}
+	state := copyHistoryState(h.first)
+	for _, change := range h.steps[:step-h.first.Step] {
+		applyStep(&state, change)
+	}
+	return state, nil
+}
+
+// Function to get what changed in the PEs, the caches and the memory from a step to another one
+func (mps *MultiprocessingSystem) DiffSteps(from int, to int) (utils.HistoryDiff, error) {
+	before, err := mps.StateAtStep(from)
+	if err != nil {
+		return utils.HistoryDiff{}, err
+	}
+	after, err := mps.StateAtStep(to)
+	if err != nil {
+		return utils.HistoryDiff{}, err
+	}
+
+	diff := utils.HistoryDiff{From: from, To: to, Changes: []utils.HistoryChange{}}
+	change := func(component string, field string, before interface{}, after interface{}) {
+		if text, next := fmt.Sprint(before), fmt.Sprint(after); text != next {
+			diff.Changes = append(diff.Changes, utils.HistoryChange{Component: component, Field: field, Before: text, After: next})
+		}
+	}
+	for i, pe := range after.PEs {
+		component := fmt.Sprintf("PE%d", pe.ID)
+		change(component, "Register", before.PEs[i].Register, pe.Register)
+		change(component, "PC", before.PEs[i].PC, pe.PC)
+		change(component, "Done", before.PEs[i].Done, pe.Done)
+		change(component, "StoreBuffer", storeBufferText(before.PEs[i].StoreBuffer), storeBufferText(pe.StoreBuffer))
+	}
+	for i, block := range after.Blocks {
+		change(fmt.Sprintf("CC%d", block.Cache), fmt.Sprintf("Block %d", block.Block), blockText(before.Blocks[i]), blockText(block))
+	}
+	for i, word := range after.Memory {
+		change("MM", fmt.Sprintf("Address %d", word.Address), before.Memory[i].Data, word.Data)
+	}
+	return diff, nil
+}
+
+// Function to apply the changes of a step to a state of the history
+func applyStep(state *utils.HistoryState, step utils.HistoryStep) {
+	state.Step = step.Step
+	for _, pe := range step.PEs {
+		state.PEs[pe.ID] = pe
+	}
+	lines := len(state.Blocks) / len(state.PEs)
+	for _, block := range step.Blocks {
+		state.Blocks[block.Cache*lines+block.Block] = block
+	}
+	for _, word := range step.Memory {
+		state.Memory[word.Address] = word
+	}
+}
+
+// Function to copy a state of the history, the copy can be changed without changing the original
+func copyHistoryState(state utils.HistoryState) utils.HistoryState {
+	copied := utils.HistoryState{
+		Step:   state.Step,
+		PEs:    append([]utils.HistoryProcessingElement{}, state.PEs...),
+		Blocks: append([]utils.HistoryBlock{}, state.Blocks...),
+		Memory: append(utils.BlockObjectList{}, state.Memory...),
+	}
+	return copied
+}
+
+// Function to compare two PEs of the history
+func samePE(a utils.HistoryProcessingElement, b utils.HistoryProcessingElement) bool {
+	return a.Register == b.Register && a.PC == b.PC && a.Done == b.Done && storeBufferText(a.StoreBuffer) == storeBufferText(b.StoreBuffer)
+}
+
+// Function to write the stores of a store buffer as text
+func storeBufferText(storeBuffer utils.StoreBufferObjectList) string {
+	text := "["
+	for i, store := range storeBuffer {
+		if i > 0 {
+			text += ", "
+		}
+		text += fmt.Sprintf("WRITE %d = %d", store.Address, store.Data)
+	}
+	return text + "]"
+}
+
+// Function to write a cache block as text
+func blockText(block utils.HistoryBlock) string {
+	return fmt.Sprintf("Address %d, Data %d, State %s", block.Address, block.Data, block.State)
+}
@@ -0,0 +1,167 @@
+package MultiprocessingSystem
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"Backend/utils"
+)
+
+// Coherence invariants checked after every access when the configuration asks for them
+const (
+	InvariantSWMR      = "SWMR"               // A single writer (a block in M) or any number of readers
+	InvariantOwner     = "SingleOwner"        // At most one cache holds a block in M, E or O
+	InvariantExclusive = "ExclusiveNoSharers" // A block in E or M has no other copies
+	InvariantDataValue = "DataValue"          // The copies, and the memory when no cache owns the block, hold the last value stored
+)
+
+// Shadow memory and report of the invariant checker
+type invariantChecker struct {
+	mutex        sync.Mutex
+	enabled      bool
+	golden       [MemorySize]int // Last value stored to every address, the value every copy must hold
+	transactions int             // Bus transactions already given to an access
+	violation    *utils.InvariantViolation
+}
+
+// Function to start checking the invariants after every access, the shadow memory starts with the current values
+func (mps *MultiprocessingSystem) startInvariants() {
+	checker := &mps.invariants
+	checker.mutex.Lock()
+	checker.enabled = true
+	for address := range checker.golden {
+		checker.golden[address] = mps.CoherentValue(address)
+	}
+	_, checker.transactions = mps.Recorder.Lengths()
+	checker.mutex.Unlock()
+	for _, cc := range mps.CacheControllers {
+		cc.Checker = mps.checkInvariants
+	}
+}
+
+// Function called by a Cache Controller after an access, before it gives the bus to another one.
+// The first access that breaks an invariant stops the system, its report is kept.
+func (mps *MultiprocessingSystem) checkInvariants(access utils.CacheAccess) {
+	checker := &mps.invariants
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+	if access.Write {
+		checker.golden[access.Address] = access.Value
+	}
+	operations, _ := mps.Recorder.Lengths()
+	_, transactions := mps.Recorder.Since(operations, checker.transactions)
+	checker.transactions += len(transactions)
+	if checker.violation != nil {
+		return
+	}
+
+	failures := []utils.InvariantFailure{}
+	for address := range checker.golden {
+		failures = append(failures, mps.AddressFailures(address, checker.golden[address])...)
+	}
+	if len(failures) == 0 {
+		return
+	}
+	checker.violation = &utils.InvariantViolation{Access: access, Transactions: transactions, Failures: failures}
+	mps.Events.Publish(utils.EventViolation, *checker.violation)
+}
+
+// Function to check the invariants of an address between two accesses, expected is the last value stored to it
+func (mps *MultiprocessingSystem) AddressFailures(address int, expected int) []utils.InvariantFailure {
+	copies, writers, owners, exclusives := []string{}, []string{}, []string{}, []string{}
+	dirty := false
+	stale := []string{}
+	for _, cc := range mps.CacheControllers {
+		for block := 0; block < cc.Cache.Lines(); block++ {
+			state := cc.Cache.GetState(block)
+			if cc.Cache.GetAddress(block) != address || state == "I" {
+				continue
+			}
+			name := fmt.Sprintf("CC%d", cc.ID)
+			data := cc.Cache.GetData(block)
+			copies = append(copies, fmt.Sprintf("%s: %s %d", name, state, data))
+			switch state {
+			case "M":
+				writers = append(writers, name)
+				owners = append(owners, name)
+				exclusives = append(exclusives, name)
+			case "E":
+				owners = append(owners, name)
+				exclusives = append(exclusives, name)
+			case "O":
+				owners = append(owners, name)
+			}
+			dirty = dirty || state == "M" || state == "O"
+			if data != expected {
+				stale = append(stale, fmt.Sprintf("%s holds %d", name, data))
+			}
+		}
+	}
+
+	memory := int(mps.MainMemory.Data[address])
+	failures := []utils.InvariantFailure{}
+	fail := func(invariant string, detail string) {
+		failures = append(failures, utils.InvariantFailure{Invariant: invariant, Address: address, Detail: detail, Copies: copies, Memory: memory, Expected: expected})
+	}
+	if len(writers) > 1 || (len(writers) == 1 && len(copies) > 1) {
+		fail(InvariantSWMR, fmt.Sprintf("%s can write the address %d while %d caches hold it", strings.Join(writers, " and "), address, len(copies)))
+	}
+	if len(owners) > 1 {
+		fail(InvariantOwner, fmt.Sprintf("%s hold the address %d in M, E or O", strings.Join(owners, " and "), address))
+	}
+	if len(exclusives) > 0 && len(copies) > 1 {
+		fail(InvariantExclusive, fmt.Sprintf("%s holds the address %d in E or M but it has %d sharers", strings.Join(exclusives, " and "), address, len(copies)-1))
+	}
+	if len(stale) > 0 {
+		fail(InvariantDataValue, fmt.Sprintf("the last value stored to the address %d is %d but %s", address, expected, strings.Join(stale, " and ")))
+	}
+	if !dirty && memory != expected {
+		fail(InvariantDataValue, fmt.Sprintf("no cache owns the address %d and the memory holds %d instead of %d", address, memory, expected))
+	}
+	return failures
+}
+
+// Function to know if the invariants are checked after every access
+func (mps *MultiprocessingSystem) CheckingInvariants() bool {
+	checker := &mps.invariants
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+	return checker.enabled
+}
+
+// Function to get the report of the access that broke the invariants, nil while they hold
+func (mps *MultiprocessingSystem) Violation() *utils.InvariantViolation {
+	checker := &mps.invariants
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+	if checker.violation == nil {
+		return nil
+	}
+	violation := *checker.violation
+	return &violation
+}
+
+// Function to get an error for a system stopped by a violation, nil while the invariants hold
+func (mps *MultiprocessingSystem) violationError() error {
+	violation := mps.Violation()
+	if violation == nil {
+		return nil
+	}
+	failure := violation.Failures[0]
+	return fmt.Errorf("the coherence invariant %s failed after the %s %d of CC%d: %s", failure.Invariant, violation.Access.Kind, violation.Access.Address, violation.Access.Cache, failure.Detail)
+}
+
+// Function to wait until the Cache Controller that served the last step gives the bus back, after its check
+func (mps *MultiprocessingSystem) waitForChecker() error {
+	if !mps.CheckingInvariants() {
+		return nil
+	}
+	select {
+	case mps.Semaphore <- struct{}{}:
+		<-mps.Semaphore
+	case <-mps.Terminate:
+		return fmt.Errorf("the system was stopped")
+	}
+	return mps.violationError()
+}
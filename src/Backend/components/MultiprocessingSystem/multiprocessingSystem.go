@@ -3,9 +3,12 @@ package MultiprocessingSystem
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"Backend/components/CacheController"
 	interconnect "Backend/components/Interconnect"
@@ -31,68 +34,175 @@ type MultiprocessingSystem struct {
 	RequestChannelM3          chan utils.RequestMainMemory
 	ResponseChannelM3         chan utils.ResponseMainMemory
 	Semaphore                 chan struct{}
+	Config                    Config
+	Recorder                  *utils.TraceRecorder
+	Events                    *utils.EventStream
+	stopOnce                  sync.Once        // Stop can be called again, for example when the server shuts down after a close
+	execution                 execution        // Free-running execution started by Run
+	history                   history          // Changes of every step to go back to an earlier one
+	invariants                invariantChecker // Coherence invariants checked after every access
 }
 
-// Function that initializes a new Multiprocessing System
+// Function that initializes a new Multiprocessing System, it returns nil when a program is invalid
 func Start(Protocol string, CodeGenerator bool, InstructionsPerCore int) *MultiprocessingSystem {
-	fmt.Println("Starting a new Multiprocessing System...")
-	fmt.Printf("Initializing %s protocol...\n", Protocol)
+	cfg := DefaultConfig()
+	cfg.Protocol = Protocol
+	cfg.CodeGenerator = CodeGenerator
+	cfg.InstructionsPerCore = InstructionsPerCore
+	mps, err := StartWithConfig(cfg)
+	if err != nil {
+		fmt.Printf("Error initializing the Multiprocessing System:\n%v\n", err)
+		return nil
+	}
+	return mps
+}
+
+// Function that initializes a new Multiprocessing System from a configuration
+// The programs are assembled before any component starts, an invalid program returns its diagnostics
+func StartWithConfig(cfg Config) (*MultiprocessingSystem, error) {
+	return start(cfg, nil)
+}
+
+// Function that initializes a new Multiprocessing System in place of another one that writes its logs in the same folder.
+// The system replaced is stopped only once the new one can't fail, it keeps running when the configuration is invalid.
+func Replace(replaced *MultiprocessingSystem, cfg Config) (*MultiprocessingSystem, error) {
+	return start(cfg, replaced)
+}
+
+// Function that initializes a new Multiprocessing System, stopping the one replaced before creating any component
+func start(cfg Config, replaced *MultiprocessingSystem) (*MultiprocessingSystem, error) {
+	Protocol := cfg.Protocol
+	Cores := cfg.Cores
+	// Batch runs ask for a quiet console
+	printf := fmt.Printf
+	if cfg.Quiet {
+		printf = func(string, ...interface{}) (int, error) { return 0, nil }
+	}
+	printf("Starting a new Multiprocessing System...\n")
+	printf("Initializing %s protocol...\n", Protocol)
+	printf("Initializing %s consistency model...\n", cfg.Consistency)
+
+	// Create the folders for the log files
+	for _, folder := range []string{"CC", "PE", "IC", "MM"} {
+		if err := os.MkdirAll(filepath.Join(cfg.LogDirectory, folder), 0755); err != nil {
+			printf("Error creating the log folder %s: %v\n", folder, err)
+		}
+	}
+
 	// Is it necessary to generate a random program for the Processing Elements??
-	if CodeGenerator {
-		instructions := utils.GenerateRandomInstructions(3, InstructionsPerCore)
+	initialWords := map[int]uint32{}
+	if cfg.Traces != nil {
+		printf("Replaying the traces given in the configuration \n")
+	} else if !cfg.UsesProgramFiles() {
+		printf("Using the programs given in the configuration \n")
+	} else if cfg.CodeGenerator {
+		instructions := utils.GenerateRandomInstructions(Cores, cfg.InstructionsPerCore)
+		if cfg.Workload != nil {
+			printf("Generating the %s workload with seed %d \n", cfg.Workload.Preset, cfg.Workload.Seed)
+			generated, err := utils.GenerateWorkload(Cores, *cfg.Workload)
+			if err != nil {
+				return nil, err
+			}
+			instructions = generated
+			initialWords = utils.WorkloadInitialMemory(Cores, *cfg.Workload)
+		}
 		// Write instructions to files
+		if err := os.MkdirAll(filepath.Dir(cfg.ProgramFile(0)), 0755); err != nil {
+			printf("Error creating the program folder: %v\n", err)
+		}
 		for coreID, coreInstructions := range instructions {
-			filename := fmt.Sprintf("generated-programs/program%d.txt", coreID)
+			filename := cfg.ProgramFile(coreID)
 			err := utils.WriteInstructionsToFile(filename, coreInstructions)
 			if err != nil {
-				fmt.Printf("Error writing to file for Core %d: %v\n", coreID, err)
+				printf("Error writing to file for Core %d: %v\n", coreID, err)
 			} else {
-				fmt.Printf("Instructions for Core %d written to %s\n", coreID, filename)
+				printf("Instructions for Core %d written to %s\n", coreID, filename)
 			}
 		}
 	} else {
-		fmt.Printf("Reusing the previous generated code \n")
-		for i := 0; i < 3; i++ {
-			filename := fmt.Sprintf("generated-programs/program%d.txt", i)
+		printf("Reusing the previous generated code \n")
+		for i := 0; i < Cores; i++ {
+			if cfg.HasProgram(i) {
+				continue
+			}
+			filename := cfg.ProgramFile(i)
 			isEmpty, err := FileIsEmpty(filename)
 			if err != nil {
-				fmt.Printf("Error reading file.")
+				printf("Error reading file.")
 			}
 			// Check if the file has no instructions
 			if isEmpty {
-				fmt.Printf("generated-programs/program%d.txt is not valid\n", i)
+				printf("%s is not valid\n", filename)
 			}
 		}
 	}
 
+	// Assemble every program before starting any component
+	programs, err := cfg.LoadPrograms()
+	if err != nil {
+		return nil, err
+	}
+	// A restored system must be the one saved in the checkpoint
+	if cfg.Checkpoint != nil {
+		if err := cfg.Checkpoint.validate(cfg, programs); err != nil {
+			return nil, err
+		}
+	}
+	// Nothing fails from here on, the logs of the system replaced are closed before the new ones are created
+	if replaced != nil {
+		replaced.Stop()
+	}
+
+	// Record the memory operations and the bus transactions to export them as traces
+	recorder := utils.NewTraceRecorder()
+	if cfg.Checkpoint != nil {
+		recorder = utils.RestoreTraceRecorder(cfg.Checkpoint.Trace)
+	}
+
 	// Create termination channel to signal the termination to all threads
 	terminate := make(chan struct{})
 
+	// The last PE that finishes publishes the end of the run with its results
+	var mps *MultiprocessingSystem
+	remaining := int32(Cores)
+	if cfg.Checkpoint != nil {
+		for _, pe := range cfg.Checkpoint.ProcessingElements {
+			if pe.IsDone {
+				remaining--
+			}
+		}
+	}
+	finished := func() {
+		if atomic.AddInt32(&remaining, -1) == 0 && cfg.Events != nil {
+			cfg.Events.Publish(utils.EventRunFinished, mps.Results())
+		}
+	}
+
 	// Create WaitGroup for PEs and CCs
 	var wg sync.WaitGroup
 
 	// Declare the Communication Channels array for PE-CC
-	RequestChannelsM1 := make([]chan utils.RequestProcessingElement, 3)
-	ResponseChannelsM1 := make([]chan utils.ResponseProcessingElement, 3)
+	RequestChannelsM1 := make([]chan utils.RequestProcessingElement, Cores)
+	ResponseChannelsM1 := make([]chan utils.ResponseProcessingElement, Cores)
 
 	// Declare the Communication Channels array for CC-IC
-	RequestChannelsM2 := make([]chan utils.RequestInterconnect, 3)
-	ResponseChannelsM2 := make([]chan utils.ResponseInterconnect, 3)
+	RequestChannelsM2 := make([]chan utils.RequestInterconnect, Cores)
+	ResponseChannelsM2 := make([]chan utils.ResponseInterconnect, Cores)
 
 	// Declare the Broadcast Communication Channels array for CC-IC
-	RequestChannelsBroadcast := make([]chan utils.RequestBroadcast, 3)
-	ResponseChannelsBroadcast := make([]chan utils.ResponseBroadcast, 3)
+	RequestChannelsBroadcast := make([]chan utils.RequestBroadcast, Cores)
+	ResponseChannelsBroadcast := make([]chan utils.ResponseBroadcast, Cores)
 
 	// Declare the Communication Channels for the Interconnect and Main Memory
 	RequestChannelM3 := make(chan utils.RequestMainMemory)
 	ResponseChannelM3 := make(chan utils.ResponseMainMemory)
 
-	// Create and start 3 Cache Controllers with the communication channels
-	ccs := make([]*CacheController.CacheController, 3) // Create an array of Cache Controllers
+	// Create and start the Cache Controllers with the communication channels
+	ccs := make([]*CacheController.CacheController, Cores) // Create an array of Cache Controllers
 
 	semaphore := make(chan struct{}, 1) // Initialize with a count of 1
 
-	for i := 0; i < 3; i++ {
+	for i := 0; i < Cores; i++ {
 		// Create the Request and Response channels for PE and IC communications
 		requestChannelM1 := make(chan utils.RequestProcessingElement)
 		responseChannelM1 := make(chan utils.ResponseProcessingElement)
@@ -114,10 +224,16 @@ func Start(Protocol string, CodeGenerator bool, InstructionsPerCore int) *Multip
 			responseChannelBroadcast,
 			semaphore,
 			Protocol,
-			"logs/CC/CC",
+			filepath.Join(cfg.LogDirectory, "CC", "CC"),
 			terminate)
 		if err != nil {
-			fmt.Printf("Error initializing CacheController %d: %v\n", i+1, err)
+			printf("Error initializing CacheController %d: %v\n", i+1, err)
+		}
+		cacheController.Latency = cfg.Latency
+		cacheController.SetCacheGeometry(cfg.CacheGeometry())
+		cacheController.Events = cfg.Events
+		if cfg.Checkpoint != nil {
+			cfg.Checkpoint.restoreCacheController(cacheController)
 		}
 
 		// Add the CacheController to the Wait Group
@@ -139,28 +255,44 @@ func Start(Protocol string, CodeGenerator bool, InstructionsPerCore int) *Multip
 		ResponseChannelsBroadcast[i] = responseChannelBroadcast
 	}
 
-	// Create and start 3 Processing Elements
-	pes := make([]*processingElement.ProcessingElement, 3) // Create an array of PEs
+	// Create and start the Processing Elements
+	pes := make([]*processingElement.ProcessingElement, Cores) // Create an array of PEs
 
-	for i := 0; i < 3; i++ {
-		pe, err := processingElement.New(
-			i, 
-			RequestChannelsM1[i], 
-			ResponseChannelsM1[i], 
-			fmt.Sprintf("generated-programs/program%d.txt", i),
-			"logs/PE/PE",
+	for i := 0; i < Cores; i++ {
+		// The trace-driven mode replays memory accesses instead of running a program
+		newProcessingElement := processingElement.NewWithProgram
+		if cfg.Traces != nil {
+			newProcessingElement = processingElement.NewWithTrace
+		}
+		pe, err := newProcessingElement(
+			i,
+			RequestChannelsM1[i],
+			ResponseChannelsM1[i],
+			programs[i],
+			filepath.Join(cfg.LogDirectory, "PE", "PE"),
 			terminate)
 		if err != nil {
-			fmt.Printf("Error initializing ProcessingElement %d: %v\n", i+1, err)
+			printf("Error initializing ProcessingElement %d: %v\n", i+1, err)
 		}
+		pe.Filename = cfg.ProgramName(i)
+		pe.Consistency = cfg.Consistency
+		pe.Recorder = recorder
+		pe.Events = cfg.Events
+		pe.OnDone = finished
+		if cfg.Checkpoint != nil {
+			cfg.Checkpoint.restoreProcessingElement(pe)
+		}
+		pes[i] = pe
 
+		// A PE that was already done when the checkpoint was saved doesn't run again
+		if pe.IsDone {
+			continue
+		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			pe.Run(&wg)
 		}()
-
-		pes[i] = pe
 	}
 
 	// Create the Interconnect and attach the communication channels with the 3 CacheControllers
@@ -173,10 +305,16 @@ func Start(Protocol string, CodeGenerator bool, InstructionsPerCore int) *Multip
 		RequestChannelsBroadcast,
 		ResponseChannelsBroadcast,
 		Protocol,
-		"logs/IC/",
+		filepath.Join(cfg.LogDirectory, "IC")+"/",
 		terminate)
 	if err != nil {
-		fmt.Printf("Error initializing Interconnect: %v\n", err)
+		printf("Error initializing Interconnect: %v\n", err)
+	}
+	interconnect.Latency = cfg.Latency
+	interconnect.Recorder = recorder
+	interconnect.Events = cfg.Events
+	if cfg.Checkpoint != nil {
+		cfg.Checkpoint.restoreInterconnect(interconnect)
 	}
 
 	// Start Interconnect
@@ -190,10 +328,28 @@ func Start(Protocol string, CodeGenerator bool, InstructionsPerCore int) *Multip
 	mainMemory, err := mainMemory.New(
 		RequestChannelM3, 
 		ResponseChannelM3,
-		"logs/MM/",
+		filepath.Join(cfg.LogDirectory, "MM")+"/",
 		terminate)
 	if err != nil {
-		fmt.Printf("Error initializing Main Memory: %v\n", err)
+		printf("Error initializing Main Memory: %v\n", err)
+	}
+	mainMemory.Latency = cfg.Latency
+	// Replace the random values when the configuration gives the initial memory
+	if cfg.InitialMemory != nil {
+		for address := range mainMemory.Data {
+			mainMemory.Data[address] = 0
+			if address < len(cfg.InitialMemory) {
+				mainMemory.Data[address] = cfg.InitialMemory[address]
+			}
+		}
+	} else {
+		// The generated workload needs some words with a known value, like its free locks
+		for address, value := range initialWords {
+			mainMemory.Data[address] = value
+		}
+	}
+	if cfg.Checkpoint != nil {
+		cfg.Checkpoint.restoreMainMemory(mainMemory)
 	}
 	// Start Main Memory
 	wg.Add(1)
@@ -202,7 +358,7 @@ func Start(Protocol string, CodeGenerator bool, InstructionsPerCore int) *Multip
 		mainMemory.Run(&wg)
 	}()
 
-	return &MultiprocessingSystem{
+	mps = &MultiprocessingSystem{
 		CacheControllers:          ccs,
 		ProcessingElements:        pes,
 		Interconnect:              interconnect,
@@ -218,11 +374,37 @@ func Start(Protocol string, CodeGenerator bool, InstructionsPerCore int) *Multip
 		RequestChannelM3:          RequestChannelM3,
 		ResponseChannelM3:         ResponseChannelM3,
 		Semaphore:                 semaphore,
+		Config:                    cfg,
+		Recorder:                  recorder,
+		Events:                    cfg.Events,
+	}
+	if cfg.CheckInvariants {
+		mps.startInvariants()
+	}
+	mps.resetHistory()
+	if cfg.Checkpoint != nil {
+		mps.restoreExecution(cfg.Checkpoint)
 	}
+	return mps, nil
 }
 
 // Function to create a JSON object with all the information of the Multiprocessing System
 func (mps *MultiprocessingSystem) GetState() (string, error) {
+	// Return a string with the JSON as a string
+	// Marshal the PE struct into a JSON string
+	jsonData, err := json.MarshalIndent(mps.StateSnapshot(), "", "    ")
+	if err != nil {
+		return "", err
+	}
+
+	// Convert the byte slice to a string
+	jsonString := string(jsonData)
+
+	return jsonString, nil
+}
+
+// Function to collect the state of the PEs, the CCs, the Interconnect and the Main Memory
+func (mps *MultiprocessingSystem) State() utils.MultiprocessingSystemState {
 
 	// Create the AboutProcessingElementList
 	pes := utils.AboutProcessingElementList{}
@@ -231,7 +413,8 @@ func (mps *MultiprocessingSystem) GetState() (string, error) {
 		instructions := utils.InstructionObjectList{}
 
 		// Get the values from the instructions queue of the Processing Element
-		for i, item := range pe.Instructions.Items {
+		_, items := pe.GetInstructions()
+		for i, item := range items {
 			// Create an InstructionObject
 			instructionObj := utils.InstructionObject{
 				Position:    i,
@@ -243,9 +426,11 @@ func (mps *MultiprocessingSystem) GetState() (string, error) {
 		// Create a struct for the PE
 		aboutPE := utils.AboutProcessingElement{
 			ID:           pe.ID,
-			Register:     pe.Register,
-			Status:       pe.Status,
+			Register:     pe.GetRegister(),
+			Status:       mps.processingElementStatus(pe),
 			Instructions: instructions,
+			Consistency:  pe.Consistency,
+			StoreBuffer:  pe.AboutStoreBuffer(),
 		}
 
 		// Add it to the list
@@ -257,7 +442,7 @@ func (mps *MultiprocessingSystem) GetState() (string, error) {
 	for _, cc := range mps.CacheControllers {
 		// Create an empty CacheObjectList
 		cacheBlocks := utils.CacheObjectList{}
-		for i := 0; i <= 3; i++ {
+		for i := 0; i < cc.Cache.Lines(); i++ {
 			// Create a new CacheObject instance
 			cacheObj := utils.CacheObject{
 				Block:   i,
@@ -318,75 +503,257 @@ func (mps *MultiprocessingSystem) GetState() (string, error) {
 	}
 
 	// Create the final object
-	JSON := utils.MultiprocessingSystemState{
-		PEs: pes,
-		CCs: ccs,
-		IC:  ic,
-		MM:  mm,
+	return utils.MultiprocessingSystemState{
+		PEs:       pes,
+		CCs:       ccs,
+		IC:        ic,
+		MM:        mm,
+		Execution: mps.Execution(),
 	}
+}
 
-	// Return a string with the JSON as a string
-	// Marshal the PE struct into a JSON string
-	jsonData, err := json.MarshalIndent((JSON), "", "    ")
-	if err != nil {
-		return "", err
+// Function to apply a steping to an individual Processing Element, it fails when the system was stopped.
+// The step holds the lock of the instructions until the PE finishes it, like the steps of the execution.
+func (mps *MultiprocessingSystem) SteppingProcessingElement(ID int) (string, error) {
+	if ID < 0 || ID >= len(mps.ProcessingElements) {
+		return "Invalid PE number", nil
+	}
+	if mps.Stopped() {
+		return "", fmt.Errorf("the system was stopped")
 	}
+	if mps.Running() {
+		return "The system is running, pause it first", nil
+	}
+	if mps.Violation() != nil {
+		return "A coherence invariant failed, the system stopped", nil
+	}
+	exec := &mps.execution
+	exec.step.RLock()
+	pe := mps.ProcessingElements[ID]
+	if !pe.Finished() && !pe.Executing() {
+		position, instruction := stepDescription(pe)
+		forgetCompletion(pe)
+		select {
+		case pe.Control <- true:
+		case <-mps.Terminate:
+			exec.step.RUnlock()
+			return "", fmt.Errorf("the system was stopped")
+		}
+		go mps.recordWhenCompleted(pe, -1, position, instruction)
+		return "Sent 'step' command to PE", nil
+	} else {
+		exec.step.RUnlock()
+		return "PE is not available...", nil
+	}
+}
 
-	// Convert the byte slice to a string
-	jsonString := string(jsonData)
+// Function to send one store from the store buffer of a Processing Element to its Cache Controller,
+// it fails when the system was stopped. The drain holds the lock of the instructions until the store reaches the cache.
+func (mps *MultiprocessingSystem) DrainStoreBuffer(ID int, index int) (string, error) {
+	if ID < 0 || ID >= len(mps.ProcessingElements) {
+		return "Invalid PE number", nil
+	}
+	if mps.Stopped() {
+		return "", fmt.Errorf("the system was stopped")
+	}
+	if mps.Running() {
+		return "The system is running, pause it first", nil
+	}
+	if mps.Violation() != nil {
+		return "A coherence invariant failed, the system stopped", nil
+	}
+	exec := &mps.execution
+	exec.step.RLock()
+	pe := mps.ProcessingElements[ID]
+	if pe.Finished() || pe.Executing() {
+		exec.step.RUnlock()
+		return "PE is not available...", nil
+	}
+	if !pe.CanDrain(index) {
+		exec.step.RUnlock()
+		return fmt.Sprintf("The store %d can't leave the store buffer under %s", index, pe.Consistency), nil
+	}
+	position, instruction := drainDescription(pe, index)
+	forgetCompletion(pe)
+	select {
+	case pe.DrainControl <- index:
+	case <-mps.Terminate:
+		exec.step.RUnlock()
+		return "", fmt.Errorf("the system was stopped")
+	}
+	go mps.recordWhenCompleted(pe, index, position, instruction)
+	return "Sent 'drain' command to PE", nil
+}
 
-	return jsonString, nil
+// Function to execute one step of a Processing Element and wait until it finishes
+func (mps *MultiprocessingSystem) StepAndWait(ID int) error {
+	if mps.Running() {
+		return fmt.Errorf("the system is running, pause it first")
+	}
+	// The snapshots and the checkpoints wait until the step finishes
+	exec := &mps.execution
+	exec.step.RLock()
+	defer exec.step.RUnlock()
+	return mps.stepAndWait(ID)
 }
 
-// Function to apply a steping to an individual Processing Element
-func (mps *MultiprocessingSystem) SteppingProcessingElement(ID int) string {
-	if ID < -1 || ID > 2 {
-		return "Invalid PE number"
+// Function to execute one step of a Processing Element for the execution or for StepAndWait
+func (mps *MultiprocessingSystem) stepAndWait(ID int) error {
+	if ID < 0 || ID >= len(mps.ProcessingElements) {
+		return fmt.Errorf("invalid PE number %d", ID)
+	}
+	if err := mps.violationError(); err != nil {
+		return err
 	}
 	pe := mps.ProcessingElements[ID]
-	if !pe.IsDone && !pe.IsExecutingInstruction {
-		pe.Control <- true
-		return "Sent 'step' command to PE"
-	} else {
-		return "PE is not available..."
+	if pe.Finished() || pe.Executing() {
+		return fmt.Errorf("PE%d is not available", ID)
+	}
+	position, instruction := stepDescription(pe)
+	forgetCompletion(pe)
+	select {
+	case pe.Control <- true:
+	case <-mps.Terminate:
+		return fmt.Errorf("the system was stopped")
+	}
+	if err := mps.waitForCompletion(pe); err != nil {
+		return err
+	}
+	// The step that broke an invariant is also kept in the history
+	err := mps.waitForChecker()
+	mps.recordStep(ID, -1, position, instruction)
+	return err
+}
+
+// Function to drain one store of a Processing Element and wait until it reaches the cache
+func (mps *MultiprocessingSystem) DrainAndWait(ID int, index int) error {
+	if ID < 0 || ID >= len(mps.ProcessingElements) {
+		return fmt.Errorf("invalid PE number %d", ID)
+	}
+	if mps.Running() {
+		return fmt.Errorf("the system is running, pause it first")
 	}
+	if err := mps.violationError(); err != nil {
+		return err
+	}
+	exec := &mps.execution
+	exec.step.RLock()
+	defer exec.step.RUnlock()
+	pe := mps.ProcessingElements[ID]
+	if pe.Finished() || pe.Executing() {
+		return fmt.Errorf("PE%d is not available", ID)
+	}
+	if !pe.CanDrain(index) {
+		return fmt.Errorf("the store %d of PE%d can't leave the store buffer under %s", index, ID, pe.Consistency)
+	}
+	position, instruction := drainDescription(pe, index)
+	forgetCompletion(pe)
+	select {
+	case pe.DrainControl <- index:
+	case <-mps.Terminate:
+		return fmt.Errorf("the system was stopped")
+	}
+	if err := mps.waitForCompletion(pe); err != nil {
+		return err
+	}
+	err := mps.waitForChecker()
+	mps.recordStep(ID, index, position, instruction)
+	return err
 }
 
-// Function to apply a stepping to an individual Processing Element
-func (mps *MultiprocessingSystem) StartProcessingElements() {
-	allDone := false
+// Function to forget a completion signal left by an earlier step
+func forgetCompletion(pe *processingElement.ProcessingElement) {
+	select {
+	case <-pe.Completed:
+	default:
+	}
+}
 
-	go func() {
-		for !allDone {
-			select {
-			case <-mps.Terminate:
-				fmt.Println("Received termination signal. Gracefully terminating.")
-				return
-			default:
-				// Execute the code block
-				for _, pe := range mps.ProcessingElements {
-					if !pe.IsDone && !pe.IsExecutingInstruction {
-						pe.Control <- true
-					}
-				}
-				// Introduce a delay here to avoid tight loops
-				time.Sleep(time.Second * 2)
-
-				// Check the condition to exit the loop
-				allDone = true
-				for _, pe := range mps.ProcessingElements {
-					if !pe.IsDone {
-						allDone = false
-						break
-					}
-				}
+// Function to wait until a Processing Element finishes its current step
+func (mps *MultiprocessingSystem) waitForCompletion(pe *processingElement.ProcessingElement) error {
+	select {
+	case <-pe.Completed:
+		return nil
+	case <-mps.Terminate:
+		return fmt.Errorf("the system was stopped")
+	}
+}
+
+// Function to run every PE to completion with a seeded scheduler, the same seed gives the same interleaving.
+// Every step picks a PE that hasn't finished, or a store that can leave a store buffer under TSO and PSO.
+// It returns false when the PEs didn't finish after maxSteps steps (programs with loops).
+func (mps *MultiprocessingSystem) RunSeeded(seed int64, maxSteps int) (bool, error) {
+	generator := rand.New(rand.NewSource(seed))
+	for step := 0; step < maxSteps; step++ {
+		busy, err := mps.StepRandom(generator)
+		if err != nil || !busy {
+			return err == nil, err
+		}
+	}
+	return mps.AreWeFinished(), nil
+}
+
+// Function to execute one action picked by a scheduler: the next step of a PE or the drain of a store.
+// It returns false when every PE already finished.
+func (mps *MultiprocessingSystem) StepRandom(generator *rand.Rand) (bool, error) {
+	// An action executes the next step of a PE, or drains a specific store when index isn't -1
+	type action struct {
+		pe    int
+		index int
+	}
+	actions := []action{}
+	for id, pe := range mps.ProcessingElements {
+		if pe.Finished() {
+			continue
+		}
+		actions = append(actions, action{pe: id, index: -1})
+		for index := range pe.StoreBuffer {
+			if pe.CanDrain(index) {
+				actions = append(actions, action{pe: id, index: index})
 			}
 		}
-	}()
+	}
+
+	// Every PE finished
+	if len(actions) == 0 {
+		return false, nil
+	}
+
+	chosen := actions[generator.Intn(len(actions))]
+	if chosen.index == -1 {
+		return true, mps.StepAndWait(chosen.pe)
+	}
+	return true, mps.DrainAndWait(chosen.pe, chosen.index)
+}
+
+// Function to get the value of an address as the processors see it, a valid cached copy is the newest one
+func (mps *MultiprocessingSystem) CoherentValue(address int) int {
+	value := int(mps.MainMemory.Data[address])
+	for _, cc := range mps.CacheControllers {
+		switch cc.GetAddressStatus(address) {
+		case "M", "O":
+			return cc.GetDataFromCache(address)
+		case "E", "S":
+			value = cc.GetDataFromCache(address)
+		}
+	}
+	return value
 }
 
 // Function to obtain the results after the execution of the Multiprocessing System
 func (mps *MultiprocessingSystem) AboutResults() (string, error) {
+	// Marshal the results into a JSON string
+	jsonData, err := json.MarshalIndent(mps.Results(), "", "    ")
+	if err != nil {
+		return "", err
+	}
+	// Convert the byte slice to a string
+	jsonString := string(jsonData)
+	return jsonString, nil
+}
+
+// Function to collect the metrics of the Cache Controllers and the Interconnect
+func (mps *MultiprocessingSystem) Results() utils.MultiprocessingSystemResults {
 	// Create an empty TransactionObjectList
 	transactions := utils.TransactionObjectList{}
 	// Get the values from the transactions queue of the Interconnect
@@ -399,49 +766,72 @@ func (mps *MultiprocessingSystem) AboutResults() (string, error) {
 		// Append the transaction object to the list
 		transactions = append(transactions, transactionObj)
 	}
-	// Sum the Cache Misses and Cache Hits for the 3 Cache Controllers
+	// Sum the Cache Misses and Cache Hits for all the Cache Controllers
 	CacheMisses := 0
 	CacheHits := 0
 	totalMemoryAccesses := 0
+	SCSuccesses := 0
+	SCFailures := 0
+	// Classified misses of every cache, and of every address in all of them
+	misses := utils.MissCounts{}
+	cacheMisses := []utils.CacheMisses{}
+	addressMisses := map[int]utils.MissCounts{}
 	for _, cc := range mps.CacheControllers {
-		CacheMisses += cc.CacheMisses
-		CacheHits += cc.CacheHits
+		// The counters are read together, a CC may still be serving an access
+		counters := cc.Counters()
+		CacheMisses += counters.Misses
+		CacheHits += counters.Hits
+		SCSuccesses += counters.SCSuccesses
+		SCFailures += counters.SCFailures
+		addresses, counts := cc.Misses.Counts()
+		cacheMisses = append(cacheMisses, utils.CacheMisses{Cache: cc.ID, Hits: counters.Hits, Misses: counters.Misses, Addresses: addresses, MissCounts: counts})
+		misses = CacheController.AddMissCounts(misses, counts)
+		for _, address := range addresses {
+			addressMisses[address.Address] = CacheController.AddMissCounts(addressMisses[address.Address], address.MissCounts)
+		}
+	}
+	addresses := []utils.AddressMisses{}
+	for address := 0; address < MemorySize; address++ {
+		if counts, ok := addressMisses[address]; ok {
+			addresses = append(addresses, utils.AddressMisses{Address: address, MissCounts: counts})
+		}
 	}
 	totalMemoryAccesses = CacheHits + CacheMisses
-	// Calculate the Miss Rate and Hit Rate
-	MissRate := float64(CacheMisses) / float64(totalMemoryAccesses) * 100
-	HitRate := float64(CacheHits) / float64(totalMemoryAccesses) * 100
-	// Create the JSON object
-	resultsJSON := utils.MultiprocessingSystemResults{
+	bus := mps.Interconnect.Counters()
+	// Calculate the Miss Rate and Hit Rate, a run without accesses has no rates
+	MissRate := 0.0
+	HitRate := 0.0
+	if totalMemoryAccesses > 0 {
+		MissRate = float64(CacheMisses) / float64(totalMemoryAccesses) * 100
+		HitRate = float64(CacheHits) / float64(totalMemoryAccesses) * 100
+	}
+	return utils.MultiprocessingSystemResults{
 		Transactions:          transactions,
-		PowerConsumption:      mps.Interconnect.PowerConsumption,
+		PowerConsumption:      bus.PowerConsumption,
 		CacheMisses:           CacheMisses,
 		CacheHits:             CacheHits,
 		MemoryAccesses:        totalMemoryAccesses,
 		MissRate:              MissRate,
 		HitRate:               HitRate,
-		ReadRequests:          mps.Interconnect.ReadRequests,
-		ReadExclusiveRequests: mps.Interconnect.ReadExclusiveRequests,
-		DataResponses:         mps.Interconnect.DataResponses,
-		Invalidates:           mps.Interconnect.Invalidates,
-		MemoryReads:           mps.Interconnect.MemoryReads,
-		MemoryWrites:          mps.Interconnect.MemoryWrites,
+		ReadRequests:          bus.ReadRequests,
+		ReadExclusiveRequests: bus.ReadExclusiveRequests,
+		DataResponses:         bus.DataResponses,
+		Invalidates:           bus.Invalidates,
+		MemoryReads:           bus.MemoryReads,
+		MemoryWrites:          bus.MemoryWrites,
+		SCSuccesses:           SCSuccesses,
+		SCFailures:            SCFailures,
+		Misses:                misses,
+		CacheMissCounts:       cacheMisses,
+		AddressMissCounts:     addresses,
 	}
-	// Marshal the PE struct into a JSON string
-	jsonData, err := json.MarshalIndent(resultsJSON, "", "    ")
-	if err != nil {
-		return "", err
-	}
-	// Convert the byte slice to a string
-	jsonString := string(jsonData)
-	return jsonString, nil
 }
 
 // Function to check if the Multiprocesing System has finished smoothly
 func (mps *MultiprocessingSystem) AreWeFinished() bool {
 	allDone := true
 	for _, pe := range mps.ProcessingElements {
-		if !pe.IsDone {
+		if !pe.Finished() {
 			allDone = false
 			break
 		}
@@ -451,21 +841,35 @@ func (mps *MultiprocessingSystem) AreWeFinished() bool {
 
 // Function to stop a new Multiprocessing System after initialized
 func (mps *MultiprocessingSystem) Stop() {
+	mps.stopOnce.Do(mps.stop)
+}
+
+// Function to know if the system was stopped, its components don't execute anything after it
+func (mps *MultiprocessingSystem) Stopped() bool {
+	select {
+	case <-mps.Terminate:
+		return true
+	default:
+		return false
+	}
+}
+
+func (mps *MultiprocessingSystem) stop() {
 	close(mps.Terminate)
 	mps.WG.Wait() // Wait for all goroutines to finish gracefully
 	// Close the log files for all PEs
 	for _, pe := range mps.ProcessingElements {
-		pe.Logger.Writer().(*os.File).Close()
+		closeLog(pe.Logger)
 	}
 	// Close the log files for all CCs
 	for _, cc := range mps.CacheControllers {
-		cc.Logger.Writer().(*os.File).Close()
+		closeLog(cc.Logger)
 	}
 	// Close the log file for the IC
-	mps.Interconnect.Logger.Writer().(*os.File).Close()
+	closeLog(mps.Interconnect.Logger)
 	// Close the log file for the MM
-	mps.MainMemory.Logger.Writer().(*os.File).Close()
-	for i := 0; i < 3; i++ {
+	closeLog(mps.MainMemory.Logger)
+	for i := range mps.ProcessingElements {
 		close(mps.RequestChannelsM1[i])
 		close(mps.ResponseChannelsM1[i])
 		close(mps.RequestChannelsM2[i])
@@ -475,7 +879,15 @@ func (mps *MultiprocessingSystem) Stop() {
 	}
 	close(mps.RequestChannelM3)
 	close(mps.ResponseChannelM3)
-	close(mps.Semaphore)
+	// The semaphore stays open, a Cache Controller may still be waiting for the bus when Quit arrives
+}
+
+// Function to write a log file to disk and close it
+func closeLog(logger *log.Logger) {
+	if file, ok := logger.Writer().(*os.File); ok {
+		file.Sync()
+		file.Close()
+	}
 }
 
 // Function to check if any file is empty
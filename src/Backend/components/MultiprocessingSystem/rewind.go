@@ -0,0 +1,159 @@
+package MultiprocessingSystem
+
+import (
+	"fmt"
+
+	"Backend/utils"
+)
+
+// Steps between two snapshots of the system kept by the history, a rewind replays fewer steps than this
+const RewindInterval = 32
+
+// Step of the history as a PE executed it, to replay it after a snapshot
+type rewindAction struct {
+	core  int
+	drain int // Store drained from the store buffer, -1 for the next step of the PE
+}
+
+// Whole system after a step, without its logs and with only the lengths of its trace, and the steps that followed it
+type rewindSnapshot struct {
+	step         int
+	checkpoint   *Checkpoint
+	operations   int // Memory operations recorded by the trace at the step
+	transactions int // Bus transactions recorded by the trace at the step
+	actions      []rewindAction
+}
+
+// Function to take a snapshot of the system after a step, while no PE is executing
+func (mps *MultiprocessingSystem) snapshot(step int) rewindSnapshot {
+	operations, transactions := mps.Recorder.Lengths()
+	return rewindSnapshot{step: step, checkpoint: mps.save(false), operations: operations, transactions: transactions}
+}
+
+// Function to keep the action of a step with the last snapshot, with the mutex of the history taken.
+// Every RewindInterval steps a new snapshot starts, unless another PE is still executing an instruction.
+func (mps *MultiprocessingSystem) keepStep(action rewindAction, step int) {
+	h := &mps.history
+	last := &h.snapshots[len(h.snapshots)-1]
+	last.actions = append(last.actions, action)
+	if step%RewindInterval != 0 {
+		return
+	}
+	for _, pe := range mps.ProcessingElements {
+		if pe.Executing() {
+			return
+		}
+	}
+	h.snapshots = append(h.snapshots, mps.snapshot(step))
+}
+
+// Function to forget the snapshots before the first state kept, except the last one the first state can be rebuilt from
+func (h *history) forgetSnapshots() {
+	for len(h.snapshots) > 1 && h.snapshots[1].step <= h.first.Step {
+		h.snapshots = h.snapshots[1:]
+	}
+}
+
+// Function to let the history know that a component was changed by hand between two steps,
+// the rewinds to the current step and after it start from the changed system
+func (mps *MultiprocessingSystem) Changed() {
+	h := &mps.history
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	snapshot := mps.snapshot(h.current.Step)
+	if last := &h.snapshots[len(h.snapshots)-1]; last.step == snapshot.step {
+		*last = snapshot
+		return
+	}
+	h.snapshots = append(h.snapshots, snapshot)
+}
+
+// Function to take the system back to the state it had after an earlier step of its history. The system is restored
+// in a new one from the last snapshot before the step, and the steps after the snapshot are replayed in their order.
+// The new system keeps the history, the trace and the breakpoints up to the step, its logs also keep the steps undone.
+// The system given is stopped, so both don't write the same logs, unless the step isn't in the history, it's running
+// or its snapshot can't be restored.
+func (mps *MultiprocessingSystem) Rewind(step int) (*MultiprocessingSystem, error) {
+	if mps.Running() {
+		return nil, fmt.Errorf("the system is running, pause it first")
+	}
+	for _, pe := range mps.ProcessingElements {
+		if pe.Executing() {
+			return nil, fmt.Errorf("PE%d is executing an instruction, wait until it finishes", pe.ID)
+		}
+	}
+
+	h := &mps.history
+	h.mutex.Lock()
+	if step < h.first.Step || step > h.current.Step {
+		h.mutex.Unlock()
+		return nil, fmt.Errorf("the history goes from step %d to step %d", h.first.Step, h.current.Step)
+	}
+	first := copyHistoryState(h.first)
+	steps := append([]utils.HistoryStep{}, h.steps[:step-h.first.Step]...)
+	snapshots := []rewindSnapshot{}
+	for _, snapshot := range h.snapshots {
+		if snapshot.step > step {
+			break
+		}
+		// The steps are shared with the history of the system given, they are copied before new ones are added
+		snapshot.actions = append([]rewindAction{}, snapshot.actions...)
+		snapshots = append(snapshots, snapshot)
+	}
+	h.mutex.Unlock()
+	last := &snapshots[len(snapshots)-1]
+	last.actions = last.actions[:step-last.step]
+
+	// The snapshot is completed with the logs, the trace and the breakpoints of the system given
+	checkpoint := *last.checkpoint
+	checkpoint.ProcessingElements = append([]ProcessingElementCheckpoint{}, checkpoint.ProcessingElements...)
+	for id, pe := range mps.ProcessingElements {
+		checkpoint.ProcessingElements[id].Log = readLog(pe.Logger)
+	}
+	checkpoint.CacheControllers = append([]CacheControllerCheckpoint{}, checkpoint.CacheControllers...)
+	for id, cc := range mps.CacheControllers {
+		checkpoint.CacheControllers[id].Log = readLog(cc.Logger)
+	}
+	checkpoint.Interconnect.Log = readLog(mps.Interconnect.Logger)
+	checkpoint.MainMemory.Log = readLog(mps.MainMemory.Logger)
+	operations, transactions := mps.Recorder.Snapshot()
+	checkpoint.Trace = utils.RecorderState{
+		MemoryOperations: operations[:last.operations],
+		BusTransactions:  transactions[:last.transactions],
+		Gaps:             last.checkpoint.Trace.Gaps,
+	}
+	exec := &mps.execution
+	exec.mutex.Lock()
+	checkpoint.Execution = ExecutionCheckpoint{
+		Delay:          exec.delay,
+		Breakpoints:    append([]utils.Breakpoint{}, exec.breakpoints...),
+		LastBreakpoint: exec.lastBreakpoint,
+	}
+	exec.mutex.Unlock()
+
+	rewound, err := Replace(mps, checkpoint.RestoreConfig())
+	if err != nil {
+		return nil, err
+	}
+	for i, action := range last.actions {
+		if action.drain == -1 {
+			err = rewound.stepAndWait(action.core)
+		} else {
+			err = rewound.DrainAndWait(action.core, action.drain)
+		}
+		// The step that broke an invariant was the last one of the original execution too
+		if err != nil && rewound.Violation() == nil {
+			rewound.Stop()
+			return nil, fmt.Errorf("the step %d can't be replayed: %v", last.step+i+1, err)
+		}
+	}
+
+	// The history goes on from the step instead of from the snapshot
+	current := rewound.historyState()
+	current.Step = step
+	rh := &rewound.history
+	rh.mutex.Lock()
+	rh.first, rh.current, rh.steps, rh.snapshots = first, current, steps, snapshots
+	rh.mutex.Unlock()
+	return rewound, nil
+}
@@ -3,13 +3,13 @@ package processingElement
 import (
 	"strconv"
     "sync"
-    "bufio"
     "os"
     "strings"
     "log"
     "encoding/json"
     "fmt"
 
+    "Backend/components/Assembler"
     "Backend/utils"
 )
 
@@ -26,8 +26,21 @@ type ProcessingElement struct {
     IsDone bool                                             // Flag to know when a PE hasn't finished executing instructions
     IsExecutingInstruction bool                             // Flag to know when a PE is currently executing an instruction
     Quit chan struct{}                                      // A signal to terminate the goroutine
-    Status string                                           // Status for every momment of the execution
+    status string                                           // Status for every momment of the execution
+    statusMutex sync.Mutex                                  // Protects the status, the register, the flags, the instructions and the store buffer, the system reads them while the PE executes
     Filename string                                         // The name of the text file where the instructions are
+    Program []string                                        // Every instruction of the program, in order
+    PC int                                                  // Position of the next instruction in the program
+    Consistency string                                      // Memory consistency model: SC, TSO or PSO
+    StoreBuffer []utils.StoreBufferEntry                    // Stores waiting to reach the Cache Controller (TSO and PSO)
+    StoreBufferSize int                                     // Maximum number of stores in the store buffer
+    DrainControl chan int                                   // Channel for external control of the store buffer
+    Completed chan struct{}                                 // Signal sent every time the PE finishes a step
+    Loads []int                                             // Values obtained by every READ and LL, in program order
+    Recorder *utils.TraceRecorder                           // Keeps the memory operations as a trace, nil disables it
+    recordedOperations int                                  // Number of memory operations recorded by the PE
+    Events *utils.EventStream                               // Receives the instruction events, nil disables them
+    OnDone func()                                           // Called once when the PE finishes, nil disables it
 }
 
 // New creates a new ProcessingElement instance with the required information to operate.
@@ -45,6 +58,57 @@ func New(
         return nil, err
     }
 
+    pe, err := NewWithProgram(id, RequestChannelCC, ResponseChannelCC, instructions.Items, logfilepath, quit)
+    if err != nil {
+        return nil, err
+    }
+    pe.Filename = filename
+    return pe, nil
+}
+
+// NewWithProgram creates a new ProcessingElement instance that runs a program already in memory.
+func NewWithProgram(
+        id int,
+        RequestChannelCC chan utils.RequestProcessingElement,
+        ResponseChannelCC chan utils.ResponseProcessingElement,
+        program []string,
+        logfilepath string,
+        quit chan struct{}) (*ProcessingElement, error) {
+
+    // Assemble the program, an invalid line rejects the whole program
+    assembled, err := assembler.Assemble("P" + strconv.Itoa(id), strings.Join(program, "\n"))
+    if err != nil {
+        return nil, err
+    }
+    return newProcessingElement(id, RequestChannelCC, ResponseChannelCC, assembled, logfilepath, quit), nil
+}
+
+// NewWithTrace creates a new ProcessingElement instance that replays the accesses of a memory trace.
+// The operations are "READ address", "WRITE address", "WRITE address value" and "IDLE".
+func NewWithTrace(
+        id int,
+        RequestChannelCC chan utils.RequestProcessingElement,
+        ResponseChannelCC chan utils.ResponseProcessingElement,
+        operations []string,
+        logfilepath string,
+        quit chan struct{}) (*ProcessingElement, error) {
+
+    pe := newProcessingElement(id, RequestChannelCC, ResponseChannelCC, operations, logfilepath, quit)
+    pe.Filename = "trace"
+    return pe, nil
+}
+
+// Function to create a Processing Element with the instructions already checked
+func newProcessingElement(
+        id int,
+        RequestChannelCC chan utils.RequestProcessingElement,
+        ResponseChannelCC chan utils.ResponseProcessingElement,
+        program []string,
+        logfilepath string,
+        quit chan struct{}) *ProcessingElement {
+
+    instructions := utils.QueueS{Items: program}
+
     // Create the log file for this object
     logFile, err := os.Create(logfilepath + strconv.Itoa(id) + ".log")
     if err != nil {
@@ -66,9 +130,17 @@ func New(
         IsDone : false,
         IsExecutingInstruction: false,
         Quit: quit,
-        Status: "Active",
-        Filename: filename,
-    }, nil
+        status: "Active",
+        Filename: "",
+        Program: append([]string{}, instructions.Items...),
+        PC: 0,
+        Consistency: "SC",
+        StoreBuffer: []utils.StoreBufferEntry{},
+        StoreBufferSize: 4,
+        DrainControl: make(chan int),
+        Completed: make(chan struct{}, 1),
+        Loads: []int{},
+    }
 }
 
 
@@ -78,7 +150,8 @@ func (pe *ProcessingElement) About()(string, error){
     instructions := utils.InstructionObjectList{}
 
     // Get the values from the instructions queue of the Processing Element
-    for i, item := range pe.Instructions.Items{
+    _, items := pe.GetInstructions()
+    for i, item := range items{
 		// Create an InstructionObject
         instructionObj := utils.InstructionObject{
             Position: i,
@@ -91,9 +164,11 @@ func (pe *ProcessingElement) About()(string, error){
     // Create a struct
     aboutPE := utils.AboutProcessingElement {
         ID: pe.ID,
-        Register: pe.Register,
-        Status: pe.Status,
+        Register: pe.GetRegister(),
+        Status: pe.GetStatus(),
         Instructions: instructions,
+        Consistency: pe.Consistency,
+        StoreBuffer: pe.AboutStoreBuffer(),
     }
 
 	// Marshal the PE struct into a JSON string
@@ -108,6 +183,78 @@ func (pe *ProcessingElement) About()(string, error){
 	return jsonString, nil
 }
 
+// Function to get the status of the PE, it can be read while the PE executes an instruction
+func (pe *ProcessingElement) GetStatus() string {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    return pe.status
+}
+
+// Function to change the status of the PE
+func (pe *ProcessingElement) SetStatus(status string) {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    pe.status = status
+}
+
+// Function to get the register of the PE, it can be read while the PE executes an instruction
+func (pe *ProcessingElement) GetRegister() int {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    return pe.Register
+}
+
+// Function to change the register of the PE
+func (pe *ProcessingElement) setRegister(value int) {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    pe.Register = value
+}
+
+// Function to know if the PE finished its program, it can be read while the PE executes an instruction
+func (pe *ProcessingElement) Finished() bool {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    return pe.IsDone
+}
+
+// Function to know if the PE is executing an instruction or draining a store
+func (pe *ProcessingElement) Executing() bool {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    return pe.IsExecutingInstruction
+}
+
+// Function to change the flag of the instruction in progress
+func (pe *ProcessingElement) setExecuting(executing bool) {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    pe.IsExecutingInstruction = executing
+}
+
+// Function to get the program counter and a copy of the instructions still pending
+func (pe *ProcessingElement) GetInstructions() (int, []string) {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    return pe.PC, append([]string{}, pe.Instructions.Items...)
+}
+
+// Function to get a copy of the stores waiting in the store buffer
+func (pe *ProcessingElement) GetStoreBuffer() []utils.StoreBufferEntry {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    return append([]utils.StoreBufferEntry{}, pe.StoreBuffer...)
+}
+
+// Function to take the next instruction of the queue and move the program counter past it
+func (pe *ProcessingElement) nextInstruction() (int, string) {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    position := pe.PC
+    pe.PC++
+    return position, pe.Instructions.Dequeue()
+}
+
 // Function to send a request to the Cache Controller
 func (pe *ProcessingElement) RequestCacheController(Type string, Address int, Data int) (int, bool) {
     // Prepare the request message for the Cache Controller
@@ -117,7 +264,7 @@ func (pe *ProcessingElement) RequestCacheController(Type string, Address int, Da
         Data: Data,                      
     }
     // Send the request to the CacheController
-    pe.Status = fmt.Sprintf("Executing %s %d", Type, Address)
+    pe.SetStatus(fmt.Sprintf("Executing %s %d", Type, Address))
     pe.RequestChannel <- request
     pe.Logger.Printf(" - PE%d sent (Type: %s, Address: %d) to the Cache Controller.\n", pe.ID, Type, Address)
 
@@ -134,88 +281,229 @@ func (pe *ProcessingElement) RequestCacheController(Type string, Address int, Da
     return DataResponse, StatusResponse
 }
 
+// Function to get the list of stores waiting in the store buffer
+func (pe *ProcessingElement) AboutStoreBuffer() utils.StoreBufferObjectList {
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    storeBuffer := utils.StoreBufferObjectList{}
+    for i, entry := range pe.StoreBuffer {
+        storeBuffer = append(storeBuffer, utils.StoreBufferObject{
+            Position: i,
+            Address: entry.Address,
+            Data: entry.Data,
+        })
+    }
+    return storeBuffer
+}
+
+// Function to know if the consistency model lets the stores wait in the store buffer
+func (pe *ProcessingElement) buffersStores() bool {
+    return pe.Consistency == "TSO" || pe.Consistency == "PSO"
+}
+
+// Function to get the newest buffered value of an address (store-to-load forwarding)
+func (pe *ProcessingElement) forwardFromStoreBuffer(address int) (int, bool) {
+    for i := len(pe.StoreBuffer) - 1; i >= 0; i-- {
+        if pe.StoreBuffer[i].Address == address {
+            return pe.StoreBuffer[i].Data, true
+        }
+    }
+    return 0, false
+}
+
+// Function to know if a store can leave the store buffer under the consistency model
+func (pe *ProcessingElement) CanDrain(index int) bool {
+    if index < 0 || index >= len(pe.StoreBuffer) {
+        return false
+    }
+    // TSO keeps every store in program order
+    if pe.Consistency != "PSO" {
+        return index == 0
+    }
+    // PSO only keeps the order between stores to the same address
+    for _, entry := range pe.StoreBuffer[:index] {
+        if entry.Address == pe.StoreBuffer[index].Address {
+            return false
+        }
+    }
+    return true
+}
+
+// Function to send one buffered store to the Cache Controller
+func (pe *ProcessingElement) drainStore(index int) {
+    entry := pe.StoreBuffer[index]
+    pe.Logger.Printf(" - PE%d is draining the store (Address: %d, Data: %d) from its store buffer.\n", pe.ID, entry.Address, entry.Data)
+    _, Status := pe.RequestCacheController("WRITE", entry.Address, entry.Data)
+    pe.Logger.Printf(" - PE%d received --> Status: %v.\n", pe.ID, Status)
+    pe.record("WRITE", true, entry.Address, entry.Data, false)
+
+    // The store leaves the buffer once the cache has it
+    pe.statusMutex.Lock()
+    pe.StoreBuffer = append(pe.StoreBuffer[:index:index], pe.StoreBuffer[index+1:]...)
+    pe.statusMutex.Unlock()
+}
+
+// Function to add a memory operation to the trace of the execution
+func (pe *ProcessingElement) record(kind string, write bool, address int, value int, forwarded bool) {
+    pe.recordedOperations++
+    pe.Recorder.RecordMemoryOperation(utils.MemoryOperation{
+        Core: pe.ID,
+        Kind: kind,
+        Write: write,
+        Address: address,
+        Value: value,
+        Forwarded: forwarded,
+    })
+}
+
+// Function to publish an instruction event with the position of the instruction in the program
+func (pe *ProcessingElement) publishInstruction(kind string, position int, instruction string) {
+    pe.Events.Publish(kind, utils.InstructionEvent{
+        Core: pe.ID,
+        Position: position,
+        Instruction: instruction,
+        Register: pe.GetRegister(),
+        Status: pe.GetStatus(),
+    })
+}
+
+// Function to send every buffered store to the Cache Controller in program order
+func (pe *ProcessingElement) drainStoreBuffer() {
+    for len(pe.StoreBuffer) > 0 {
+        pe.drainStore(0)
+    }
+}
+
+// Function to let a waiting scheduler know that the PE finished a step
+func (pe *ProcessingElement) notifyCompleted() {
+    select {
+    case pe.Completed <- struct{}{}:
+    default:
+    }
+}
+
+// Function to mark the PE as done and let the system know
+func (pe *ProcessingElement) done(status string) {
+    pe.statusMutex.Lock()
+    pe.IsDone = true
+    pe.IsExecutingInstruction = false
+    pe.status = status
+    pe.statusMutex.Unlock()
+    if pe.OnDone != nil {
+        pe.OnDone()
+    }
+}
+
+// Function to release the PE after an instruction and check if the program has finished
+func (pe *ProcessingElement) finishInstruction() bool {
+    pe.Logger.Printf(" - PE%d has finished with the instruction.\n", pe.ID)
+    defer pe.notifyCompleted()
+
+    // Check if there are still instructions to execute or stores to drain
+    if pe.Instructions.IsEmpty() && len(pe.StoreBuffer) == 0 {
+        pe.Logger.Printf(" - PE%d has executed all instructions.\n", pe.ID)
+        // Notify the main that this PE has executed all instructions
+        pe.done("Done")
+        return true
+    }
+
+    // Let others know the PE is now available
+    pe.setExecuting(false)
+    pe.SetStatus("Free")
+    return false
+}
+
+// Function to move the program counter to an absolute position of the program
+func (pe *ProcessingElement) jumpTo(position int) {
+    if position > len(pe.Program) {
+        position = len(pe.Program)
+    }
+    pe.statusMutex.Lock()
+    defer pe.statusMutex.Unlock()
+    pe.PC = position
+    // The queue always holds the instructions that are still pending from the program counter
+    pe.Instructions = utils.QueueS{Items: append([]string{}, pe.Program[position:]...)}
+}
+
 // Run simulates the execution of instructions for a ProcessingElement.
 func (pe *ProcessingElement) Run(wg *sync.WaitGroup) {
     pe.Logger.Printf(" - PE%d is ready to execute instructions.\n", pe.ID)
-    pe.Status = "Ready"
+    pe.SetStatus("Ready")
     for {
         select {
             // The PE receives a signal to execute an instruction
             case <- pe.Control:
                 // Let others know the PE is currently busy executing an instruction
-                pe.IsExecutingInstruction = true
-                pe.Status = "Signal Received"
+                pe.setExecuting(true)
+                pe.SetStatus("Signal Received")
 
                 // Check if there are still instructions to execute
                 if pe.Instructions.IsEmpty() {
+                    // The program has finished, but the buffered stores still have to reach the cache
+                    if len(pe.StoreBuffer) > 0 {
+                        pe.SetStatus("Draining Store Buffer")
+                        pe.drainStore(0)
+                        if pe.finishInstruction() {
+                            return
+                        }
+                        continue
+                    }
                     pe.Logger.Printf(" - PE%d has executed all instructions.\n", pe.ID)
                     // Notify the main that this PE has executed all instructions
-                    pe.IsDone = true
-                    pe.Status = "Done"
+                    pe.done("Done")
+                    pe.notifyCompleted()
                     return
                 }
                 
                 // Get the next instruction
-                instruction := pe.Instructions.Dequeue()
+                position, instruction := pe.nextInstruction()
 
                 pe.Logger.Printf(" - PE%d received external signal to execute instruction: %s.\n", pe.ID, instruction)
+                pe.publishInstruction(utils.EventInstructionStart, position, instruction)
                 words := strings.Fields(instruction)
                 operation := words[0]
+                recorded := pe.recordedOperations
 
                 switch operation {
                 // Increment
                 case "INC":
-                    pe.Status = "Executing INC"
+                    pe.SetStatus("Executing INC")
                     pe.Logger.Printf(" - PE%d is executing a %s operation.\n", pe.ID, operation)
-                    pe.Register++
+                    pe.setRegister(pe.Register + 1)
                     pe.Logger.Printf(" - Now the value of the register is %d.\n", pe.Register)
-                    pe.Logger.Printf(" - PE%d has finished with the instruction.\n", pe.ID)
-                    // Let others know the PE is now available
-                    pe.IsExecutingInstruction = false
-                    pe.Status = "Free"
-
-
-                    // Check if there are still instructions to execute
-                    if pe.Instructions.IsEmpty() {
-                        pe.Logger.Printf(" - PE%d has executed all instructions.\n", pe.ID)
-                        // Notify the main that this PE has executed all instructions
-                        pe.IsDone = true
-                        pe.Status = "Done"
-                        return
-                    }
 
-                // Read a data from an specific memory address
-                case "READ":
+                // Read a data from an specific memory address, LL also places a reservation on it
+                case "READ", "LL":
                     pe.Logger.Printf(" - PE%d is executing a %s operation.\n", pe.ID, operation)
                     // Create a request structure
                     address, err := strconv.Atoi(words[1])
                     if err != nil {
-                        // Error parsing the integer
+                        // Stop with an error instead of leaving the PE waiting forever
+                        pe.fail(instruction, err)
                         return
                     }
-                    
-                    // Send a READ request to the Cache Controller
-                    Data, _ := pe.RequestCacheController(operation, address, 0)
+
+                    // A LL behaves as a fence
+                    if operation == "LL" {
+                        pe.drainStoreBuffer()
+                    }
+
+                    // Take the value from the store buffer when there is a pending store to the address
+                    Data, forwarded := pe.forwardFromStoreBuffer(address)
+                    if forwarded {
+                        pe.Logger.Printf(" - PE%d forwarded the value from its store buffer.\n", pe.ID)
+                    } else {
+                        // Send a READ or LL request to the Cache Controller
+                        Data, _ = pe.RequestCacheController(operation, address, 0)
+                    }
 
                     // Process the response values
                     pe.Logger.Printf(" - PE%d received Data: %d.\n", pe.ID, Data)
-                    pe.Status = "Updating Register"
-                    pe.Register = Data
+                    pe.SetStatus("Updating Register")
+                    pe.setRegister(Data)
+                    pe.Loads = append(pe.Loads, Data)
+                    pe.record(operation, false, address, Data, forwarded)
                     pe.Logger.Printf(" - Updated local register: Rs = %d.\n", pe.Register)
-                    pe.Logger.Printf(" - PE%d has finished with the instruction.\n", pe.ID)
-
-                    // Let others know the PE is now available
-                    pe.IsExecutingInstruction = false
-                    pe.Status = "Free"
-
-                    // Check if there are still instructions to execute
-                    if pe.Instructions.IsEmpty() {
-                        pe.Logger.Printf(" - PE%d has executed all instructions.\n", pe.ID)
-                        // Notify the main that this PE has executed all instructions
-                        pe.IsDone = true
-                        pe.Status = "Done"
-                        return
-                    }
 
                 // Write dato into an specific memory address
                 case "WRITE":
@@ -223,94 +511,144 @@ func (pe *ProcessingElement) Run(wg *sync.WaitGroup) {
                     // Create a request structure
                     address, err := strconv.Atoi(words[1])
                     if err != nil {
-                        // Error parsing the integer
+                        // Stop with an error instead of leaving the PE waiting forever
+                        pe.fail(instruction, err)
                         return
                     }
 
-                    // Send a READ request to the Cache Controller
+                    // The WRITEs of a trace give the value to store
+                    if len(words) == 3 {
+                        value, err := strconv.Atoi(words[2])
+                        if err != nil {
+                            pe.fail(instruction, err)
+                            return
+                        }
+                        pe.setRegister(value)
+                    }
+
+                    // Under TSO and PSO the store waits in the store buffer
+                    if pe.buffersStores() {
+                        // Make room for the new store
+                        if len(pe.StoreBuffer) >= pe.StoreBufferSize {
+                            pe.drainStore(0)
+                        }
+                        pe.statusMutex.Lock()
+                        pe.StoreBuffer = append(pe.StoreBuffer, utils.StoreBufferEntry{Address: address, Data: pe.Register})
+                        pe.statusMutex.Unlock()
+                        pe.Logger.Printf(" - PE%d placed the store (Address: %d, Data: %d) in its store buffer.\n", pe.ID, address, pe.Register)
+                        break
+                    }
+
+                    // Send a WRITE request to the Cache Controller
                     _, Status := pe.RequestCacheController(operation, address, pe.Register)
 
                     // Process the response values
                     pe.Logger.Printf(" - PE%d received --> Status: %v.\n", pe.ID, Status)
-                    pe.Logger.Printf(" - PE%d has finished with the instruction.\n", pe.ID)
+                    pe.record(operation, true, address, pe.Register, false)
 
-                    // Let others know the PE is now available
-                    pe.IsExecutingInstruction = false
-                    pe.Status = "Free"
+                // Wait until every buffered store reaches the Cache Controller
+                case "FENCE":
+                    pe.SetStatus("Executing FENCE")
+                    pe.Logger.Printf(" - PE%d is executing a %s operation.\n", pe.ID, operation)
+                    pe.drainStoreBuffer()
 
+                // Store conditional, the register ends with 1 on success and 0 on failure
+                case "SC":
+                    pe.Logger.Printf(" - PE%d is executing a %s operation.\n", pe.ID, operation)
+                    // Create a request structure
+                    address, err := strconv.Atoi(words[1])
+                    if err != nil {
+                        // Stop with an error instead of leaving the PE waiting forever
+                        pe.fail(instruction, err)
+                        return
+                    }
 
-                    // Check if there are still instructions to execute
-                    if pe.Instructions.IsEmpty() {
-                        pe.Logger.Printf(" - PE%d has executed all instructions.\n", pe.ID)
-                        // Notify the main that this PE has executed all instructions
-                        pe.IsDone = true
-                        pe.Status = "Done"
+                    // A SC behaves as a fence
+                    pe.drainStoreBuffer()
+
+                    // Send a SC request to the Cache Controller
+                    _, Status := pe.RequestCacheController(operation, address, pe.Register)
+
+                    // Process the response values
+                    pe.Logger.Printf(" - PE%d received --> Status: %v.\n", pe.ID, Status)
+                    pe.SetStatus("Updating Register")
+                    if Status {
+                        pe.record(operation, true, address, pe.Register, false)
+                        pe.setRegister(1)
+                    } else {
+                        pe.setRegister(0)
+                    }
+                    pe.Logger.Printf(" - Updated local register: Rs = %d.\n", pe.Register)
+
+                // Idle cycle of a trace, the PE doesn't access the memory
+                case "IDLE":
+                    pe.SetStatus("Idle")
+                    pe.Logger.Printf(" - PE%d is idle for a cycle.\n", pe.ID)
+
+                // Jump to an absolute position of the program when the register is zero
+                case "BEQZ":
+                    pe.SetStatus("Executing BEQZ")
+                    pe.Logger.Printf(" - PE%d is executing a %s operation.\n", pe.ID, operation)
+                    position, err := strconv.Atoi(words[1])
+                    if err != nil {
+                        // Stop with an error instead of leaving the PE waiting forever
+                        pe.fail(instruction, err)
                         return
                     }
+                    if pe.Register == 0 {
+                        pe.jumpTo(position)
+                        pe.Logger.Printf(" - PE%d jumped to the instruction %d.\n", pe.ID, position)
+                    }
+                }
+
+                // Count the steps without memory operations as the gap before the next one
+                if pe.recordedOperations == recorded {
+                    pe.Recorder.RecordStep(pe.ID)
+                }
+                pe.publishInstruction(utils.EventInstructionFinish, position, instruction)
+
+                // Let others know if the PE is now available or done
+                if pe.finishInstruction() {
+                    return
+                }
 
+            // The PE receives a signal to drain a specific store from its store buffer
+            case index := <- pe.DrainControl:
+                pe.setExecuting(true)
+                pe.SetStatus("Draining Store Buffer")
+                if pe.CanDrain(index) {
+                    pe.drainStore(index)
+                } else {
+                    pe.Logger.Printf(" - PE%d can't drain the store %d under %s.\n", pe.ID, index, pe.Consistency)
+                }
+
+                // Let others know if the PE is now available or done
+                if pe.finishInstruction() {
+                    return
                 }
 
             // When the PE receives a signal terminate
             case <- pe.Quit:
                 pe.Logger.Printf(" - PE%d received termination signal and is exiting gracefully.\n", pe.ID)
-                pe.Status = "Forced to quit"
+                pe.SetStatus("Forced to quit")
                 return
         }
     }
 }
 
-// Reads lines from a text file and returns them as a slice of strings.
+// Reads a program from a text file and returns its assembled instructions.
 func readInstructionsFromFile(filename string) (utils.QueueS, error) {
-    // Create a new queue to store the instructions from the text file
-	Instructions := utils.QueueS{}
-
-    // Try opening the text file
-    file, err := os.Open(filename)
+    // The assembler reports every invalid line with its position
+    program, err := assembler.AssembleFile(filename)
     if err != nil {
-        return Instructions, err
-    }
-    // Close the file after reading all lines
-    defer file.Close()
-
-    scanner := bufio.NewScanner(file)
-
-    for scanner.Scan() {
-        line := strings.TrimSpace(scanner.Text())
-        // Check if the instruction is valid
-        if (isValidInstruction(line)){
-            Instructions.Enqueue(line)
-        }
-    }
-
-    // If there was an error, return an empty queue
-    if err := scanner.Err(); err != nil {
-        return Instructions, err
-    }
-
-    // If not, return the queue with the instructions
-    return Instructions, nil
-}
-
-// isValidInstruction checks if an instruction is valid.
-func isValidInstruction(instruction string) bool {
-    // Split the instruction into words
-    words := strings.Fields(instruction)
-
-    if len(words) == 1 {
-        // Single-word instructions (e.g., "INC")
-        return words[0] == "INC"
-    } else if len(words) == 2 {
-        // Two-word instructions (e.g., "READ 5" or "WRITE 10")
-        if words[0] == "READ" || words[0] == "WRITE" {
-            num, err := strconv.Atoi(words[1])
-            if err != nil {
-                // Error parsing the integer
-                return false
-            }
-            return num >= 0 && num <= 15
-        }
+        return utils.QueueS{}, err
     }
+    return utils.QueueS{Items: program}, nil
+}
 
-    // Invalid instruction format
-    return false
-}
\ No newline at end of file
+// Function to stop the PE when an instruction can't be executed
+func (pe *ProcessingElement) fail(instruction string, err error) {
+    pe.Logger.Printf(" - PE%d can't execute the instruction %q: %v.\n", pe.ID, instruction, err)
+    pe.done("Error: invalid instruction " + instruction)
+    pe.notifyCompleted()
+}
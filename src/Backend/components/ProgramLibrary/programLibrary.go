@@ -0,0 +1,171 @@
+package programLibrary
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	assembler "Backend/components/Assembler"
+)
+
+// Extension of the files where the programs are saved
+const extension = ".txt"
+
+// Maximum size of an uploaded program
+const MaxSourceSize = 64 * 1024
+
+// Folder with named programs, every program is a text file with its source
+type Library struct {
+	Directory string
+	mutex     sync.Mutex
+}
+
+// Summary of a saved program
+type ProgramInfo struct {
+	Name         string    `json:"Name"`
+	Instructions int       `json:"Instructions"`
+	Size         int       `json:"Size"`
+	Modified     time.Time `json:"Modified"`
+}
+
+// Saved program with its source and the instructions the PE executes
+type Program struct {
+	Name         string   `json:"Name"`
+	Source       string   `json:"Source"`
+	Instructions []string `json:"Instructions"`
+}
+
+// Error returned when a program doesn't exist
+type NotFoundError struct {
+	Name string
+}
+
+// Function to describe a missing program
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("the program %q doesn't exist", e.Name)
+}
+
+// New creates a library that keeps its programs in a folder, the folder is created when needed.
+func New(directory string) *Library {
+	return &Library{Directory: directory}
+}
+
+// Function to check that a name can be used as a file name
+func ValidateName(name string) error {
+	if name == "" || len(name) > 64 {
+		return fmt.Errorf("the program name must have between 1 and 64 characters")
+	}
+	for _, char := range name {
+		if !(char == '_' || char == '-' || (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9')) {
+			return fmt.Errorf("invalid program name %q, use letters, digits, '-' and '_'", name)
+		}
+	}
+	return nil
+}
+
+// Function to get the file of a program
+func (library *Library) path(name string) string {
+	return filepath.Join(library.Directory, name+extension)
+}
+
+// Save assembles a program and stores it under a name, an invalid program is rejected with
+// the assembler's diagnostics. It returns true when an existing program was replaced.
+func (library *Library) Save(name string, source string) (bool, error) {
+	if err := ValidateName(name); err != nil {
+		return false, err
+	}
+	if len(source) > MaxSourceSize {
+		return false, fmt.Errorf("the program has %d bytes, the limit is %d", len(source), MaxSourceSize)
+	}
+	if _, err := assembler.Assemble(name+extension, source); err != nil {
+		return false, err
+	}
+
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+
+	if err := os.MkdirAll(library.Directory, 0755); err != nil {
+		return false, err
+	}
+	_, err := os.Stat(library.path(name))
+	replaced := err == nil
+	return replaced, os.WriteFile(library.path(name), []byte(source), 0644)
+}
+
+// Get reads a program and assembles it.
+func (library *Library) Get(name string) (Program, error) {
+	if err := ValidateName(name); err != nil {
+		return Program{}, err
+	}
+
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+
+	source, err := os.ReadFile(library.path(name))
+	if os.IsNotExist(err) {
+		return Program{}, &NotFoundError{Name: name}
+	}
+	if err != nil {
+		return Program{}, err
+	}
+	instructions, err := assembler.Assemble(name+extension, string(source))
+	if err != nil {
+		return Program{}, err
+	}
+	return Program{Name: name, Source: string(source), Instructions: instructions}, nil
+}
+
+// List returns the saved programs sorted by name.
+func (library *Library) List() ([]ProgramInfo, error) {
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+
+	programs := []ProgramInfo{}
+	entries, err := os.ReadDir(library.Directory)
+	if os.IsNotExist(err) {
+		return programs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), extension)
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), extension) || ValidateName(name) != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		// A file edited by hand can be invalid, it is listed without instructions
+		count := 0
+		if source, err := os.ReadFile(library.path(name)); err == nil {
+			if instructions, err := assembler.Assemble(entry.Name(), string(source)); err == nil {
+				count = len(instructions)
+			}
+		}
+		programs = append(programs, ProgramInfo{Name: name, Instructions: count, Size: int(info.Size()), Modified: info.ModTime()})
+	}
+	sort.Slice(programs, func(i, j int) bool { return programs[i].Name < programs[j].Name })
+	return programs, nil
+}
+
+// Delete removes a saved program.
+func (library *Library) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+
+	err := os.Remove(library.path(name))
+	if os.IsNotExist(err) {
+		return &NotFoundError{Name: name}
+	}
+	return err
+}
@@ -2,37 +2,46 @@ package restfulapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 
+	"Backend/components/Assembler"
+	"Backend/components/Comparison"
+	"Backend/components/Litmus"
 	"Backend/components/MultiprocessingSystem"
+	"Backend/components/ProgramLibrary"
+	"Backend/components/Sweep"
+	"Backend/components/Trace"
+	"Backend/utils"
 )
 
 var (
-	mutex               sync.Mutex
-	BroadcastData       string
-	terminateRESTfulAPI chan struct{}
-	mps                 *MultiprocessingSystem.MultiprocessingSystem
+	mutex   sync.Mutex
+	mps     *MultiprocessingSystem.MultiprocessingSystem
+	library = programLibrary.New("programs")
 )
 
 func homeLink(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Welcome home!")
 }
 
-func Restfulapi() {
+// Función para crear el enrutador con todas las rutas y el middleware de CORS
+func Router() http.Handler {
 	// Configura el enrutador.
 	router := mux.NewRouter().StrictSlash(true)
 
 	// Enable CORS middleware
 	headersOk := handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization"})
-	originsOk := handlers.AllowedOrigins([]string{"*"})
-	methodsOk := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "OPTIONS"})
+	originsOk := handlers.AllowedOrigins(settings.AllowedOrigins)
+	methodsOk := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"})
 
 	// Ruta para obtener información sobre PE.
 	router.HandleFunc("/about", GetAbouts).Methods("GET")
@@ -43,30 +52,71 @@ func Restfulapi() {
 	router.HandleFunc("/setaction", SetAction).Methods("POST")
 	router.HandleFunc("/setlj", SetLj).Methods("POST")
 
-	// Servidor HTTP con CORS middleware.
-	handlerWithCORS := handlers.CORS(originsOk, headersOk, methodsOk)(router)
+	// Rutas para los breakpoints de la ejecución libre.
+	router.HandleFunc("/breakpoints", GetBreakpoints).Methods("GET")
+	router.HandleFunc("/breakpoints", AddBreakpoint).Methods("POST")
+	router.HandleFunc("/breakpoints/{breakpoint}", DeleteBreakpoint).Methods("DELETE")
 
-	terminateRESTfulAPI = make(chan struct{})
+	// Rutas para guardar y restaurar el estado completo del sistema.
+	router.HandleFunc("/checkpoint", GetCheckpoint).Methods("GET")
+	router.HandleFunc("/checkpoint", RestoreCheckpoint).Methods("POST")
 
-	// Inicia el servidor HTTP en una goroutine.
-	go func() {
-		err := http.ListenAndServe(":8080", handlerWithCORS)
-		log.Fatal(err)
-		if err != nil {
-			fmt.Println("Error al iniciar el servidor:", err)
-		}
-	}()
+	// Rutas para consultar los estados anteriores de la historia de pasos.
+	router.HandleFunc("/history", GetHistory).Methods("GET")
+	router.HandleFunc("/history/diff", GetHistoryDiff).Methods("GET")
+	router.HandleFunc("/history/{step}", GetHistoryState).Methods("GET")
+	router.HandleFunc("/history/{step}/rewind", RewindHistory).Methods("POST")
 
-	// Espera hasta que se reciba un mensaje en el canal de terminación.
-	<-terminateRESTfulAPI
-}
+	// Ruta para ensamblar un programa sin iniciar el sistema.
+	router.HandleFunc("/assemble", AssembleProgram).Methods("POST")
+
+	// Rutas para exportar la ejecución como trazas.
+	router.HandleFunc("/trace/memory", GetMemoryTrace).Methods("GET")
+	router.HandleFunc("/trace/bus", GetBusTrace).Methods("GET")
+
+	// Ruta para comparar protocolos con los mismos programas.
+	router.HandleFunc("/compare", RunComparison).Methods("POST")
+
+	// Ruta del stream de eventos de la simulación por WebSocket.
+	router.HandleFunc("/events", GetEvents).Methods("GET")
 
-// ... (rest of your code remains unchanged)
+	// Ruta para ejecutar todas las combinaciones de un barrido de parámetros.
+	router.HandleFunc("/sweep", RunSweep).Methods("POST")
 
+	// Ruta para listar los patrones de carga del generador.
+	router.HandleFunc("/workloads", GetWorkloads).Methods("GET")
 
-// ... (rest of your code remains unchanged)
+	// Rutas para los programas guardados.
+	router.HandleFunc("/programs", GetPrograms).Methods("GET")
+	router.HandleFunc("/programs", UploadProgram).Methods("POST")
+	router.HandleFunc("/programs/{name}", GetProgram).Methods("GET")
+	router.HandleFunc("/programs/{name}", DeleteProgram).Methods("DELETE")
 
+	// Rutas para los litmus tests.
+	router.HandleFunc("/litmus", GetLitmusTests).Methods("GET")
+	router.HandleFunc("/litmus", RunLitmusTest).Methods("POST")
 
+	// Rutas de la API v1, con varios sistemas aislados a la vez.
+	registerV1(router)
+
+	// Ruta para apagar el servidor.
+	router.HandleFunc("/close", CloseServer).Methods("POST")
+
+	// Ruta de las métricas para Prometheus.
+	router.HandleFunc("/metrics", GetPrometheusMetrics).Methods("GET")
+
+	// Ruta del documento OpenAPI, cada petición se valida con él antes de llegar a su handler.
+	router.HandleFunc("/openapi.json", GetOpenAPI).Methods("GET")
+	router.Use(observeRequests, validateRequests)
+	router.NotFoundHandler = http.HandlerFunc(notFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowed)
+	if err := document.check(router); err != nil {
+		panic(err)
+	}
+
+	// Servidor HTTP con CORS middleware.
+	return handlers.CORS(originsOk, headersOk, methodsOk)(router)
+}
 
 func Test() {
 	mutex.Lock()
@@ -80,29 +130,43 @@ func GetAbouts(w http.ResponseWriter, r *http.Request) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	aboutMps, _ := mps.GetState()
-	fmt.Println(aboutMps)
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+
+	aboutMps, err := mps.GetState()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, errorInternal, err.Error())
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, aboutMps)
+	io.WriteString(w, aboutMps)
 }
 
+// Handler para obtener las métricas, solo cuando todos los PEs terminaron
 func GetMetrics(w http.ResponseWriter, r *http.Request) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	if mps.AreWeFinished() {
-		fmt.Println("The Multiprocessing System has already finished.")
-		aboutMetrics, _ := mps.AboutResults()
-		fmt.Println(aboutMetrics)
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintf(w, aboutMetrics)
-	} else {
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+
+	if !mps.AreWeFinished() {
 		fmt.Println("The Multiprocessing System has not finished yet.")
-		aboutMetrics := "false"
-		fmt.Println(aboutMetrics)
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintf(w, aboutMetrics)
+		writeError(w, http.StatusConflict, errorRunNotFinished, "El sistema no ha terminado, las métricas están disponibles al final")
+		return
 	}
+	fmt.Println("The Multiprocessing System has already finished.")
+	aboutMetrics, err := mps.AboutResults()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, errorInternal, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, aboutMetrics)
 }
 
 // Handler para establecer datos.
@@ -112,31 +176,90 @@ func SetInitialize(w http.ResponseWriter, r *http.Request) {
 
 	var (
 		newData1 struct {
-			Type     string `json:"type"`
-			LastCode bool   `json:"lastCode"`
+			Type        string         `json:"type"`
+			LastCode    bool           `json:"lastCode"`
+			Consistency string         `json:"consistency"`
+			Cores       int            `json:"cores"`    // Número de PEs, 3 por defecto
+			CacheLines  int            `json:"cacheLines"` // Bloques de cada caché, 4 por defecto
+			CacheWays   int            `json:"cacheWays"`  // Asociatividad de las cachés, completamente asociativas por defecto
+			Programs    []string       `json:"programs"` // Programa guardado de cada PE, "" usa el programa generado
+			Traces      []trace.Source `json:"traces"`   // Trazas de memoria a reproducir en lugar de programas
+			Mapping     trace.Mapping  `json:"mapping"`  // Plegado de las direcciones de las trazas
+			Workload    *workloadRequest `json:"workload"` // Patrón de los programas generados, opcional
 		}
 	)
 
+	// Los campos del plegado que no se envían usan los valores por defecto
+	newData1.Mapping = trace.DefaultMapping()
+
 	// Intenta decodificar en newData1
 	if err := json.NewDecoder(r.Body).Decode(&newData1); err == nil {
-		// Verificar y manejar newData1
-		if newData1.Type == "MESI"  {
-			// Procesar solicitud MESI aquí
-			mps = MultiprocessingSystem.Start("MESI", newData1.LastCode, 4)
-			w.WriteHeader(http.StatusOK)
-			fmt.Fprintf(w, "Solicitud MESI procesada exitosamente")
+		// El modelo de consistencia es opcional, SC por defecto
+		cfg := MultiprocessingSystem.DefaultConfig()
+		cfg.Protocol = newData1.Type
+		cfg.CodeGenerator = newData1.LastCode
+		cfg.LogDirectory = settings.LogDirectory
+		cfg.ProgramDirectory = settings.GeneratedDirectory
+		if newData1.Consistency != "" {
+			cfg.Consistency = newData1.Consistency
+		}
+
+		// Los campos del patrón de carga que no se envían usan los valores del preset
+		if newData1.Workload != nil {
+			cfg.Workload = newData1.Workload.options()
+			cfg.CodeGenerator = true
+		}
+
+		if newData1.Cores != 0 {
+			cfg.Cores = newData1.Cores
+		}
+		cfg.CacheLines = newData1.CacheLines
+		cfg.CacheWays = newData1.CacheWays
+
+		// Reproducir las trazas en lugar de ejecutar programas
+		if newData1.Traces != nil {
+			if cfg.Cores < 1 || cfg.Cores > MultiprocessingSystem.MaxCores {
+				writeError(w, http.StatusBadRequest, errorInvalidRequest, fmt.Sprintf("El número de PEs debe estar entre 1 y %d", MultiprocessingSystem.MaxCores))
+				return
+			}
+			traces, err := trace.Load(newData1.Traces, newData1.Mapping, cfg.Cores)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+				return
+			}
+			cfg.Traces = traces
+		}
+
+		// Asignar los programas guardados a los PEs pedidos
+		if err := assignPrograms(&cfg, newData1.Programs); err != nil {
+			writeLibraryError(w, err)
 			return
 		}
-		if newData1.Type == "MOESI"{
-			// Procesar solicitud MOESI aquí
-			mps = MultiprocessingSystem.Start("MOESI", newData1.LastCode, 4)
-			w.WriteHeader(http.StatusOK)
-			fmt.Fprintf(w, "Solicitud MOESI procesada exitosamente")
+		if err := cfg.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
 			return
 		}
+
+		// Procesar solicitud MESI o MOESI aquí, un programa inválido se rechaza antes de iniciar el sistema
+		cfg.Events = events
+
+		// El sistema anterior se detiene antes de que el nuevo cree sus logs en la misma carpeta,
+		// solo cuando el nuevo ya no puede fallar, si falla el anterior sigue disponible
+		started, err := MultiprocessingSystem.Replace(mps, cfg)
+		if err != nil {
+			writeProgramError(w, err)
+			return
+		}
+		mps = started
+
+		// Los clientes del stream reciben el estado inicial del nuevo sistema
+		events.Publish(utils.EventSnapshot, mps.State())
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Solicitud %s (%s) procesada exitosamente", cfg.Protocol, cfg.Consistency)
+		return
 	}
 
-	http.Error(w, "JSON no válido", http.StatusBadRequest)
+	writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
 }
 
 // Handler para establecer datos.
@@ -144,12 +267,18 @@ func SetAction(w http.ResponseWriter, r *http.Request) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	//BroadcastUpdate()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
 
 	var (
 		newData3 struct {
-			Action string `json:"action"`
-			Number string `json:"number"`
+			Action       string `json:"action"`
+			Number       string `json:"number"`
+			Entry        int    `json:"entry"`
+			Instructions int    `json:"instructions"` // Instrucciones de all antes de pausar, 0 sin límite
+			Cycles       int    `json:"cycles"`       // Ciclos de all antes de pausar, 0 sin límite
 		}
 	)
 
@@ -158,28 +287,58 @@ func SetAction(w http.ResponseWriter, r *http.Request) {
 		// Verificar y manejar newData3
 		peIndex, err := strconv.Atoi(newData3.Number)
 		if err != nil || peIndex < -1 || peIndex > len(mps.ProcessingElements)-1 {
-			http.Error(w, "Número de PE no válido", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errorValidation, "Número de PE no válido")
 			return
 		}
 
 		if newData3.Action == "step" {
 			// Procesar solicitud de paso aquí
-			mps.SteppingProcessingElement(peIndex)
+			if _, err := mps.SteppingProcessingElement(peIndex); err != nil {
+				writeError(w, http.StatusConflict, errorConflict, err.Error())
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "Solicitud Step %s procesada exitosamente", newData3.Number)
+			return
+		}
+		if newData3.Action == "drain" {
+			// Procesar solicitud de vaciado del store buffer aquí
+			result, err := mps.DrainStoreBuffer(peIndex, newData3.Entry)
+			if err != nil {
+				writeError(w, http.StatusConflict, errorConflict, err.Error())
+				return
+			}
 			w.WriteHeader(http.StatusOK)
-			fmt.Fprintf(w, "Solicitud Step"+newData3.Number+"procesada exitosamente")
+			fmt.Fprintf(w, "%s", result)
 			return
 		}
 		if newData3.Action == "all" {
-			// Procesar solicitud de inicio aquí
-			mps.StartProcessingElements()
+			// Procesar solicitud de inicio aquí, con pausas para que el frontend siga la ejecución
+			limit := MultiprocessingSystem.RunLimit{Instructions: newData3.Instructions, Cycles: newData3.Cycles, Delay: allDelay}
+			if err := mps.Run(limit); err != nil {
+				writeError(w, http.StatusConflict, errorConflict, err.Error())
+				return
+			}
 			fmt.Println("Execute all done.")
 			w.WriteHeader(http.StatusOK)
 			fmt.Fprintf(w, "Solicitud ALL procesada exitosamente")
 			return
 		}
+		if control, found := executionActions[newData3.Action]; found {
+			// Procesar solicitud de pausa, reanudación o cancelación aquí
+			if err := control(mps); err != nil {
+				writeError(w, http.StatusConflict, errorConflict, err.Error())
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "Solicitud %s procesada exitosamente", strings.ToUpper(newData3.Action))
+			return
+		}
+		writeError(w, http.StatusBadRequest, errorValidation, fmt.Sprintf("Acción %q no válida, use step, drain, all, pause, resume o cancel", newData3.Action))
+		return
 	}
 
-	http.Error(w, "JSON no válido", http.StatusBadRequest)
+	writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
 }
 
 // Handler para establecer datos.
@@ -187,7 +346,10 @@ func SetLj(w http.ResponseWriter, r *http.Request) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	//BroadcastUpdate()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
 
 	var (
 		newData2 struct {
@@ -208,27 +370,493 @@ func SetLj(w http.ResponseWriter, r *http.Request) {
 			fmt.Fprintf(w, "Solicitud cerrar Multiprocessing System procesada exitosamente")
 			return
 		}
+		writeError(w, http.StatusBadRequest, errorValidation, fmt.Sprintf("Acción %q no válida, use close", newData2.Action))
+		return
+	}
+
+	writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+}
+
+// Handler para listar los litmus tests incluidos
+func GetLitmusTests(w http.ResponseWriter, r *http.Request) {
+	type litmusTest struct {
+		Name    string     `json:"Name"`
+		Doc     string     `json:"Doc"`
+		Threads [][]string `json:"Threads"`
+	}
+	tests := []litmusTest{}
+	for _, test := range litmus.Catalog() {
+		tests = append(tests, litmusTest{Name: test.Name, Doc: test.Doc, Threads: test.Threads})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tests)
+}
+
+// Handler para ejecutar un litmus test, incluido o enviado como texto
+func RunLitmusTest(w http.ResponseWriter, r *http.Request) {
+	var (
+		newData4 struct {
+			Test        string `json:"test"`
+			Source      string `json:"source"`
+			Protocol    string `json:"protocol"`
+			Consistency string `json:"consistency"`
+			Runs        int    `json:"runs"`
+			Seed        int64  `json:"seed"`
+		}
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&newData4); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+		return
+	}
+
+	// Buscar el test por nombre o leerlo del texto enviado
+	var test litmus.Test
+	if newData4.Source != "" {
+		parsed, err := litmus.Parse(newData4.Source)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+			return
+		}
+		test = parsed
+	} else {
+		found, ok := litmus.Find(newData4.Test)
+		if !ok {
+			writeError(w, http.StatusNotFound, errorNotFound, "Litmus test no encontrado")
+			return
+		}
+		test = found
+	}
+
+	// Los campos vacíos usan los valores por defecto
+	options := litmus.DefaultOptions()
+	if newData4.Protocol != "" {
+		options.Protocol = newData4.Protocol
+	}
+	if newData4.Consistency != "" {
+		options.Consistency = newData4.Consistency
+	}
+	if newData4.Runs != 0 {
+		options.Runs = newData4.Runs
+	}
+	if newData4.Seed != 0 {
+		options.Seed = newData4.Seed
+	}
+
+	report, err := litmus.Run(test, options)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Respuesta con el programa ensamblado o con los errores encontrados
+type assembleResponse struct {
+	Valid        bool                   `json:"Valid"`
+	Instructions []string               `json:"Instructions"`
+	Error        string                 `json:"Error,omitempty"`
+	Diagnostics  []assembler.Diagnostic `json:"Diagnostics"`
+}
+
+// Handler para ensamblar un programa y obtener sus errores con línea y columna
+func AssembleProgram(w http.ResponseWriter, r *http.Request) {
+	var (
+		newData5 struct {
+			Name   string `json:"name"`
+			Source string `json:"source"`
+		}
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&newData5); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+		return
+	}
+	if newData5.Name == "" {
+		newData5.Name = "program"
 	}
 
-	http.Error(w, "JSON no válido", http.StatusBadRequest)
+	response := assembleResponse{Valid: true, Instructions: []string{}, Diagnostics: []assembler.Diagnostic{}}
+	program, err := assembler.Assemble(newData5.Name, newData5.Source)
+	if err != nil {
+		response = programErrorResponse(err)
+	} else {
+		response.Instructions = program
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Function to build the response of a program that can't be assembled
+func programErrorResponse(err error) assembleResponse {
+	response := assembleResponse{Valid: false, Instructions: []string{}, Error: err.Error(), Diagnostics: []assembler.Diagnostic{}}
+	var assemblyError *assembler.Error
+	if errors.As(err, &assemblyError) {
+		response.Diagnostics = assemblyError.Diagnostics
+	}
+	return response
 }
 
-// Handler para cerrar el servidor por http
-func CloseServer(w http.ResponseWriter, r *http.Request) {
+// Handler para listar los programas guardados
+func GetPrograms(w http.ResponseWriter, r *http.Request) {
+	programs, err := library.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, errorInternal, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(programs)
+}
+
+// Handler para guardar un programa, un programa inválido se rechaza con los errores del ensamblador
+func UploadProgram(w http.ResponseWriter, r *http.Request) {
+	var (
+		newData6 struct {
+			Name   string `json:"name"`
+			Source string `json:"source"`
+		}
+	)
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*programLibrary.MaxSourceSize)
+	if err := json.NewDecoder(r.Body).Decode(&newData6); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+		return
+	}
+
+	replaced, err := library.Save(newData6.Name, newData6.Source)
+	if err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	program, err := library.Get(newData6.Name)
+	if err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !replaced {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(program)
+}
+
+// Handler para obtener un programa guardado con sus instrucciones ensambladas
+func GetProgram(w http.ResponseWriter, r *http.Request) {
+	program, err := library.Get(mux.Vars(r)["name"])
+	if err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(program)
+}
+
+// Handler para borrar un programa guardado
+func DeleteProgram(w http.ResponseWriter, r *http.Request) {
+	if err := library.Delete(mux.Vars(r)["name"]); err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Function to answer an error of the program library with the right status code
+func writeLibraryError(w http.ResponseWriter, err error) {
+	var notFound *programLibrary.NotFoundError
+	var assemblyError *assembler.Error
+	switch {
+	case errors.As(err, &notFound):
+		writeError(w, http.StatusNotFound, errorNotFound, err.Error())
+	case errors.As(err, &assemblyError):
+		writeProgramError(w, err)
+	default:
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+	}
+}
+
+// Handler para listar los patrones de carga con sus valores por defecto
+func GetWorkloads(w http.ResponseWriter, r *http.Request) {
+	type workloadPreset struct {
+		Name        string                `json:"Name"`
+		Description string                `json:"Description"`
+		Defaults    utils.WorkloadOptions `json:"Defaults"`
+	}
+	presets := []workloadPreset{}
+	for _, preset := range utils.WorkloadPresets() {
+		presets = append(presets, workloadPreset{Name: preset[0], Description: preset[1], Defaults: utils.DefaultWorkloadOptions(preset[0])})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(presets)
+}
+
+// Handler para exportar las operaciones de memoria de los PEs (formatos text, binary, din o json)
+func GetMemoryTrace(w http.ResponseWriter, r *http.Request) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	// Cierra el canal de terminación para señalar la terminación del servidor.
-	close(terminateRESTfulAPI)
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	operations, _ := mps.Recorder.Snapshot()
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "text":
+		w.Header().Set("Content-Type", "text/plain")
+		trace.WriteMemoryText(w, operations)
+	case "din":
+		w.Header().Set("Content-Type", "text/plain")
+		trace.WriteMemoryDinero(w, operations)
+	case "binary":
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment; filename=memory.trace")
+		trace.WriteMemoryBinary(w, operations)
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(operations)
+	default:
+		writeError(w, http.StatusBadRequest, errorValidation, fmt.Sprintf("Formato %q no válido, use text, binary, din o json", format))
+	}
+}
+
+// Handler para exportar las transacciones del bus (formatos text, binary o json)
+func GetBusTrace(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
 
-	// Envía una respuesta exitosa al cliente.
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "Server is closing...")
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	_, transactions := mps.Recorder.Snapshot()
+	writeBusTrace(w, r, transactions)
+}
+
+// Función para escribir las transacciones del bus en el formato pedido
+func writeBusTrace(w http.ResponseWriter, r *http.Request, transactions []utils.BusTransaction) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "text":
+		w.Header().Set("Content-Type", "text/plain")
+		trace.WriteBusText(w, transactions)
+	case "binary":
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment; filename=bus.trace")
+		trace.WriteBusBinary(w, transactions)
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(transactions)
+	default:
+		writeError(w, http.StatusBadRequest, errorValidation, fmt.Sprintf("Formato %q no válido, use text, binary o json", format))
+	}
 }
 
-// Handler para cerrar el servidor por terminal
-func Close() {
-	fmt.Println("Sent 'lj' command to terminate the program")
-	close(terminateRESTfulAPI)
-	fmt.Println("Server is closing...")
+// Handler para ejecutar los mismos programas con varios protocolos y comparar sus métricas (formatos json o text)
+func RunComparison(w http.ResponseWriter, r *http.Request) {
+	var (
+		newData5 struct {
+			Protocols     []string         `json:"protocols"`     // Cada protocolo se combina con cada consistencia
+			Consistencies []string         `json:"consistencies"` // SC por defecto
+			Cores         int              `json:"cores"`
+			Programs      []string         `json:"programs"` // Programas guardados, los PEs sin programa usan uno generado
+			Workload      *workloadRequest `json:"workload"`
+			Memory        []uint32         `json:"memory"` // Memoria inicial, aleatoria si no se envía
+			Seed          int64            `json:"seed"`
+			MaxSteps      int              `json:"maxSteps"`
+		}
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&newData5); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" {
+		writeError(w, http.StatusBadRequest, errorValidation, fmt.Sprintf("Formato %q no válido, use json o text", format))
+		return
+	}
+
+	// Los campos vacíos usan los valores por defecto
+	options := comparison.DefaultOptions()
+	if newData5.Cores != 0 {
+		options.Base.Cores = newData5.Cores
+	}
+	if newData5.Workload != nil {
+		options.Base.Workload = newData5.Workload.options()
+	}
+	if newData5.Memory != nil {
+		options.Base.InitialMemory = newData5.Memory
+	}
+	if newData5.Seed != 0 {
+		options.Seed = newData5.Seed
+	}
+	if newData5.MaxSteps != 0 {
+		options.MaxSteps = newData5.MaxSteps
+	}
+	if len(newData5.Protocols) > 0 || len(newData5.Consistencies) > 0 {
+		protocols, consistencies := newData5.Protocols, newData5.Consistencies
+		if len(protocols) == 0 {
+			protocols = []string{"MESI", "MOESI"}
+		}
+		if len(consistencies) == 0 {
+			consistencies = []string{"SC"}
+		}
+		options.Variants = []comparison.Variant{}
+		for _, protocol := range protocols {
+			for _, consistency := range consistencies {
+				name := protocol
+				if len(consistencies) > 1 {
+					name = protocol + "/" + consistency
+				}
+				options.Variants = append(options.Variants, comparison.Variant{Name: name, Protocol: protocol, Consistency: consistency})
+			}
+		}
+	}
+	if err := assignPrograms(&options.Base, newData5.Programs); err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+
+	report, err := comparison.Compare(options)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+		return
+	}
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain")
+		report.WriteTable(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Handler para ejecutar un barrido de parámetros (formatos json, csv o runs-csv con cada ejecución)
+func RunSweep(w http.ResponseWriter, r *http.Request) {
+	var (
+		newData6 struct {
+			sweep.Parameters        // Valores de cada parámetro, los que no se envían usan los valores por defecto
+			Consistency      string `json:"consistency"`
+			Instructions     int    `json:"instructions"`
+			MaxSteps         int    `json:"maxSteps"`
+			Parallel         int    `json:"parallel"` // Ejecuciones simultáneas
+		}
+	)
+
+	if err := json.NewDecoder(r.Body).Decode(&newData6); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" && format != "runs-csv" {
+		writeError(w, http.StatusBadRequest, errorValidation, fmt.Sprintf("Formato %q no válido, use json, csv o runs-csv", format))
+		return
+	}
+
+	// Los campos vacíos usan los valores por defecto
+	options := sweep.DefaultOptions()
+	if newData6.Cores != nil {
+		options.Parameters.Cores = newData6.Cores
+	}
+	if newData6.CacheLines != nil {
+		options.Parameters.CacheLines = newData6.CacheLines
+		// Sin asociatividad las cachés son completamente asociativas
+		if newData6.CacheWays == nil {
+			options.Parameters.CacheWays = []int{0}
+		}
+	}
+	if newData6.CacheWays != nil {
+		options.Parameters.CacheWays = newData6.CacheWays
+	}
+	if newData6.Protocols != nil {
+		options.Parameters.Protocols = newData6.Protocols
+	}
+	if newData6.Presets != nil {
+		options.Parameters.Presets = newData6.Presets
+	}
+	if newData6.Seeds != nil {
+		options.Parameters.Seeds = newData6.Seeds
+	}
+	if newData6.Consistency != "" {
+		options.Base.Consistency = newData6.Consistency
+	}
+	if newData6.Instructions != 0 {
+		options.Instructions = newData6.Instructions
+	}
+	if newData6.MaxSteps != 0 {
+		options.MaxSteps = newData6.MaxSteps
+	}
+	if newData6.Parallel != 0 {
+		options.Parallel = newData6.Parallel
+	}
+
+	report, err := sweep.Run(options)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+		return
+	}
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		report.WriteCSV(w)
+	case "runs-csv":
+		w.Header().Set("Content-Type", "text/csv")
+		report.WriteRunsCSV(w)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// Patrón de carga pedido, los campos que no se envían usan los valores del preset
+type workloadRequest struct {
+	Preset        string   `json:"preset"`
+	Instructions  *int     `json:"instructions"`
+	Seed          *int64   `json:"seed"`
+	ReadRatio     *float64 `json:"readRatio"`
+	SharingDegree *int     `json:"sharingDegree"`
+	Locality      *float64 `json:"locality"`
+}
+
+// Function to get the options of the generator from a request
+func (workload *workloadRequest) options() *utils.WorkloadOptions {
+	options := utils.DefaultWorkloadOptions(workload.Preset)
+	if workload.Instructions != nil {
+		options.Instructions = *workload.Instructions
+	}
+	if workload.Seed != nil {
+		options.Seed = *workload.Seed
+	}
+	if workload.ReadRatio != nil {
+		options.ReadRatio = *workload.ReadRatio
+	}
+	if workload.SharingDegree != nil {
+		options.SharingDegree = *workload.SharingDegree
+	}
+	if workload.Locality != nil {
+		options.Locality = *workload.Locality
+	}
+	return &options
+}
+
+// Function to give the saved programs to the PEs, an empty name keeps the generated program
+func assignPrograms(cfg *MultiprocessingSystem.Config, names []string) error {
+	if len(names) > cfg.Cores {
+		return fmt.Errorf("Se enviaron %d programas para %d PEs", len(names), cfg.Cores)
+	}
+	for id, name := range names {
+		if name == "" {
+			continue
+		}
+		program, err := library.Get(name)
+		if err != nil {
+			return err
+		}
+		if cfg.Programs == nil {
+			cfg.Programs = make([][]string, cfg.Cores)
+			cfg.ProgramNames = make([]string, cfg.Cores)
+		}
+		cfg.Programs[id] = strings.Split(program.Source, "\n")
+		cfg.ProgramNames[id] = name
+	}
+	return nil
 }
@@ -0,0 +1,115 @@
+package restfulapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Breakpoint pedido, core y address que no se envían valen -1 (todos los PEs o cualquier dirección)
+type breakpointRequest struct {
+	Kind        string `json:"kind"`
+	Core        *int   `json:"core"`
+	Position    int    `json:"position"`
+	Instruction string `json:"instruction"`
+	Address     *int   `json:"address"`
+	Access      string `json:"access"`
+	Transaction string `json:"transaction"`
+}
+
+// Handler para listar los breakpoints del sistema del frontend
+func GetBreakpoints(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, mps.Breakpoints())
+}
+
+// Handler para agregar un breakpoint al sistema del frontend
+func AddBreakpoint(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	addBreakpoint(w, r, mps)
+}
+
+// Handler para eliminar un breakpoint del sistema del frontend
+func DeleteBreakpoint(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	deleteBreakpoint(w, r, mps)
+}
+
+// Handler para listar los breakpoints de un sistema de la API v1
+func getSystemBreakpoints(w http.ResponseWriter, r *http.Request, s *session) {
+	writeJSON(w, http.StatusOK, s.system.Breakpoints())
+}
+
+// Handler para agregar un breakpoint a un sistema de la API v1
+func addSystemBreakpoint(w http.ResponseWriter, r *http.Request, s *session) {
+	addBreakpoint(w, r, s.system)
+}
+
+// Handler para eliminar un breakpoint de un sistema de la API v1
+func deleteSystemBreakpoint(w http.ResponseWriter, r *http.Request, s *session) {
+	deleteBreakpoint(w, r, s.system)
+}
+
+// Función para agregar el breakpoint de la petición, responde con su ID
+func addBreakpoint(w http.ResponseWriter, r *http.Request, system *MultiprocessingSystem.MultiprocessingSystem) {
+	request := breakpointRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+		return
+	}
+	breakpoint := utils.Breakpoint{
+		Kind:        request.Kind,
+		Core:        -1,
+		Position:    request.Position,
+		Instruction: request.Instruction,
+		Address:     -1,
+		Access:      request.Access,
+		Transaction: request.Transaction,
+	}
+	if request.Core != nil {
+		breakpoint.Core = *request.Core
+	}
+	if request.Address != nil {
+		breakpoint.Address = *request.Address
+	}
+	breakpoint, err := system.AddBreakpoint(breakpoint)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, breakpoint)
+}
+
+// Función para eliminar el breakpoint de la ruta
+func deleteBreakpoint(w http.ResponseWriter, r *http.Request, system *MultiprocessingSystem.MultiprocessingSystem) {
+	id, err := strconv.Atoi(mux.Vars(r)["breakpoint"])
+	if err == nil {
+		err = system.RemoveBreakpoint(id)
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, errorNotFound, fmt.Sprintf("No existe el breakpoint %s", mux.Vars(r)["breakpoint"]))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,81 @@
+package restfulapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Handler para guardar el estado completo del sistema del frontend
+func GetCheckpoint(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	writeCheckpoint(w, mps)
+}
+
+// Handler para reemplazar el sistema del frontend por uno guardado con GetCheckpoint
+func RestoreCheckpoint(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	checkpoint := &MultiprocessingSystem.Checkpoint{}
+	if err := json.NewDecoder(r.Body).Decode(checkpoint); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido: "+err.Error())
+		return
+	}
+	if err := checkpoint.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+		return
+	}
+	cfg := checkpoint.RestoreConfig()
+	cfg.LogDirectory = settings.LogDirectory
+	cfg.ProgramDirectory = settings.GeneratedDirectory
+	cfg.Events = events
+
+	// El sistema anterior se detiene antes de que el nuevo cree sus logs en la misma carpeta,
+	// solo cuando el nuevo ya no puede fallar, si falla el anterior sigue disponible
+	started, err := MultiprocessingSystem.Replace(mps, cfg)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+		return
+	}
+	mps = started
+
+	// Los clientes del stream reciben el estado restaurado
+	events.Publish(utils.EventSnapshot, mps.State())
+	writeJSON(w, http.StatusOK, mps.State())
+}
+
+// Handler para guardar el estado completo de un sistema de la API v1
+func getSystemCheckpoint(w http.ResponseWriter, r *http.Request, s *session) {
+	writeCheckpoint(w, s.system)
+}
+
+// Función para crear una sesión con el sistema guardado en un checkpoint, el planificador de run usa la semilla
+func restoreSystem(w http.ResponseWriter, checkpoint *MultiprocessingSystem.Checkpoint, seed int64) {
+	if err := checkpoint.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+		return
+	}
+	// Como los sistemas creados en la API v1, sin latencias ni mensajes en la consola
+	cfg := checkpoint.RestoreConfig()
+	cfg.Latency = utils.NoLatencies()
+	cfg.Quiet = true
+	startSession(w, cfg, seed)
+}
+
+// Función para responder con el checkpoint de un sistema, falla mientras se ejecuta una instrucción
+func writeCheckpoint(w http.ResponseWriter, system *MultiprocessingSystem.MultiprocessingSystem) {
+	checkpoint, err := system.Checkpoint()
+	if err != nil {
+		writeError(w, http.StatusConflict, errorConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, checkpoint)
+}
@@ -0,0 +1,147 @@
+package restfulapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Duración que se escribe como texto en el archivo de configuración, por ejemplo "30s" o "2m"
+type Duration time.Duration
+
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(duration).String())
+}
+
+func (duration *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("la duración debe ser un texto como \"30s\": %s", data)
+	}
+	value, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*duration = Duration(value)
+	return nil
+}
+
+// Configuración del servidor, se lee de un archivo JSON y la reemplazan las variables de entorno y los flags
+type ServerConfig struct {
+	Address            string   `json:"address"`            // Dirección en la que escucha el servidor
+	TLSCertFile        string   `json:"tlsCertFile"`        // Certificado de HTTPS, junto con tlsKeyFile
+	TLSKeyFile         string   `json:"tlsKeyFile"`         // Clave privada del certificado
+	AllowedOrigins     []string `json:"allowedOrigins"`     // Orígenes aceptados por CORS y los WebSockets, "*" acepta todos
+	LogDirectory       string   `json:"logDirectory"`       // Carpeta de los logs del sistema del frontend
+	LibraryDirectory   string   `json:"libraryDirectory"`   // Carpeta de los programas guardados
+	GeneratedDirectory string   `json:"generatedDirectory"` // Carpeta de los programas generados
+	ReadHeaderTimeout  Duration `json:"readHeaderTimeout"`  // Tiempo para leer las cabeceras de una petición
+	ReadTimeout        Duration `json:"readTimeout"`        // Tiempo para leer una petición completa, 0 sin límite
+	WriteTimeout       Duration `json:"writeTimeout"`       // Tiempo para escribir una respuesta, 0 sin límite para los barridos largos
+	IdleTimeout        Duration `json:"idleTimeout"`        // Tiempo que se mantiene una conexión sin peticiones
+	ShutdownTimeout    Duration `json:"shutdownTimeout"`    // Tiempo para terminar las peticiones en curso al apagar
+}
+
+// Prefijo de las variables de entorno de la configuración
+const environmentPrefix = "SIMULATOR_"
+
+// Función para obtener la configuración con la que el servidor se comporta como siempre
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		Address:            ":8080",
+		AllowedOrigins:     []string{"*"},
+		LogDirectory:       "logs",
+		LibraryDirectory:   "programs",
+		GeneratedDirectory: "generated-programs",
+		ReadHeaderTimeout:  Duration(10 * time.Second),
+		IdleTimeout:        Duration(2 * time.Minute),
+		ShutdownTimeout:    Duration(10 * time.Second),
+	}
+}
+
+// Función para leer un archivo de configuración, los campos que no tiene mantienen su valor
+func (cfg *ServerConfig) Load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// Función para aplicar las variables de entorno SIMULATOR_*, lookup es os.LookupEnv fuera de las pruebas
+func (cfg *ServerConfig) ApplyEnvironment(lookup func(string) (string, bool)) error {
+	texts := map[string]*string{
+		"ADDRESS":             &cfg.Address,
+		"TLS_CERT_FILE":       &cfg.TLSCertFile,
+		"TLS_KEY_FILE":        &cfg.TLSKeyFile,
+		"LOG_DIRECTORY":       &cfg.LogDirectory,
+		"LIBRARY_DIRECTORY":   &cfg.LibraryDirectory,
+		"GENERATED_DIRECTORY": &cfg.GeneratedDirectory,
+	}
+	for name, field := range texts {
+		if value, found := lookup(environmentPrefix + name); found {
+			*field = value
+		}
+	}
+	if value, found := lookup(environmentPrefix + "ALLOWED_ORIGINS"); found {
+		cfg.AllowedOrigins = splitOrigins(value)
+	}
+	durations := map[string]*Duration{
+		"READ_HEADER_TIMEOUT": &cfg.ReadHeaderTimeout,
+		"READ_TIMEOUT":        &cfg.ReadTimeout,
+		"WRITE_TIMEOUT":       &cfg.WriteTimeout,
+		"IDLE_TIMEOUT":        &cfg.IdleTimeout,
+		"SHUTDOWN_TIMEOUT":    &cfg.ShutdownTimeout,
+	}
+	for name, field := range durations {
+		if value, found := lookup(environmentPrefix + name); found {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s%s: %v", environmentPrefix, name, err)
+			}
+			*field = Duration(duration)
+		}
+	}
+	return nil
+}
+
+// Función para separar una lista de orígenes escrita con comas
+func splitOrigins(text string) []string {
+	origins := []string{}
+	for _, origin := range strings.Split(text, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// Función para comprobar que la configuración permite iniciar el servidor
+func (cfg ServerConfig) Validate() error {
+	if cfg.Address == "" {
+		return fmt.Errorf("falta la dirección del servidor")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return fmt.Errorf("HTTPS necesita el certificado y la clave")
+	}
+	if len(cfg.AllowedOrigins) == 0 {
+		return fmt.Errorf("falta al menos un origen para CORS")
+	}
+	if cfg.LogDirectory == "" || cfg.LibraryDirectory == "" || cfg.GeneratedDirectory == "" {
+		return fmt.Errorf("las carpetas de logs, de programas guardados y de programas generados son obligatorias")
+	}
+	for _, timeout := range []Duration{cfg.ReadHeaderTimeout, cfg.ReadTimeout, cfg.WriteTimeout, cfg.IdleTimeout, cfg.ShutdownTimeout} {
+		if timeout < 0 {
+			return fmt.Errorf("los tiempos no pueden ser negativos")
+		}
+	}
+	return nil
+}
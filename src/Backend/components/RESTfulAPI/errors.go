@@ -0,0 +1,74 @@
+package restfulapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"Backend/components/Assembler"
+)
+
+// Códigos de error de la API, los clientes distinguen los errores por el código y no por el mensaje
+const (
+	errorInvalidJSON      = "invalid_json"           // El cuerpo no es JSON
+	errorValidation       = "validation_failed"      // La petición no cumple el documento OpenAPI
+	errorInvalidRequest   = "invalid_request"        // La configuración pedida no es válida
+	errorInvalidProgram   = "invalid_program"        // Un programa no se pudo ensamblar, ver Diagnostics
+	errorTooLarge         = "request_too_large"      // El cuerpo supera maxRequestSize
+	errorNotFound         = "not_found"              // La ruta o el recurso no existe
+	errorMethodNotAllowed = "method_not_allowed"     // La ruta no acepta el método
+	errorNotInitialized   = "system_not_initialized" // No hay un sistema iniciado
+	errorRunNotFinished   = "run_not_finished"       // Las métricas se piden antes de que el sistema termine
+	errorConflict         = "conflict"               // El estado del sistema no permite la operación
+	errorTooManySystems   = "too_many_systems"       // Ya hay maxSessions sistemas
+	errorInternal         = "internal_error"         // Error del servidor
+)
+
+// Error de la API, con el campo de cada problema de validación o los diagnósticos del ensamblador
+type apiError struct {
+	Code        string                 `json:"Code"`
+	Message     string                 `json:"Message"`
+	Details     []errorDetail          `json:"Details,omitempty"`
+	Diagnostics []assembler.Diagnostic `json:"Diagnostics,omitempty"`
+}
+
+// Problema de un campo de la petición, por ejemplo body.workload.readRatio o query.format
+type errorDetail struct {
+	Field   string `json:"Field"`
+	Message string `json:"Message"`
+}
+
+// Cuerpo de todas las respuestas de error
+type errorResponse struct {
+	Error apiError `json:"Error"`
+}
+
+// Función para responder con un error
+func writeError(w http.ResponseWriter, status int, code string, message string, details ...errorDetail) {
+	writeJSON(w, status, errorResponse{Error: apiError{Code: code, Message: message, Details: details}})
+}
+
+// Función para responder que no hay un sistema iniciado
+func writeNotInitialized(w http.ResponseWriter) {
+	writeError(w, http.StatusConflict, errorNotInitialized, "El sistema no se ha inicializado")
+}
+
+// Function to reject a request because of an invalid program, the body lists every diagnostic
+func writeProgramError(w http.ResponseWriter, err error) {
+	response := errorResponse{Error: apiError{Code: errorInvalidProgram, Message: err.Error()}}
+	var assemblyError *assembler.Error
+	if errors.As(err, &assemblyError) {
+		response.Error.Diagnostics = assemblyError.Diagnostics
+	}
+	writeJSON(w, http.StatusBadRequest, response)
+}
+
+// Handler de las rutas que no existen
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, errorNotFound, fmt.Sprintf("No existe la ruta %s", r.URL.Path))
+}
+
+// Handler de las rutas que existen con otro método
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, errorMethodNotAllowed, fmt.Sprintf("La ruta %s no acepta %s", r.URL.Path, r.Method))
+}
@@ -0,0 +1,144 @@
+package restfulapi
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Eventos guardados para que los clientes puedan reanudar el stream
+const eventHistory = 4096
+
+var (
+	// Stream compartido por todos los sistemas iniciados, las secuencias siguen entre inicializaciones
+	events = utils.NewEventStream(eventHistory)
+
+	// El frontend se sirve desde otro origen, se aceptan los mismos orígenes que con CORS
+	upgrader = websocket.Upgrader{CheckOrigin: allowedOrigin}
+)
+
+// Función para comprobar el origen de un WebSocket, las peticiones sin Origin no vienen de un navegador
+func allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range settings.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// Tiempo máximo para escribir un evento antes de cerrar la conexión
+const eventWriteTimeout = 10 * time.Second
+
+// Handler del stream de eventos por WebSocket. Envía un snapshot del sistema y después los eventos
+// a medida que ocurren. Con ?after=N se reanuda después del evento N; si esos eventos ya no se
+// guardan, el stream empieza con un snapshot nuevo.
+func GetEvents(w http.ResponseWriter, r *http.Request) {
+	// El stream se reemplaza cuando el servidor se apaga
+	mutex.Lock()
+	stream := events
+	mutex.Unlock()
+	serveEvents(w, r, stream, &mutex, func() *MultiprocessingSystem.MultiprocessingSystem { return mps })
+}
+
+// Función para servir un stream de eventos, lock protege el sistema devuelto por system
+func serveEvents(w http.ResponseWriter, r *http.Request, events *utils.EventStream, lock sync.Locker, system func() *MultiprocessingSystem.MultiprocessingSystem) {
+	after := -1
+	if text := r.URL.Query().Get("after"); text != "" {
+		value, err := strconv.Atoi(text)
+		if err != nil || value < 0 {
+			writeError(w, http.StatusBadRequest, errorValidation, "Parámetro after no válido")
+			return
+		}
+		after = value
+	}
+
+	connection, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade ya respondió al cliente
+		return
+	}
+	defer connection.Close()
+
+	// El snapshot y la suscripción se toman juntos, ninguna otra petición cambia el sistema entre ambos
+	lock.Lock()
+	resume := after >= 0 && after <= events.Last()
+	if !resume {
+		after = events.Last()
+	}
+	backlog, stream, cancel, complete := events.Subscribe(after)
+	if !complete {
+		cancel()
+		resume, after = false, events.Last()
+		backlog, stream, cancel, _ = events.Subscribe(after)
+	}
+	var snapshot *utils.Event
+	if !resume {
+		snapshot = snapshotEvent(system(), after)
+	}
+	lock.Unlock()
+	defer cancel()
+
+	// Leer los mensajes del cliente detecta cuando cierra la conexión
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := connection.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if snapshot != nil && writeEvent(connection, *snapshot) != nil {
+		return
+	}
+	for _, event := range backlog {
+		if writeEvent(connection, event) != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case event, open := <-stream:
+			if !open && events.Closed() {
+				connection.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "system deleted"))
+				return
+			}
+			// Un cliente lento se desconecta, puede reanudar con su último evento
+			if !open {
+				connection.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "stream behind, resume with after"))
+				return
+			}
+			if writeEvent(connection, event) != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// Función para obtener el estado del sistema actual como evento, sin sistema no hay snapshot
+func snapshotEvent(system *MultiprocessingSystem.MultiprocessingSystem, sequence int) *utils.Event {
+	if system == nil {
+		return nil
+	}
+	return &utils.Event{Sequence: sequence, Type: utils.EventSnapshot, Data: system.StateSnapshot()}
+}
+
+// Función para enviar un evento como JSON
+func writeEvent(connection *websocket.Conn, event utils.Event) error {
+	connection.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+	return connection.WriteJSON(event)
+}
@@ -0,0 +1,57 @@
+package restfulapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"Backend/components/MultiprocessingSystem"
+)
+
+// Pausa entre ciclos de la acción all, el frontend consulta el estado mientras tanto
+const allDelay = 2 * time.Second
+
+// Acciones que controlan la ejecución libre de un sistema
+var executionActions = map[string]func(system *MultiprocessingSystem.MultiprocessingSystem) error{
+	"pause":  (*MultiprocessingSystem.MultiprocessingSystem).Pause,
+	"resume": (*MultiprocessingSystem.MultiprocessingSystem).Resume,
+	"cancel": (*MultiprocessingSystem.MultiprocessingSystem).Cancel,
+}
+
+// Handler para obtener el estado de la ejecución libre de un sistema
+func getExecution(w http.ResponseWriter, r *http.Request, s *session) {
+	writeJSON(w, http.StatusOK, s.system.Execution())
+}
+
+// Handler para iniciar, pausar, reanudar o cancelar la ejecución libre de un sistema
+func controlExecution(w http.ResponseWriter, r *http.Request, s *session) {
+	request := struct {
+		Action       string `json:"action"`
+		Instructions int    `json:"instructions"` // Instrucciones antes de pausar, 0 sin límite
+		Cycles       int    `json:"cycles"`       // Ciclos antes de pausar, 0 sin límite
+		Delay        int    `json:"delay"`        // Milisegundos entre ciclos
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+		return
+	}
+
+	var err error
+	if request.Action == "run" {
+		err = s.system.Run(MultiprocessingSystem.RunLimit{
+			Instructions: request.Instructions,
+			Cycles:       request.Cycles,
+			Delay:        time.Duration(request.Delay) * time.Millisecond,
+		})
+	} else if control, found := executionActions[request.Action]; found {
+		err = control(s.system)
+	} else {
+		writeError(w, http.StatusBadRequest, errorValidation, "Acción no válida, use run, pause, resume o cancel")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, errorConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.system.Execution())
+}
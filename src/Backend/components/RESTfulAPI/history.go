@@ -0,0 +1,151 @@
+package restfulapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Handler para listar los pasos guardados en la historia del sistema del frontend
+func GetHistory(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, mps.History())
+}
+
+// Handler para obtener el estado del sistema del frontend después de un paso
+func GetHistoryState(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	writeHistoryState(w, r, mps)
+}
+
+// Handler para obtener los cambios del sistema del frontend entre dos pasos
+func GetHistoryDiff(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	writeHistoryDiff(w, r, mps)
+}
+
+// Handler para volver el sistema del frontend al estado después de un paso, continúa desde ese paso
+func RewindHistory(w http.ResponseWriter, r *http.Request) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if mps == nil {
+		writeNotInitialized(w)
+		return
+	}
+	rewound := rewindSystem(w, r, mps)
+	if rewound == nil {
+		// Un sistema detenido por una vuelta atrás fallida ya no puede continuar
+		if mps.Stopped() {
+			mps = nil
+		}
+		return
+	}
+	mps = rewound
+
+	// Los clientes del stream reciben el estado del paso
+	events.Publish(utils.EventSnapshot, mps.State())
+	writeJSON(w, http.StatusOK, mps.State())
+}
+
+// Handler para listar los pasos guardados en la historia de un sistema de la API v1
+func getSystemHistory(w http.ResponseWriter, r *http.Request, s *session) {
+	writeJSON(w, http.StatusOK, s.system.History())
+}
+
+// Handler para obtener el estado de un sistema de la API v1 después de un paso
+func getSystemHistoryState(w http.ResponseWriter, r *http.Request, s *session) {
+	writeHistoryState(w, r, s.system)
+}
+
+// Handler para obtener los cambios de un sistema de la API v1 entre dos pasos
+func getSystemHistoryDiff(w http.ResponseWriter, r *http.Request, s *session) {
+	writeHistoryDiff(w, r, s.system)
+}
+
+// Handler para volver un sistema de la API v1 al estado después de un paso, continúa desde ese paso
+func rewindSystemHistory(w http.ResponseWriter, r *http.Request, s *session) {
+	rewound := rewindSystem(w, r, s.system)
+	if rewound == nil {
+		return
+	}
+	s.system = rewound
+	s.events.Publish(utils.EventSnapshot, s.system.State())
+	writeJSON(w, http.StatusOK, s.system.State())
+}
+
+// Función para volver un sistema al paso de la ruta, responde con el error y devuelve nil cuando falla
+func rewindSystem(w http.ResponseWriter, r *http.Request, system *MultiprocessingSystem.MultiprocessingSystem) *MultiprocessingSystem.MultiprocessingSystem {
+	step, err := strconv.Atoi(mux.Vars(r)["step"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorValidation, "Paso no válido")
+		return nil
+	}
+	if _, err := system.StateAtStep(step); err != nil {
+		writeError(w, http.StatusNotFound, errorNotFound, err.Error())
+		return nil
+	}
+	rewound, err := system.Rewind(step)
+	switch {
+	case err == nil:
+		return rewound
+	case system.Stopped():
+		writeError(w, http.StatusInternalServerError, errorInternal, err.Error())
+	default:
+		writeError(w, http.StatusConflict, errorConflict, err.Error())
+	}
+	return nil
+}
+
+// Función para responder con el estado después del paso de la ruta, 0 es el estado inicial
+func writeHistoryState(w http.ResponseWriter, r *http.Request, system *MultiprocessingSystem.MultiprocessingSystem) {
+	step, err := strconv.Atoi(mux.Vars(r)["step"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorValidation, "Paso no válido")
+		return
+	}
+	state, err := system.StateAtStep(step)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errorNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// Función para responder con los cambios entre los pasos from y to de la consulta
+func writeHistoryDiff(w http.ResponseWriter, r *http.Request, system *MultiprocessingSystem.MultiprocessingSystem) {
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorValidation, "Parámetro from no válido")
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorValidation, "Parámetro to no válido")
+		return
+	}
+	diff, err := system.DiffSteps(from, to)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errorNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, diff)
+}
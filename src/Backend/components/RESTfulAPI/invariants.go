@@ -0,0 +1,18 @@
+package restfulapi
+
+import (
+	"net/http"
+
+	"Backend/utils"
+)
+
+// Invariantes de coherencia de un sistema, Violation es null mientras se cumplen
+type invariantsResource struct {
+	Enabled   bool                      `json:"Enabled"` // El sistema se creó con checkInvariants
+	Violation *utils.InvariantViolation `json:"Violation"`
+}
+
+// Handler para obtener el reporte del verificador de invariantes de un sistema de la API v1
+func getSystemInvariants(w http.ResponseWriter, r *http.Request, s *session) {
+	writeJSON(w, http.StatusOK, invariantsResource{Enabled: s.system.CheckingInvariants(), Violation: s.system.Violation()})
+}
@@ -0,0 +1,335 @@
+package restfulapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Documento OpenAPI 3 de la API, la validación de las peticiones lo usa tal cual se publica
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// Tamaño máximo del cuerpo de una petición, las trazas binarias llegan en base64
+const maxRequestSize = 32 << 20
+
+// Partes del documento OpenAPI que se usan para validar
+type openAPI struct {
+	Paths      map[string]map[string]*operation `json:"paths"` // Operación de cada ruta por método en minúsculas
+	Components struct {
+		Schemas    map[string]*schema    `json:"schemas"`
+		Parameters map[string]*parameter `json:"parameters"`
+	} `json:"components"`
+}
+
+type operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*parameter `json:"parameters"`
+	RequestBody *struct {
+		Required bool `json:"required"`
+		Content  map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+type parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // path o query
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+// Subconjunto de JSON Schema que usa el documento
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"` // false rechaza los campos que no están en properties
+	Items                *schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MaxItems             *int               `json:"maxItems"`
+}
+
+var document = loadOpenAPI()
+
+// Función para leer el documento embebido, un documento roto es un error de programación
+func loadOpenAPI() *openAPI {
+	spec := &openAPI{}
+	if err := json.Unmarshal(openAPIDocument, spec); err != nil {
+		panic(fmt.Sprintf("openapi.json no es válido: %v", err))
+	}
+	return spec
+}
+
+// Handler para obtener el documento OpenAPI
+func GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
+
+// Función para obtener la operación de una ruta del enrutador, nil si el documento no la describe
+func (spec *openAPI) operation(template string, method string) *operation {
+	return spec.Paths[template][strings.ToLower(method)]
+}
+
+// Función para seguir la referencia de un esquema
+func (spec *openAPI) schema(s *schema) *schema {
+	for s != nil && s.Ref != "" {
+		s = spec.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// Función para seguir la referencia de un parámetro
+func (spec *openAPI) parameter(p *parameter) *parameter {
+	if p.Ref != "" {
+		return spec.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+	}
+	return p
+}
+
+// Función para comprobar que cada ruta del enrutador está en el documento y cada operación del documento tiene ruta
+func (spec *openAPI) check(router *mux.Router) error {
+	routed := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if spec.operation(template, method) == nil {
+				return fmt.Errorf("openapi.json no describe %s %s", method, template)
+			}
+			routed[template+" "+strings.ToLower(method)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if !routed[path+" "+method] {
+				return fmt.Errorf("openapi.json describe %s %s pero no tiene ruta", strings.ToUpper(method), path)
+			}
+		}
+	}
+	return nil
+}
+
+// Middleware que valida los parámetros y el cuerpo de cada petición con su operación del documento.
+// El cuerpo leído se devuelve a la petición para el handler.
+func validateRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, _ := route.GetPathTemplate()
+		operation := document.operation(template, r.Method)
+		if operation == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		details := []errorDetail{}
+		for _, p := range operation.Parameters {
+			p = document.parameter(p)
+			var value string
+			var present bool
+			if p.In == "path" {
+				value, present = mux.Vars(r)[p.Name]
+			} else {
+				present = r.URL.Query().Has(p.Name)
+				value = r.URL.Query().Get(p.Name)
+			}
+			field := p.In + "." + p.Name
+			if !present {
+				if p.Required {
+					details = append(details, errorDetail{field, "Es obligatorio"})
+				}
+				continue
+			}
+			details = append(details, document.validate(p.Schema, parameterValue(document.schema(p.Schema), value), field)...)
+		}
+
+		if operation.RequestBody != nil {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, errorTooLarge, fmt.Sprintf("El cuerpo supera los %d bytes", maxRequestSize))
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusBadRequest, errorInvalidJSON, "No se pudo leer el cuerpo: "+err.Error())
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+
+			if len(bytes.TrimSpace(body)) == 0 {
+				if operation.RequestBody.Required {
+					details = append(details, errorDetail{"body", "Es obligatorio"})
+				}
+			} else {
+				value, err := decodeBody(body)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido: "+err.Error())
+					return
+				}
+				details = append(details, document.validate(operation.RequestBody.Content["application/json"].Schema, value, "body")...)
+			}
+		}
+
+		if len(details) > 0 {
+			writeError(w, http.StatusBadRequest, errorValidation, "La petición no cumple el documento OpenAPI", details...)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Función para decodificar un cuerpo JSON, los números se guardan como json.Number para distinguir los enteros
+func decodeBody(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("hay datos después del valor JSON")
+	}
+	return value, nil
+}
+
+// Función para convertir el texto de un parámetro al tipo de su esquema
+func parameterValue(s *schema, text string) interface{} {
+	switch s.Type {
+	case "integer", "number":
+		return json.Number(text)
+	case "boolean":
+		if value, err := strconv.ParseBool(text); err == nil {
+			return value
+		}
+	}
+	return text
+}
+
+// Función para validar un valor con un esquema, devuelve un problema por cada campo no válido
+func (spec *openAPI) validate(s *schema, value interface{}, field string) []errorDetail {
+	s = spec.schema(s)
+	if s == nil {
+		return nil
+	}
+	problem := func(format string, args ...interface{}) []errorDetail {
+		return []errorDetail{{field, fmt.Sprintf(format, args...)}}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return problem("Debe ser un objeto")
+		}
+		details := []errorDetail{}
+		for _, name := range s.Required {
+			if _, found := object[name]; !found {
+				details = append(details, errorDetail{field + "." + name, "Es obligatorio"})
+			}
+		}
+		names := []string{}
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, found := s.Properties[name]
+			if !found {
+				if string(s.AdditionalProperties) == "false" {
+					details = append(details, errorDetail{field + "." + name, "No es un campo de la petición"})
+				}
+				continue
+			}
+			details = append(details, spec.validate(property, object[name], field+"."+name)...)
+		}
+		return details
+
+	case "array":
+		list, ok := value.([]interface{})
+		if !ok {
+			return problem("Debe ser una lista")
+		}
+		if s.MaxItems != nil && len(list) > *s.MaxItems {
+			return problem("Tiene %d elementos, el máximo es %d", len(list), *s.MaxItems)
+		}
+		details := []errorDetail{}
+		for i, item := range list {
+			details = append(details, spec.validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i))...)
+		}
+		return details
+
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return problem("Debe ser un texto")
+		}
+		if len(s.Enum) > 0 {
+			options := []string{}
+			for _, option := range s.Enum {
+				if option == text {
+					return nil
+				}
+				options = append(options, fmt.Sprint(option))
+			}
+			return problem("%q no es válido, use %s", text, strings.Join(options, ", "))
+		}
+
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return problem("Debe ser un número")
+		}
+		if s.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				return problem("%s no es un número entero", number)
+			}
+		}
+		amount, err := number.Float64()
+		if err != nil {
+			return problem("%s no es un número", number)
+		}
+		if s.Minimum != nil && amount < *s.Minimum {
+			return problem("Debe ser al menos %v", *s.Minimum)
+		}
+		if s.Maximum != nil && amount > *s.Maximum {
+			return problem("Debe ser como máximo %v", *s.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return problem("Debe ser true o false")
+		}
+	}
+	return nil
+}
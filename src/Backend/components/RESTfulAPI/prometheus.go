@@ -0,0 +1,260 @@
+package restfulapi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"Backend/components/CacheController"
+	"Backend/components/MultiprocessingSystem"
+)
+
+// Sesión con la que se publica el sistema del frontend, los sistemas de la API v1 usan su identificador
+const defaultSession = "default"
+
+// Límites de los buckets del histograma de las peticiones, en segundos
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histograma de la duración de las peticiones HTTP por método, ruta y código de respuesta
+type latencyHistogram struct {
+	mutex  sync.Mutex
+	series map[[3]string]*latencySeries
+}
+
+type latencySeries struct {
+	counts []uint64 // Peticiones de cada bucket, sin acumular
+	count  uint64
+	sum    float64
+}
+
+var requestLatency = &latencyHistogram{series: map[[3]string]*latencySeries{}}
+
+// Función para guardar la duración de una petición
+func (histogram *latencyHistogram) observe(method string, route string, status int, seconds float64) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+	key := [3]string{method, route, strconv.Itoa(status)}
+	series := histogram.series[key]
+	if series == nil {
+		series = &latencySeries{counts: make([]uint64, len(latencyBuckets))}
+		histogram.series[key] = series
+	}
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			series.counts[i]++
+			break
+		}
+	}
+	series.count++
+	series.sum += seconds
+}
+
+// Función para escribir el histograma en el formato de Prometheus
+func (histogram *latencyHistogram) write(w io.Writer) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+	name := "simulator_http_request_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Duración de las peticiones HTTP.\n# TYPE %s histogram\n", name, name)
+	keys := [][3]string{}
+	for key := range histogram.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.Join(keys[i][:], " ") < strings.Join(keys[j][:], " ")
+	})
+	for _, key := range keys {
+		series := histogram.series[key]
+		labels := [][2]string{{"method", key[0]}, {"route", key[1]}, {"code", key[2]}}
+		cumulative := uint64(0)
+		for i, bound := range latencyBuckets {
+			cumulative += series.counts[i]
+			writeSample(w, name+"_bucket", append(labels, [2]string{"le", formatValue(bound)}), float64(cumulative))
+		}
+		writeSample(w, name+"_bucket", append(labels, [2]string{"le", "+Inf"}), float64(series.count))
+		writeSample(w, name+"_sum", labels, series.sum)
+		writeSample(w, name+"_count", labels, float64(series.count))
+	}
+}
+
+// ResponseWriter que guarda el código de la respuesta, los WebSockets toman la conexión con Hijack
+type statusRecorder struct {
+	http.ResponseWriter
+	status   int
+	hijacked bool
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(data []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return recorder.ResponseWriter.Write(data)
+}
+
+func (recorder *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := recorder.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("la respuesta no permite Hijack")
+	}
+	recorder.hijacked = true
+	return hijacker.Hijack()
+}
+
+func (recorder *statusRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Middleware que mide la duración de cada petición con la plantilla de su ruta, así las rutas
+// con identificadores no crean una serie por recurso. Los streams de eventos no se miden.
+func observeRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.hijacked {
+			return
+		}
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			route, _ = current.GetPathTemplate()
+		}
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		requestLatency.observe(r.Method, route, recorder.status, time.Since(start).Seconds())
+	})
+}
+
+// Sistema publicado con sus etiquetas
+type labeledSystem struct {
+	session string
+	system  *MultiprocessingSystem.MultiprocessingSystem
+}
+
+// Contador o gauge de un sistema, cache es nil para los valores del Interconnect
+type systemMetric struct {
+	name  string
+	kind  string
+	help  string
+	cache func(cc *CacheController.CacheController) float64
+	value func(system *MultiprocessingSystem.MultiprocessingSystem) float64
+}
+
+var systemMetrics = []systemMetric{
+	{name: "simulator_cache_hits_total", kind: "counter", help: "Aciertos de la caché de cada PE.",
+		cache: func(cc *CacheController.CacheController) float64 { return float64(cc.Counters().Hits) }},
+	{name: "simulator_cache_misses_total", kind: "counter", help: "Fallos de la caché de cada PE.",
+		cache: func(cc *CacheController.CacheController) float64 { return float64(cc.Counters().Misses) }},
+	{name: "simulator_cache_sc_successes_total", kind: "counter", help: "SC que escribieron en la caché de cada PE.",
+		cache: func(cc *CacheController.CacheController) float64 { return float64(cc.Counters().SCSuccesses) }},
+	{name: "simulator_cache_sc_failures_total", kind: "counter", help: "SC que fallaron en la caché de cada PE.",
+		cache: func(cc *CacheController.CacheController) float64 { return float64(cc.Counters().SCFailures) }},
+	{name: "simulator_bus_read_requests_total", kind: "counter", help: "ReadRequest servidos por el Interconnect.",
+		value: func(mps *MultiprocessingSystem.MultiprocessingSystem) float64 {
+			return float64(mps.Interconnect.Counters().ReadRequests)
+		}},
+	{name: "simulator_bus_read_exclusive_requests_total", kind: "counter", help: "ReadExclusiveRequest servidos por el Interconnect.",
+		value: func(mps *MultiprocessingSystem.MultiprocessingSystem) float64 {
+			return float64(mps.Interconnect.Counters().ReadExclusiveRequests)
+		}},
+	{name: "simulator_bus_data_responses_total", kind: "counter", help: "DataResponse enviados por el Interconnect.",
+		value: func(mps *MultiprocessingSystem.MultiprocessingSystem) float64 {
+			return float64(mps.Interconnect.Counters().DataResponses)
+		}},
+	{name: "simulator_bus_invalidates_total", kind: "counter", help: "Invalidate enviados por el Interconnect.",
+		value: func(mps *MultiprocessingSystem.MultiprocessingSystem) float64 {
+			return float64(mps.Interconnect.Counters().Invalidates)
+		}},
+	{name: "simulator_memory_reads_total", kind: "counter", help: "Lecturas de la Main Memory.",
+		value: func(mps *MultiprocessingSystem.MultiprocessingSystem) float64 {
+			return float64(mps.Interconnect.Counters().MemoryReads)
+		}},
+	{name: "simulator_memory_writes_total", kind: "counter", help: "Escrituras en la Main Memory.",
+		value: func(mps *MultiprocessingSystem.MultiprocessingSystem) float64 {
+			return float64(mps.Interconnect.Counters().MemoryWrites)
+		}},
+	{name: "simulator_power_consumption", kind: "gauge", help: "Consumo acumulado del Interconnect.",
+		value: func(mps *MultiprocessingSystem.MultiprocessingSystem) float64 {
+			return mps.Interconnect.Counters().PowerConsumption
+		}},
+	{name: "simulator_finished", kind: "gauge", help: "1 cuando todos los PEs terminaron su programa.",
+		value: func(mps *MultiprocessingSystem.MultiprocessingSystem) float64 {
+			if mps.AreWeFinished() {
+				return 1
+			}
+			return 0
+		}},
+}
+
+// Handler de las métricas en el formato de texto de Prometheus. Los contadores de las sesiones se leen
+// sin esperar a un run en curso, cada CC y el Interconnect los entregan con su propio mutex.
+func GetPrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	systems := []labeledSystem{}
+	mutex.Lock()
+	if mps != nil {
+		systems = append(systems, labeledSystem{defaultSession, mps})
+	}
+	mutex.Unlock()
+	for _, s := range sessions.list() {
+		systems = append(systems, labeledSystem{s.ID, s.system})
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprintf(w, "# HELP simulator_systems Sistemas en el servidor.\n# TYPE simulator_systems gauge\n")
+	writeSample(w, "simulator_systems", nil, float64(len(systems)))
+	for _, metric := range systemMetrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, labeled := range systems {
+			labels := [][2]string{{"session", labeled.session}, {"protocol", labeled.system.Config.Protocol}}
+			if metric.cache == nil {
+				writeSample(w, metric.name, labels, metric.value(labeled.system))
+				continue
+			}
+			for id, cc := range labeled.system.CacheControllers {
+				writeSample(w, metric.name, append(labels, [2]string{"core", strconv.Itoa(id)}), metric.cache(cc))
+			}
+		}
+	}
+	requestLatency.write(w)
+}
+
+// Caracteres que se escapan en los valores de las etiquetas
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Función para escribir una muestra con sus etiquetas
+func writeSample(w io.Writer, name string, labels [][2]string, value float64) {
+	parts := []string{}
+	for _, label := range labels {
+		parts = append(parts, label[0]+`="`+labelEscaper.Replace(label[1])+`"`)
+	}
+	if len(parts) > 0 {
+		name += "{" + strings.Join(parts, ",") + "}"
+	}
+	fmt.Fprintf(w, "%s %s\n", name, formatValue(value))
+}
+
+// Función para escribir un valor como lo espera Prometheus
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
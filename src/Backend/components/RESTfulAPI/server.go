@@ -0,0 +1,135 @@
+package restfulapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"Backend/components/ProgramLibrary"
+	"Backend/utils"
+)
+
+var (
+	// Configuración del servidor en marcha, la usan el enrutador y los handlers del frontend
+	settings = DefaultServerConfig()
+
+	// Servidor al que se le pide apagarse desde la ruta /close o desde la consola
+	serverMutex sync.Mutex
+	running     *Server
+)
+
+// Servidor HTTP de la API
+type Server struct {
+	Config    ServerConfig
+	http      *http.Server
+	done      chan struct{} // Se cierra cuando se pide apagar el servidor
+	closeOnce sync.Once
+}
+
+// NewServer prepares a server with its configuration, the routes use its origins and data directories.
+func NewServer(cfg ServerConfig) (*Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	settings = cfg
+	library = programLibrary.New(cfg.LibraryDirectory)
+	server := &Server{
+		Config: cfg,
+		http: &http.Server{
+			Addr:              cfg.Address,
+			Handler:           Router(),
+			ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout),
+			ReadTimeout:       time.Duration(cfg.ReadTimeout),
+			WriteTimeout:      time.Duration(cfg.WriteTimeout),
+			IdleTimeout:       time.Duration(cfg.IdleTimeout),
+		},
+		done: make(chan struct{}),
+	}
+	serverMutex.Lock()
+	running = server
+	serverMutex.Unlock()
+	return server, nil
+}
+
+// Función para atender las peticiones de un listener hasta que el servidor se apague, entonces devuelve nil
+func (server *Server) Serve(listener net.Listener) error {
+	var err error
+	if server.Config.TLSCertFile != "" {
+		err = server.http.ServeTLS(listener, server.Config.TLSCertFile, server.Config.TLSKeyFile)
+	} else {
+		err = server.http.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Función para obtener el canal que se cierra cuando se pide apagar el servidor
+func (server *Server) Done() <-chan struct{} {
+	return server.done
+}
+
+// Función para pedir que el servidor se apague, quien lo inició llama a Shutdown
+func (server *Server) Close() {
+	server.closeOnce.Do(func() { close(server.done) })
+}
+
+// Función para apagar el servidor: deja de aceptar conexiones, espera las peticiones en curso y
+// detiene las simulaciones, que escriben sus logs al cerrarse. Con ctx vencido no espera más.
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.Close()
+	err := server.http.Shutdown(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		stopSimulations()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		if err == nil {
+			err = fmt.Errorf("las simulaciones no se detuvieron a tiempo: %w", ctx.Err())
+		}
+	}
+	return err
+}
+
+// Función para detener el sistema del frontend y las sesiones, los streams de eventos terminan con ellos
+func stopSimulations() {
+	mutex.Lock()
+	if mps != nil {
+		mps.Stop()
+	}
+	events.Close()
+	events = utils.NewEventStream(eventHistory)
+	mutex.Unlock()
+
+	for _, s := range sessions.list() {
+		if sessions.remove(s.ID) != nil {
+			s.stop()
+		}
+	}
+}
+
+// Handler para cerrar el servidor por http
+func CloseServer(w http.ResponseWriter, r *http.Request) {
+	// La respuesta se envía antes de apagar, Shutdown espera a esta petición
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "Server is closing...")
+	Close()
+}
+
+// Handler para cerrar el servidor por terminal
+func Close() {
+	serverMutex.Lock()
+	defer serverMutex.Unlock()
+	if running != nil {
+		fmt.Println("Server is closing...")
+		running.Close()
+	}
+}
@@ -0,0 +1,114 @@
+package restfulapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Sistemas que pueden existir a la vez en el servidor
+const maxSessions = 32
+
+// Simulación aislada de la API v1, cada una con su sistema, sus eventos y sus logs
+type session struct {
+	mutex        sync.Mutex // Lo toma cada petición de la sesión, las sesiones corren en paralelo
+	ID           string
+	Created      time.Time
+	Seed         int64
+	system       *MultiprocessingSystem.MultiprocessingSystem
+	generator    *mathrand.Rand // Planificador de run, con la semilla de la sesión
+	events       *utils.EventStream
+	logDirectory string
+}
+
+// Sesiones del servidor por identificador
+type sessionStore struct {
+	mutex sync.Mutex
+	items map[string]*session
+}
+
+var sessions = &sessionStore{items: map[string]*session{}}
+
+// Función para iniciar una sesión con un sistema nuevo, sus logs van a una carpeta temporal propia
+func newSession(cfg MultiprocessingSystem.Config, seed int64) (*session, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, err
+	}
+	logDirectory, err := os.MkdirTemp("", "session-logs")
+	if err != nil {
+		return nil, err
+	}
+	s := &session{
+		ID:           hex.EncodeToString(bytes),
+		Created:      time.Now(),
+		Seed:         seed,
+		generator:    mathrand.New(mathrand.NewSource(seed)),
+		events:       utils.NewEventStream(eventHistory),
+		logDirectory: logDirectory,
+	}
+	cfg.LogDirectory = logDirectory
+	cfg.Events = s.events
+	s.system, err = MultiprocessingSystem.StartWithConfig(cfg)
+	if err != nil {
+		os.RemoveAll(logDirectory)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Función para detener el sistema de una sesión, cerrar sus streams y borrar sus logs
+func (s *session) stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.system.Stop()
+	s.events.Close()
+	os.RemoveAll(s.logDirectory)
+}
+
+// Función para guardar una sesión, falla cuando ya hay demasiadas
+func (store *sessionStore) add(s *session) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if len(store.items) >= maxSessions {
+		return fmt.Errorf("Ya hay %d sistemas en el servidor, elimine alguno", maxSessions)
+	}
+	store.items[s.ID] = s
+	return nil
+}
+
+// Función para obtener una sesión, nil si no existe
+func (store *sessionStore) get(id string) *session {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.items[id]
+}
+
+// Función para quitar una sesión, nil si no existe
+func (store *sessionStore) remove(id string) *session {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	s := store.items[id]
+	delete(store.items, id)
+	return s
+}
+
+// Función para obtener las sesiones en orden de creación
+func (store *sessionStore) list() []*session {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	list := []*session{}
+	for _, s := range store.items {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	return list
+}
@@ -0,0 +1,385 @@
+package restfulapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"Backend/components/Comparison"
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Pasos máximos de un run de la API v1 cuando no se indican
+const defaultRunSteps = 10000
+
+// Función para registrar las rutas de la API v1, cada sistema es un recurso con su propia sesión
+func registerV1(router *mux.Router) {
+	v1 := router.PathPrefix("/v1").Subrouter()
+	v1.HandleFunc("/systems", ListSystems).Methods("GET")
+	v1.HandleFunc("/systems", CreateSystem).Methods("POST")
+	v1.HandleFunc("/systems/{id}", withSession(getSystem)).Methods("GET")
+	v1.HandleFunc("/systems/{id}", DeleteSystem).Methods("DELETE")
+	v1.HandleFunc("/systems/{id}/run", withSession(runSystem)).Methods("POST")
+	v1.HandleFunc("/systems/{id}/execution", withSession(getExecution)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/execution", withSession(controlExecution)).Methods("POST")
+	v1.HandleFunc("/systems/{id}/breakpoints", withSession(getSystemBreakpoints)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/breakpoints", withSession(addSystemBreakpoint)).Methods("POST")
+	v1.HandleFunc("/systems/{id}/breakpoints/{breakpoint}", withSession(deleteSystemBreakpoint)).Methods("DELETE")
+	v1.HandleFunc("/systems/{id}/checkpoint", withSession(getSystemCheckpoint)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/history", withSession(getSystemHistory)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/invariants", withSession(getSystemInvariants)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/history/diff", withSession(getSystemHistoryDiff)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/history/{step}", withSession(getSystemHistoryState)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/history/{step}/rewind", withSession(rewindSystemHistory)).Methods("POST")
+	v1.HandleFunc("/systems/{id}/pes", withSession(getProcessingElements)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/pes/{pe}", withSession(getProcessingElement)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/pes/{pe}/step", withSession(stepProcessingElement)).Methods("POST")
+	v1.HandleFunc("/systems/{id}/pes/{pe}/drain", withSession(drainProcessingElement)).Methods("POST")
+	v1.HandleFunc("/systems/{id}/caches", withSession(getCaches)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/caches/{cc}", withSession(getCache)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/memory", withSession(getMemory)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/bus", withSession(getBus)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/metrics", withSession(getSystemMetrics)).Methods("GET")
+	v1.HandleFunc("/systems/{id}/events", GetSystemEvents).Methods("GET")
+}
+
+// Sistema pedido al crear un recurso, los campos que no se envían usan la configuración por defecto
+type systemRequest struct {
+	Protocol        string           `json:"protocol"`
+	Consistency     string           `json:"consistency"`
+	Cores           int              `json:"cores"`
+	CacheLines      int              `json:"cacheLines"`
+	CacheWays       int              `json:"cacheWays"`
+	Programs        []string         `json:"programs"`        // Programa guardado de cada PE, "" usa uno generado
+	Sources         []string         `json:"sources"`         // Código de cada PE, reemplaza al programa guardado
+	Workload        *workloadRequest `json:"workload"`        // Patrón de los programas generados
+	Memory          []uint32         `json:"memory"`          // Memoria inicial, aleatoria si no se envía
+	Seed            int64            `json:"seed"`            // Semilla de los programas generados, la memoria y run
+	CheckInvariants bool             `json:"checkInvariants"` // Comprobar los invariantes de coherencia tras cada acceso
+
+	// Sistema guardado con la ruta checkpoint, reemplaza al resto de campos salvo la semilla de run
+	Checkpoint *MultiprocessingSystem.Checkpoint `json:"checkpoint"`
+}
+
+// Recurso de un sistema
+type systemResource struct {
+	ID          string               `json:"ID"`
+	Created     time.Time            `json:"Created"`
+	Protocol    string               `json:"Protocol"`
+	Consistency string               `json:"Consistency"`
+	Cores       int                  `json:"Cores"`
+	CacheLines  int                  `json:"CacheLines"`
+	CacheWays   int                  `json:"CacheWays"`
+	Seed        int64                `json:"Seed"`
+	Finished    bool                 `json:"Finished"` // Todos los PEs terminaron su programa
+	Programs    [][]string           `json:"Programs"`
+	Execution   utils.ExecutionState `json:"Execution"` // Ejecución libre iniciada con la ruta execution
+}
+
+// Función para describir el sistema de una sesión
+func (s *session) resource() systemResource {
+	cfg := s.system.Config
+	lines, ways := cfg.CacheGeometry()
+	return systemResource{
+		ID:          s.ID,
+		Created:     s.Created,
+		Protocol:    cfg.Protocol,
+		Consistency: cfg.Consistency,
+		Cores:       cfg.Cores,
+		CacheLines:  lines,
+		CacheWays:   ways,
+		Seed:        s.Seed,
+		Finished:    s.system.AreWeFinished(),
+		Programs:    cfg.Programs,
+		Execution:   s.system.Execution(),
+	}
+}
+
+// Función para responder con JSON
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// Función para pasar a un handler la sesión de la ruta, con su mutex tomado
+func withSession(handler func(w http.ResponseWriter, r *http.Request, s *session)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := sessions.get(mux.Vars(r)["id"])
+		if s == nil {
+			writeError(w, http.StatusNotFound, errorNotFound, "No existe el sistema "+mux.Vars(r)["id"])
+			return
+		}
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		handler(w, r, s)
+	}
+}
+
+// Función para leer un índice de la ruta entre 0 y limit-1
+func routeIndex(w http.ResponseWriter, r *http.Request, name string, limit int) (int, bool) {
+	value, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil || value < 0 || value >= limit {
+		writeError(w, http.StatusNotFound, errorNotFound, fmt.Sprintf("No existe %s %s, use 0 a %d", strings.ToUpper(name), mux.Vars(r)[name], limit-1))
+		return 0, false
+	}
+	return value, true
+}
+
+// Handler para listar los sistemas
+func ListSystems(w http.ResponseWriter, r *http.Request) {
+	resources := []systemResource{}
+	for _, s := range sessions.list() {
+		s.mutex.Lock()
+		resources = append(resources, s.resource())
+		s.mutex.Unlock()
+	}
+	writeJSON(w, http.StatusOK, resources)
+}
+
+// Handler para crear un sistema en una sesión nueva
+func CreateSystem(w http.ResponseWriter, r *http.Request) {
+	request := systemRequest{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido: "+err.Error())
+		return
+	}
+	if request.Checkpoint != nil {
+		restoreSystem(w, request.Checkpoint, request.Seed)
+		return
+	}
+
+	cfg := MultiprocessingSystem.DefaultConfig()
+	if request.Protocol != "" {
+		cfg.Protocol = strings.ToUpper(request.Protocol)
+	}
+	if request.Consistency != "" {
+		cfg.Consistency = strings.ToUpper(request.Consistency)
+	}
+	if request.Cores != 0 {
+		cfg.Cores = request.Cores
+	}
+	cfg.CacheLines = request.CacheLines
+	cfg.CacheWays = request.CacheWays
+	if request.Workload != nil {
+		cfg.Workload = request.Workload.options()
+	}
+	cfg.InitialMemory = request.Memory
+	cfg.CheckInvariants = request.CheckInvariants
+	if err := cfg.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+		return
+	}
+
+	// El código enviado tiene prioridad sobre los programas guardados
+	if err := assignPrograms(&cfg, request.Programs); err != nil {
+		writeLibraryError(w, err)
+		return
+	}
+	if len(request.Sources) > cfg.Cores {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, fmt.Sprintf("Se enviaron %d programas para %d PEs", len(request.Sources), cfg.Cores))
+		return
+	}
+	for id, source := range request.Sources {
+		if source == "" {
+			continue
+		}
+		if cfg.Programs == nil {
+			cfg.Programs = make([][]string, cfg.Cores)
+			cfg.ProgramNames = make([]string, cfg.Cores)
+		}
+		cfg.Programs[id] = strings.Split(source, "\n")
+		cfg.ProgramNames[id] = fmt.Sprintf("source%d", id)
+	}
+
+	// Las sesiones nunca usan los archivos de generated-programs, que comparten todas
+	cfg, err := comparison.Prepare(cfg, request.Seed)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorInvalidRequest, err.Error())
+		return
+	}
+	startSession(w, cfg, request.Seed)
+}
+
+// Función para iniciar el sistema de una sesión nueva y responder con su recurso
+func startSession(w http.ResponseWriter, cfg MultiprocessingSystem.Config, seed int64) {
+	s, err := newSession(cfg, seed)
+	if err != nil {
+		writeProgramError(w, err)
+		return
+	}
+	if err := sessions.add(s); err != nil {
+		s.stop()
+		writeError(w, http.StatusTooManyRequests, errorTooManySystems, err.Error())
+		return
+	}
+	s.events.Publish(utils.EventSnapshot, s.system.StateSnapshot())
+
+	w.Header().Set("Location", "/v1/systems/"+s.ID)
+	writeJSON(w, http.StatusCreated, s.resource())
+}
+
+// Handler para obtener un sistema
+func getSystem(w http.ResponseWriter, r *http.Request, s *session) {
+	writeJSON(w, http.StatusOK, s.resource())
+}
+
+// Handler para eliminar un sistema y detener su sesión
+func DeleteSystem(w http.ResponseWriter, r *http.Request) {
+	s := sessions.remove(mux.Vars(r)["id"])
+	if s == nil {
+		writeError(w, http.StatusNotFound, errorNotFound, "No existe el sistema "+mux.Vars(r)["id"])
+		return
+	}
+	s.stop()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler para ejecutar el sistema con el planificador de la sesión hasta que termine o hasta maxSteps pasos
+func runSystem(w http.ResponseWriter, r *http.Request, s *session) {
+	request := struct {
+		MaxSteps int `json:"maxSteps"`
+	}{MaxSteps: defaultRunSteps}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+			return
+		}
+		if request.MaxSteps < 1 {
+			writeError(w, http.StatusBadRequest, errorValidation, "maxSteps debe ser positivo")
+			return
+		}
+	}
+	if s.system.Running() {
+		writeError(w, http.StatusConflict, errorConflict, "El sistema está en ejecución, páusela primero")
+		return
+	}
+	steps := 0
+	for ; steps < request.MaxSteps; steps++ {
+		busy, err := s.system.StepRandom(s.generator)
+		if err != nil && s.system.Violation() != nil {
+			writeError(w, http.StatusConflict, errorConflict, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, errorInternal, err.Error())
+			return
+		}
+		if !busy {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Steps    int  `json:"Steps"`
+		Finished bool `json:"Finished"`
+	}{steps, s.system.AreWeFinished()})
+}
+
+// Handler para listar los PEs
+func getProcessingElements(w http.ResponseWriter, r *http.Request, s *session) {
+	writeJSON(w, http.StatusOK, s.system.StateSnapshot().PEs)
+}
+
+// Handler para obtener un PE
+func getProcessingElement(w http.ResponseWriter, r *http.Request, s *session) {
+	if pe, ok := routeIndex(w, r, "pe", len(s.system.ProcessingElements)); ok {
+		writeJSON(w, http.StatusOK, s.system.StateSnapshot().PEs[pe])
+	}
+}
+
+// Handler para ejecutar la siguiente instrucción de un PE, responde con el PE cuando termina
+func stepProcessingElement(w http.ResponseWriter, r *http.Request, s *session) {
+	pe, ok := routeIndex(w, r, "pe", len(s.system.ProcessingElements))
+	if !ok {
+		return
+	}
+	if err := s.system.StepAndWait(pe); err != nil {
+		writeError(w, http.StatusConflict, errorConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.system.StateSnapshot().PEs[pe])
+}
+
+// Handler para vaciar un store del store buffer de un PE, el primero si no se indica
+func drainProcessingElement(w http.ResponseWriter, r *http.Request, s *session) {
+	pe, ok := routeIndex(w, r, "pe", len(s.system.ProcessingElements))
+	if !ok {
+		return
+	}
+	request := struct {
+		Entry int `json:"entry"`
+	}{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, errorInvalidJSON, "JSON no válido")
+			return
+		}
+	}
+	if request.Entry < 0 || request.Entry >= len(s.system.ProcessingElements[pe].StoreBuffer) {
+		writeError(w, http.StatusConflict, errorConflict, fmt.Sprintf("El PE%d no tiene el store %d", pe, request.Entry))
+		return
+	}
+	if err := s.system.DrainAndWait(pe, request.Entry); err != nil {
+		writeError(w, http.StatusConflict, errorConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.system.StateSnapshot().PEs[pe])
+}
+
+// Handler para listar las cachés
+func getCaches(w http.ResponseWriter, r *http.Request, s *session) {
+	writeJSON(w, http.StatusOK, s.system.StateSnapshot().CCs)
+}
+
+// Handler para obtener una caché
+func getCache(w http.ResponseWriter, r *http.Request, s *session) {
+	if cc, ok := routeIndex(w, r, "cc", len(s.system.CacheControllers)); ok {
+		writeJSON(w, http.StatusOK, s.system.StateSnapshot().CCs[cc])
+	}
+}
+
+// Handler para obtener la Main Memory y el valor de cada dirección que ven los procesadores
+func getMemory(w http.ResponseWriter, r *http.Request, s *session) {
+	memory := struct {
+		MainMemory []int `json:"MainMemory"`
+		Coherent   []int `json:"Coherent"`
+	}{}
+	for address := 0; address < MultiprocessingSystem.MemorySize; address++ {
+		memory.MainMemory = append(memory.MainMemory, int(s.system.MainMemory.Data[address]))
+		memory.Coherent = append(memory.Coherent, s.system.CoherentValue(address))
+	}
+	writeJSON(w, http.StatusOK, memory)
+}
+
+// Handler para obtener las transacciones del bus (formatos json, text o binary)
+func getBus(w http.ResponseWriter, r *http.Request, s *session) {
+	_, transactions := s.system.Recorder.Snapshot()
+	if r.URL.Query().Get("format") == "" {
+		writeJSON(w, http.StatusOK, transactions)
+		return
+	}
+	writeBusTrace(w, r, transactions)
+}
+
+// Handler para obtener las métricas del sistema
+func getSystemMetrics(w http.ResponseWriter, r *http.Request, s *session) {
+	writeJSON(w, http.StatusOK, s.system.Results())
+}
+
+// Handler del stream de eventos de un sistema por WebSocket
+func GetSystemEvents(w http.ResponseWriter, r *http.Request) {
+	s := sessions.get(mux.Vars(r)["id"])
+	if s == nil {
+		writeError(w, http.StatusNotFound, errorNotFound, "No existe el sistema "+mux.Vars(r)["id"])
+		return
+	}
+	serveEvents(w, r, s.events, &s.mutex, func() *MultiprocessingSystem.MultiprocessingSystem { return s.system })
+}
@@ -0,0 +1,314 @@
+package sweep
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"Backend/components/Comparison"
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Values of every parameter, every combination is run once for every seed
+type Parameters struct {
+	Cores      []int    `json:"cores"`
+	CacheLines []int    `json:"cacheLines"`
+	CacheWays  []int    `json:"cacheWays"` // Associativity, 0 is fully associative and the values that don't divide the lines are skipped
+	Protocols  []string `json:"protocols"`
+	Presets    []string `json:"presets"` // Workload presets of the generated programs
+	Seeds      []int64  `json:"seeds"`   // Seeds of the programs, the initial memory and the scheduler
+}
+
+// Options of a sweep
+type Options struct {
+	Base         MultiprocessingSystem.Config // Consistency, latencies and initial memory shared by every run
+	Parameters   Parameters
+	Instructions int // Instructions of every generated program
+	MaxSteps     int // Scheduler steps before a run is abandoned
+	Parallel     int // Runs at the same time, 1 runs them one after another
+}
+
+// Function to get a sweep of MESI and MOESI with the default system and three seeds
+func DefaultOptions() Options {
+	return Options{
+		Base: MultiprocessingSystem.DefaultConfig(),
+		Parameters: Parameters{
+			Cores:      []int{3},
+			CacheLines: []int{4},
+			CacheWays:  []int{4},
+			Protocols:  []string{"MESI", "MOESI"},
+			Presets:    []string{"uniform"},
+			Seeds:      []int64{1, 2, 3},
+		},
+		Instructions: 8,
+		MaxSteps:     10000,
+		Parallel:     1,
+	}
+}
+
+// ParseRange reads a list of integers written as "2,4,8", "1-4" or "1-8:2" (from 1 to 8 in steps of 2),
+// the forms can be mixed: "1-3,8".
+func ParseRange(text string) ([]int, error) {
+	values := []int{}
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		bounds, stepText, hasStep := strings.Cut(part, ":")
+		first, last, isRange := strings.Cut(bounds, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", part)
+		}
+		if !isRange {
+			if hasStep {
+				return nil, fmt.Errorf("invalid range %q, a step needs a start and an end", part)
+			}
+			values = append(values, start)
+			continue
+		}
+		end, err := strconv.Atoi(last)
+		if err != nil || end < start {
+			return nil, fmt.Errorf("invalid range %q", part)
+		}
+		step := 1
+		if hasStep {
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+		for value := start; value <= end; value += step {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// Combination of parameters, without the seed
+type Point struct {
+	Cores      int    `json:"Cores"`
+	CacheLines int    `json:"CacheLines"`
+	CacheWays  int    `json:"CacheWays"`
+	Protocol   string `json:"Protocol"`
+	Preset     string `json:"Preset"`
+}
+
+// Result of one combination with one seed
+type Sample struct {
+	Point
+	Seed    int64              `json:"Seed"`
+	Metrics comparison.Metrics `json:"Metrics"`
+	Error   string             `json:"Error,omitempty"` // Why the run failed, its metrics are not aggregated
+}
+
+// Mean and sample standard deviation of a metric across the seeds
+type Statistic struct {
+	Mean   float64 `json:"Mean"`
+	StdDev float64 `json:"StdDev"`
+}
+
+// Aggregated results of a combination
+type Result struct {
+	Point
+	Runs     int                  `json:"Runs"`     // Runs that finished
+	Failures int                  `json:"Failures"` // Runs that failed
+	Metrics  map[string]Statistic `json:"Metrics"`
+}
+
+// Results of every run and of every combination
+type Report struct {
+	Parameters Parameters `json:"Parameters"`
+	Runs       []Sample   `json:"Runs"`
+	Results    []Result   `json:"Results"`
+}
+
+// Function to get every valid combination of the parameters
+func (parameters Parameters) points() []Point {
+	points := []Point{}
+	for _, cores := range parameters.Cores {
+		for _, lines := range parameters.CacheLines {
+			for _, ways := range parameters.CacheWays {
+				if ways == 0 {
+					ways = lines
+				}
+				if ways < 1 || lines%ways != 0 {
+					continue
+				}
+				for _, protocol := range parameters.Protocols {
+					for _, preset := range parameters.Presets {
+						points = append(points, Point{Cores: cores, CacheLines: lines, CacheWays: ways, Protocol: protocol, Preset: preset})
+					}
+				}
+			}
+		}
+	}
+	return points
+}
+
+// Function to get the comparison that runs a combination with a seed
+func (options Options) comparison(point Point, seed int64) comparison.Options {
+	base := options.Base
+	base.Cores = point.Cores
+	base.CacheLines = point.CacheLines
+	base.CacheWays = point.CacheWays
+	base.Protocol = point.Protocol
+	base.Programs, base.ProgramNames, base.Traces = nil, nil, nil
+	workload := utils.DefaultWorkloadOptions(point.Preset)
+	workload.Instructions = options.Instructions
+	base.Workload = &workload
+	return comparison.Options{
+		Base:     base,
+		Variants: []comparison.Variant{{Name: point.Protocol, Protocol: point.Protocol, Consistency: base.Consistency}},
+		Seed:     seed,
+		MaxSteps: options.MaxSteps,
+	}
+}
+
+// Run executes every combination of the parameters with every seed and aggregates the metrics of the seeds.
+// An invalid combination stops the sweep before any run, a run that fails is reported and not aggregated.
+func Run(options Options) (*Report, error) {
+	points := options.Parameters.points()
+	if len(points) == 0 || len(options.Parameters.Seeds) == 0 {
+		return nil, fmt.Errorf("the parameters don't give any combination to run")
+	}
+	for _, point := range points {
+		if err := options.comparison(point, 0).Base.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %v", point.Label(), err)
+		}
+	}
+	if options.Parallel < 1 {
+		options.Parallel = 1
+	}
+
+	// Every run writes its own position, so the order doesn't depend on the workers
+	report := &Report{Parameters: options.Parameters, Runs: make([]Sample, len(points)*len(options.Parameters.Seeds))}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < options.Parallel; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				point := points[index/len(options.Parameters.Seeds)]
+				seed := options.Parameters.Seeds[index%len(options.Parameters.Seeds)]
+				run := Sample{Point: point, Seed: seed}
+				result, err := comparison.Compare(options.comparison(point, seed))
+				if err != nil {
+					run.Error = err.Error()
+				} else {
+					run.Metrics = result.Rows[0].Metrics
+				}
+				report.Runs[index] = run
+			}
+		}()
+	}
+	for index := range report.Runs {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, point := range points {
+		report.Results = append(report.Results, aggregate(point, report.Runs[i*len(options.Parameters.Seeds):(i+1)*len(options.Parameters.Seeds)]))
+	}
+	return report, nil
+}
+
+// Function to get the mean and standard deviation of every metric of the runs that finished
+func aggregate(point Point, runs []Sample) Result {
+	result := Result{Point: point, Metrics: map[string]Statistic{}}
+	values := map[string][]float64{}
+	for _, run := range runs {
+		if run.Error != "" {
+			result.Failures++
+			continue
+		}
+		result.Runs++
+		for name, value := range run.Metrics.Map() {
+			values[name] = append(values[name], value)
+		}
+	}
+	for _, name := range comparison.MetricNames() {
+		result.Metrics[name] = statistic(values[name])
+	}
+	return result
+}
+
+// Function to get the mean and the sample standard deviation of some values, 0 when they can't be computed
+func statistic(values []float64) Statistic {
+	if len(values) == 0 {
+		return Statistic{}
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return Statistic{Mean: mean}
+	}
+	squares := 0.0
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return Statistic{Mean: mean, StdDev: math.Sqrt(squares / float64(len(values)-1))}
+}
+
+// Function to write a combination as text
+func (point Point) Label() string {
+	return fmt.Sprintf("%d cores, %d lines, %d ways, %s, %s", point.Cores, point.CacheLines, point.CacheWays, point.Protocol, point.Preset)
+}
+
+// Function to get the columns of a combination
+func (point Point) columns() []string {
+	return []string{strconv.Itoa(point.Cores), strconv.Itoa(point.CacheLines), strconv.Itoa(point.CacheWays), point.Protocol, point.Preset}
+}
+
+// Names of the columns of a combination
+var pointHeader = []string{"cores", "cacheLines", "cacheWays", "protocol", "preset"}
+
+// Function to write a number without useless decimals
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// WriteCSV writes the aggregated results, one row per combination with the mean and the standard
+// deviation of every metric.
+func (report *Report) WriteCSV(writer io.Writer) error {
+	table := csv.NewWriter(writer)
+	header := append(append([]string{}, pointHeader...), "runs", "failures")
+	for _, name := range comparison.MetricNames() {
+		header = append(header, name+"Mean", name+"StdDev")
+	}
+	table.Write(header)
+	for _, result := range report.Results {
+		row := append(result.columns(), strconv.Itoa(result.Runs), strconv.Itoa(result.Failures))
+		for _, name := range comparison.MetricNames() {
+			row = append(row, formatNumber(result.Metrics[name].Mean), formatNumber(result.Metrics[name].StdDev))
+		}
+		table.Write(row)
+	}
+	table.Flush()
+	return table.Error()
+}
+
+// WriteRunsCSV writes every run, one row per combination and seed.
+func (report *Report) WriteRunsCSV(writer io.Writer) error {
+	table := csv.NewWriter(writer)
+	header := append(append([]string{}, pointHeader...), "seed")
+	header = append(append(header, comparison.MetricNames()...), "error")
+	table.Write(header)
+	for _, run := range report.Runs {
+		row := append(run.columns(), strconv.FormatInt(run.Seed, 10))
+		values := run.Metrics.Map()
+		for _, name := range comparison.MetricNames() {
+			row = append(row, formatNumber(values[name]))
+		}
+		table.Write(append(row, run.Error))
+	}
+	table.Flush()
+	return table.Error()
+}
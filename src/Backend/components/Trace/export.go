@@ -0,0 +1,269 @@
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"Backend/utils"
+)
+
+// Header of the binary traces, followed by the version and the kind of trace
+const binaryMagic = "CCTR"
+
+// Version of the binary format
+const binaryVersion = 1
+
+// Kinds of binary trace
+const (
+	memoryKind = 'M'
+	busKind    = 'B'
+)
+
+// Flags of a memory operation in the binary format
+const (
+	flagWrite     = 1 << iota // The operation stored a value
+	flagForwarded             // A READ served by the store buffer
+	flagLL                    // The operation was a LL
+	flagSC                    // The operation was a successful SC
+)
+
+// Codes of the bus transactions and actions in the binary format
+var busCodes = []string{"ReadRequest", "ReadExclusiveRequest", "DataResponse", "Invalidate", ""}
+
+// Function to write the memory operations in the text format accepted by ParseText.
+// Replaying the trace in the order of its lines gives the same final memory.
+func WriteMemoryText(writer io.Writer, operations []utils.MemoryOperation) error {
+	buffered := bufio.NewWriter(writer)
+	fmt.Fprintf(buffered, "# core R|W address value gap\n")
+	for _, operation := range operations {
+		kind := "R"
+		if operation.Write {
+			kind = "W"
+		}
+		fmt.Fprintf(buffered, "%d %s %d %d %d", operation.Core, kind, operation.Address, operation.Value, operation.Gap)
+		switch {
+		case operation.Forwarded:
+			fmt.Fprintf(buffered, " # forwarded from the store buffer")
+		case operation.Kind == "LL" || operation.Kind == "SC":
+			fmt.Fprintf(buffered, " # %s", operation.Kind)
+		}
+		fmt.Fprintf(buffered, "\n")
+	}
+	return buffered.Flush()
+}
+
+// Function to write the memory operations in the Dinero IV "din" format, "label address" with
+// label 0 for reads and 1 for writes. The addresses are byte addresses of 4 byte words.
+func WriteMemoryDinero(writer io.Writer, operations []utils.MemoryOperation) error {
+	buffered := bufio.NewWriter(writer)
+	for _, operation := range operations {
+		// The loads served by the store buffer never reached the cache
+		if operation.Forwarded {
+			continue
+		}
+		label := 0
+		if operation.Write {
+			label = 1
+		}
+		fmt.Fprintf(buffered, "%d %x\n", label, operation.Address*4)
+	}
+	return buffered.Flush()
+}
+
+// Function to write the bus transactions as text, one transaction per line
+func WriteBusText(writer io.Writer, transactions []utils.BusTransaction) error {
+	buffered := bufio.NewWriter(writer)
+	fmt.Fprintf(buffered, "# sequence cc transaction action address data remote-state new-state memory-reads memory-writes\n")
+	for _, transaction := range transactions {
+		fmt.Fprintf(buffered, "%d %d %s %s %d %d %s %s %d %d\n",
+			transaction.Sequence, transaction.Requester, transaction.Type, transaction.AR, transaction.Address,
+			transaction.Data, transaction.RemoteStatus, transaction.NewStatus, transaction.MemoryReads, transaction.MemoryWrites)
+	}
+	return buffered.Flush()
+}
+
+// Function to write the header of a binary trace
+func writeBinaryHeader(buffer *bytes.Buffer, kind byte, count int) {
+	buffer.WriteString(binaryMagic)
+	buffer.WriteByte(binaryVersion)
+	buffer.WriteByte(kind)
+	buffer.Write(binary.AppendUvarint(nil, uint64(count)))
+}
+
+// Function to read the header of a binary trace and get its number of records
+func readBinaryHeader(reader *bytes.Reader, kind byte) (int, error) {
+	header := make([]byte, len(binaryMagic)+2)
+	if _, err := io.ReadFull(reader, header); err != nil || string(header[:len(binaryMagic)]) != binaryMagic {
+		return 0, fmt.Errorf("not a binary trace")
+	}
+	if header[len(binaryMagic)] != binaryVersion {
+		return 0, fmt.Errorf("unsupported binary trace version %d", header[len(binaryMagic)])
+	}
+	if header[len(binaryMagic)+1] != kind {
+		return 0, fmt.Errorf("expected a trace of kind %c, got %c", kind, header[len(binaryMagic)+1])
+	}
+	count, err := binary.ReadUvarint(reader)
+	return int(count), err
+}
+
+// Function to write the memory operations in the compact binary format.
+// Every record is: core (uvarint), flags (byte), address (uvarint), value (varint), gap (uvarint).
+func WriteMemoryBinary(writer io.Writer, operations []utils.MemoryOperation) error {
+	buffer := &bytes.Buffer{}
+	writeBinaryHeader(buffer, memoryKind, len(operations))
+	for _, operation := range operations {
+		flags := byte(0)
+		if operation.Write {
+			flags |= flagWrite
+		}
+		if operation.Forwarded {
+			flags |= flagForwarded
+		}
+		switch operation.Kind {
+		case "LL":
+			flags |= flagLL
+		case "SC":
+			flags |= flagSC
+		}
+		record := binary.AppendUvarint(nil, uint64(operation.Core))
+		record = append(record, flags)
+		record = binary.AppendUvarint(record, uint64(operation.Address))
+		record = binary.AppendVarint(record, int64(operation.Value))
+		record = binary.AppendUvarint(record, uint64(operation.Gap))
+		buffer.Write(record)
+	}
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+// ReadMemoryBinary reads a binary memory trace, the records keep the order of the execution.
+func ReadMemoryBinary(data []byte) ([]Record, error) {
+	reader := bytes.NewReader(data)
+	count, err := readBinaryHeader(reader, memoryKind)
+	if err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	for i := 0; i < count; i++ {
+		core, err1 := binary.ReadUvarint(reader)
+		flags, err2 := reader.ReadByte()
+		address, err3 := binary.ReadUvarint(reader)
+		value, err4 := binary.ReadVarint(reader)
+		gap, err5 := binary.ReadUvarint(reader)
+		for _, err := range []error{err1, err2, err3, err4, err5} {
+			if err != nil {
+				return nil, fmt.Errorf("record %d: the trace is truncated", i)
+			}
+		}
+		records = append(records, Record{
+			Core:     int(core),
+			Write:    flags&flagWrite != 0,
+			Address:  address,
+			Value:    int(value),
+			HasValue: true,
+			Gap:      int(gap),
+		})
+	}
+	return records, nil
+}
+
+// Function to get the code of a bus transaction or action
+func busCode(name string) byte {
+	for code, known := range busCodes {
+		if known == name {
+			return byte(code)
+		}
+	}
+	return byte(len(busCodes) - 1)
+}
+
+// Function to get the state of a cache line as a single byte
+func stateByte(state string) byte {
+	if state == "" {
+		return '-'
+	}
+	return state[0]
+}
+
+// Function to write the bus transactions in the compact binary format. Every record is: requester (uvarint),
+// transaction (byte), action (byte), address (uvarint), data (varint), remote state (byte), new state (byte),
+// memory reads (uvarint) and memory writes (uvarint). The sequence is the position of the record.
+func WriteBusBinary(writer io.Writer, transactions []utils.BusTransaction) error {
+	buffer := &bytes.Buffer{}
+	writeBinaryHeader(buffer, busKind, len(transactions))
+	for _, transaction := range transactions {
+		record := binary.AppendUvarint(nil, uint64(transaction.Requester))
+		record = append(record, busCode(transaction.Type), busCode(transaction.AR))
+		record = binary.AppendUvarint(record, uint64(transaction.Address))
+		record = binary.AppendVarint(record, int64(transaction.Data))
+		record = append(record, stateByte(transaction.RemoteStatus), stateByte(transaction.NewStatus))
+		record = binary.AppendUvarint(record, uint64(transaction.MemoryReads))
+		record = binary.AppendUvarint(record, uint64(transaction.MemoryWrites))
+		buffer.Write(record)
+	}
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+// ReadBusBinary reads a binary bus trace.
+func ReadBusBinary(data []byte) ([]utils.BusTransaction, error) {
+	reader := bytes.NewReader(data)
+	count, err := readBinaryHeader(reader, busKind)
+	if err != nil {
+		return nil, err
+	}
+	state := func(value byte) string {
+		if value == '-' {
+			return ""
+		}
+		return string(value)
+	}
+	transactions := []utils.BusTransaction{}
+	for i := 0; i < count; i++ {
+		requester, err1 := binary.ReadUvarint(reader)
+		codes := make([]byte, 2)
+		_, err2 := io.ReadFull(reader, codes)
+		address, err3 := binary.ReadUvarint(reader)
+		data, err4 := binary.ReadVarint(reader)
+		states := make([]byte, 2)
+		_, err5 := io.ReadFull(reader, states)
+		reads, err6 := binary.ReadUvarint(reader)
+		writes, err7 := binary.ReadUvarint(reader)
+		for _, err := range []error{err1, err2, err3, err4, err5, err6, err7} {
+			if err != nil {
+				return nil, fmt.Errorf("record %d: the trace is truncated", i)
+			}
+		}
+		if int(codes[0]) >= len(busCodes) || int(codes[1]) >= len(busCodes) {
+			return nil, fmt.Errorf("record %d: unknown transaction code", i)
+		}
+		transactions = append(transactions, utils.BusTransaction{
+			Sequence:     i,
+			Requester:    int(requester),
+			Type:         busCodes[codes[0]],
+			AR:           busCodes[codes[1]],
+			Address:      int(address),
+			Data:         int(data),
+			RemoteStatus: state(states[0]),
+			NewStatus:    state(states[1]),
+			MemoryReads:  int(reads),
+			MemoryWrites: int(writes),
+		})
+	}
+	return transactions, nil
+}
+
+// Schedule gives the order in which the PEs must be stepped to replay a trace in the order of its records:
+// every record takes the idle steps of its gap and one step for the access.
+func Schedule(records []Record) []int {
+	steps := []int{}
+	for _, record := range records {
+		for i := 0; i <= record.Gap; i++ {
+			steps = append(steps, record.Core)
+		}
+	}
+	return steps
+}
@@ -0,0 +1,273 @@
+package trace
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"Backend/utils"
+)
+
+// Memory reference of a trace, before its address is folded into the simulated memory
+type Record struct {
+	Core     int    // Core that makes the access
+	Write    bool   // WRITE when true, READ otherwise
+	Address  uint64 // Address of the traced program
+	Value    int    // Value stored by a WRITE when HasValue is true
+	HasValue bool   // A WRITE without value stores the register of the PE
+	Gap      int    // Idle cycles of the core before the access
+	Line     int    // Line of the access in a text or lackey trace, 0 in a binary trace
+}
+
+// Trace given to the simulator, the text and binary traces have several cores and a lackey trace has one
+type Source struct {
+	Format string `json:"format"` // text, lackey or binary
+	Text   string `json:"source"` // Contents of the trace
+	Data   []byte `json:"data"`   // Contents of a binary trace, base64 in JSON
+	Core   int    `json:"core"`   // Core of a lackey trace, -1 spreads the accesses over every core in turns
+}
+
+// How the addresses of a trace are mapped to the words of the simulated memory
+type Mapping struct {
+	WordSize            int    `json:"wordSize"`            // Bytes per simulated word
+	Base                uint64 `json:"base"`                // Address subtracted before folding
+	Size                uint64 `json:"size"`                // Bytes of the traced region starting at Base, 0 accepts every address above Base
+	Fold                string `json:"fold"`                // modulo, xor or first-touch
+	IncludeInstructions bool   `json:"includeInstructions"` // Replay the instruction fetches of a lackey trace as READs
+}
+
+// Function to get the mapping used when a request doesn't give one
+func DefaultMapping() Mapping {
+	return Mapping{WordSize: 1, Fold: "modulo"}
+}
+
+// Function to check that a mapping can be applied
+func (mapping Mapping) Validate() error {
+	if mapping.WordSize <= 0 {
+		return fmt.Errorf("the word size must be positive")
+	}
+	if mapping.Fold != "modulo" && mapping.Fold != "xor" && mapping.Fold != "first-touch" {
+		return fmt.Errorf("unknown fold %q, expected modulo, xor or first-touch", mapping.Fold)
+	}
+	if mapping.Base+mapping.Size < mapping.Base {
+		return fmt.Errorf("the traced region overflows the addresses of 64 bits")
+	}
+	return nil
+}
+
+// ParseText reads a trace with one access per line:
+//
+//	core R|W address [value] [gap]
+//
+// The address and the value can be decimal or hexadecimal (0x). Lines starting with '#' are comments.
+func ParseText(reader io.Reader) ([]Record, error) {
+	records := []Record{}
+	scanner := bufio.NewScanner(reader)
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) < 3 || len(words) > 5 {
+			return nil, fmt.Errorf("line %d: expected 'core R|W address [value] [gap]'", number)
+		}
+
+		record := Record{Line: number}
+		core, err := strconv.Atoi(words[0])
+		if err != nil || core < 0 {
+			return nil, fmt.Errorf("line %d: invalid core %q", number, words[0])
+		}
+		record.Core = core
+		switch strings.ToUpper(words[1]) {
+		case "R":
+		case "W":
+			record.Write = true
+		default:
+			return nil, fmt.Errorf("line %d: invalid operation %q, expected R or W", number, words[1])
+		}
+		if record.Address, err = strconv.ParseUint(words[2], 0, 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid address %q", number, words[2])
+		}
+		if len(words) >= 4 && words[3] != "-" {
+			value, err := strconv.ParseInt(words[3], 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q", number, words[3])
+			}
+			record.Value, record.HasValue = int(value), true
+		}
+		if len(words) == 5 {
+			if record.Gap, err = strconv.Atoi(words[4]); err != nil || record.Gap < 0 {
+				return nil, fmt.Errorf("line %d: invalid gap %q", number, words[4])
+			}
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// ParseLackey reads a trace written by Valgrind's lackey tool (--trace-mem=yes):
+//
+//	I  0023C790,2
+//	 L BE80199C,4
+//	 S BE80199C,4
+//	 M 0025747C,1
+//
+// A modify (M) is a READ followed by a WRITE. The instruction fetches (I) are skipped unless includeInstructions is set.
+func ParseLackey(reader io.Reader, core int, includeInstructions bool) ([]Record, error) {
+	records := []Record{}
+	scanner := bufio.NewScanner(reader)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		// Skip the blank lines and the messages of Valgrind (==pid==)
+		if line == "" || strings.HasPrefix(line, "==") || strings.HasPrefix(line, "--") {
+			continue
+		}
+		words := strings.Fields(line)
+		if len(words) != 2 {
+			return nil, fmt.Errorf("line %d: expected 'kind address,size'", number)
+		}
+		addressText, _, _ := strings.Cut(words[1], ",")
+		address, err := strconv.ParseUint(addressText, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid address %q", number, addressText)
+		}
+		switch words[0] {
+		case "I":
+			if includeInstructions {
+				records = append(records, Record{Core: core, Address: address, Line: number})
+			}
+		case "L":
+			records = append(records, Record{Core: core, Address: address, Line: number})
+		case "S":
+			records = append(records, Record{Core: core, Address: address, Write: true, Line: number})
+		case "M":
+			records = append(records, Record{Core: core, Address: address, Line: number}, Record{Core: core, Address: address, Write: true, Line: number})
+		default:
+			return nil, fmt.Errorf("line %d: unknown access kind %q", number, words[0])
+		}
+	}
+	return records, scanner.Err()
+}
+
+// Function to read a trace in any of the supported formats
+func Parse(source Source, mapping Mapping, cores int) ([]Record, error) {
+	switch source.Format {
+	case "text", "":
+		return ParseText(strings.NewReader(source.Text))
+	case "lackey":
+		records, err := ParseLackey(strings.NewReader(source.Text), source.Core, mapping.IncludeInstructions)
+		if err != nil {
+			return nil, err
+		}
+		// Spread a single thread over the cores to create sharing
+		if source.Core == -1 {
+			for i := range records {
+				records[i].Core = i % cores
+			}
+		}
+		return records, nil
+	case "binary":
+		if source.Data == nil {
+			return ReadMemoryBinary([]byte(source.Text))
+		}
+		return ReadMemoryBinary(source.Data)
+	}
+	return nil, fmt.Errorf("unknown trace format %q, expected text, lackey or binary", source.Format)
+}
+
+// State of the address folding, first-touch remembers the words already seen
+type folder struct {
+	mapping Mapping
+	touched map[uint64]int
+}
+
+// Function to get the simulated address of an address of the trace, the address must be inside the traced region
+func (f *folder) fold(address uint64) (int, error) {
+	if address < f.mapping.Base {
+		return 0, fmt.Errorf("the address %#x is below the base %#x", address, f.mapping.Base)
+	}
+	if f.mapping.Size != 0 && address-f.mapping.Base >= f.mapping.Size {
+		return 0, fmt.Errorf("the address %#x is beyond the traced region ending at %#x", address, f.mapping.Base+f.mapping.Size)
+	}
+	word := (address - f.mapping.Base) / uint64(f.mapping.WordSize)
+	switch f.mapping.Fold {
+	case "xor":
+		// Combine every group of bits of the word number
+		folded := uint64(0)
+		for ; word != 0; word /= utils.MemorySize {
+			folded ^= word % utils.MemorySize
+		}
+		return int(folded), nil
+	case "first-touch":
+		// Words get the simulated addresses in order of appearance
+		if _, found := f.touched[word]; !found {
+			f.touched[word] = len(f.touched) % utils.MemorySize
+		}
+		return f.touched[word], nil
+	}
+	return int(word % utils.MemorySize), nil
+}
+
+// Load reads the traces and turns them into the operations replayed by every PE:
+// "READ address", "WRITE address", "WRITE address value" and "IDLE" for every cycle of gap.
+func Load(sources []Source, mapping Mapping, cores int) ([][]string, error) {
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+	operations := make([][]string, cores)
+	for core := range operations {
+		operations[core] = []string{}
+	}
+	f := &folder{mapping: mapping, touched: map[uint64]int{}}
+	for i, source := range sources {
+		if source.Format == "lackey" && (source.Core < -1 || source.Core >= cores) {
+			return nil, fmt.Errorf("trace %d: the core %d doesn't exist, the system has %d cores", i, source.Core, cores)
+		}
+		records, err := Parse(source, mapping, cores)
+		if err != nil {
+			return nil, fmt.Errorf("trace %d: %v", i, err)
+		}
+		for index, record := range records {
+			if record.Core >= cores {
+				return nil, fmt.Errorf("trace %d: the core %d doesn't exist, the system has %d cores", i, record.Core, cores)
+			}
+			address, err := f.fold(record.Address)
+			if err != nil {
+				return nil, fmt.Errorf("trace %d: %s: %v", i, record.position(index), err)
+			}
+			operations[record.Core] = append(operations[record.Core], Operations(record, address)...)
+		}
+	}
+	return operations, nil
+}
+
+// Function to get where a record is in its trace, the line of a text or lackey trace or the position in a binary trace
+func (record Record) position(index int) string {
+	if record.Line == 0 {
+		return fmt.Sprintf("record %d", index)
+	}
+	return fmt.Sprintf("line %d", record.Line)
+}
+
+// Function to get the operations of a record once its address is folded
+func Operations(record Record, address int) []string {
+	operations := []string{}
+	for i := 0; i < record.Gap; i++ {
+		operations = append(operations, "IDLE")
+	}
+	switch {
+	case !record.Write:
+		operations = append(operations, fmt.Sprintf("READ %d", address))
+	case record.HasValue:
+		operations = append(operations, fmt.Sprintf("WRITE %d %d", address, record.Value))
+	default:
+		operations = append(operations, fmt.Sprintf("WRITE %d", address))
+	}
+	return operations
+}
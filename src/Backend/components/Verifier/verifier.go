@@ -0,0 +1,407 @@
+package verifier
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Accesses a processor can send to its cache, every one is tried from every state
+var operations = []string{"READ", "LL", "WRITE", "SC"}
+
+// Invariant reported when an access doesn't finish, the protocol left the bus or a cache waiting forever
+const InvariantDeadlock = "Deadlock"
+
+// Verdicts of a verification
+const (
+	VerdictVerified   = "verified"   // Every reachable state holds the invariants
+	VerdictViolation  = "violation"  // A reachable state breaks an invariant, the report has its counterexample
+	VerdictIncomplete = "incomplete" // The search stopped at the maximum number of states without a violation
+)
+
+// Options of a verification
+type Options struct {
+	Protocol  string        // Cache coherence protocol: MESI or MOESI
+	Caches    int           // Number of Cache Controllers
+	Blocks    int           // Addresses accessed by the processors, from 0
+	Lines     int           // Blocks of every cache, 0 holds every address so no block is replaced
+	Ways      int           // Associativity of the caches, 0 is fully associative
+	Values    int           // Values stored by the writes, from 0
+	MaxStates int           // States explored before the search gives up
+	Timeout   time.Duration // Time an access can take before it is reported as a deadlock
+}
+
+// Function to get the options used when a request leaves them empty
+func DefaultOptions() Options {
+	return Options{
+		Protocol:  "MESI",
+		Caches:    2,
+		Blocks:    2,
+		Values:    2,
+		MaxStates: 200000,
+		Timeout:   2 * time.Second,
+	}
+}
+
+// Access of a processor to its cache, the only event that changes a global state.
+// The bus transactions it causes run to completion before the next access, as in the simulator.
+type Event struct {
+	Cache   int    `json:"Cache"`
+	Kind    string `json:"Kind"` // READ, LL, WRITE or SC
+	Address int    `json:"Address"`
+	Value   int    `json:"Value"` // Value stored by a WRITE or a SC
+}
+
+// Function to write an event as text, like CC0 WRITE 1 = 2
+func (event Event) String() string {
+	if event.Kind == "WRITE" || event.Kind == "SC" {
+		return fmt.Sprintf("CC%d %s %d = %d", event.Cache, event.Kind, event.Address, event.Value)
+	}
+	return fmt.Sprintf("CC%d %s %d", event.Cache, event.Kind, event.Address)
+}
+
+// Contents of a cache in a global state
+type CacheState struct {
+	Blocks            utils.CacheObjectList `json:"Blocks"`
+	ReplacementQueues [][]int               `json:"ReplacementQueues"` // Blocks of every set from the next one to replace
+	LinkedAddress     int                   `json:"LinkedAddress"`
+}
+
+// Global state of the system between two accesses
+type State struct {
+	Caches []CacheState `json:"Caches"`
+	Memory []int        `json:"Memory"` // Words of the Main Memory accessed by the processors
+	Stored []int        `json:"Stored"` // Last value stored to every address, the value every copy must hold
+}
+
+// Function to get the text that identifies a state in the set of explored states
+func (state State) key() string {
+	return fmt.Sprint(state.Caches, state.Memory, state.Stored)
+}
+
+// Step of a counterexample
+type TraceStep struct {
+	Event        Event                  `json:"Event"`
+	Transactions []utils.BusTransaction `json:"Transactions"` // Bus transactions caused by the access
+	State        State                  `json:"State"`        // State after the access
+}
+
+// Shortest sequence of accesses from the initial state to a state that breaks the invariants
+type Counterexample struct {
+	Failures []utils.InvariantFailure `json:"Failures"`
+	Trace    []TraceStep              `json:"Trace"`
+}
+
+// Results of a verification
+type Report struct {
+	Protocol    string          `json:"Protocol"`
+	Caches      int             `json:"Caches"`
+	Blocks      int             `json:"Blocks"`
+	Lines       int             `json:"Lines"`
+	Ways        int             `json:"Ways"`
+	Values      int             `json:"Values"`
+	States      int             `json:"States"`      // Distinct states reached
+	Transitions int             `json:"Transitions"` // Accesses applied
+	Depth       int             `json:"Depth"`       // Accesses from the initial state to the farthest state
+	Initial     State           `json:"Initial"`
+	Violation   *Counterexample `json:"Violation"`
+	Verdict     string          `json:"Verdict"`
+}
+
+// State reached by the search with the access that reached it first
+type node struct {
+	state        State
+	parent       int
+	depth        int
+	event        Event
+	transactions []utils.BusTransaction
+}
+
+// Run explores, breadth first, every global state the protocol can reach from empty caches and a memory of zeros
+// by applying every access of every processor in every order. The accesses are served by the Cache Controllers
+// and the Interconnect of a simulated system, so the protocol verified is the one the simulator runs.
+// The first state that breaks an invariant, or an access that never finishes, ends the search with a counterexample.
+func Run(options Options) (*Report, error) {
+	if options.Lines == 0 {
+		options.Lines = options.Blocks
+	}
+	if options.Ways == 0 {
+		options.Ways = options.Lines
+	}
+	if options.Blocks < 1 || options.Blocks > MultiprocessingSystem.MemorySize {
+		return nil, fmt.Errorf("the number of blocks must be between 1 and %d", MultiprocessingSystem.MemorySize)
+	}
+	if options.Values < 1 {
+		return nil, fmt.Errorf("the number of values must be positive")
+	}
+	if options.MaxStates <= 0 {
+		return nil, fmt.Errorf("the maximum number of states must be positive")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultOptions().Timeout
+	}
+
+	// The log files of the system go to a temporary folder
+	logDirectory, err := os.MkdirTemp("", "verifier-logs")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(logDirectory)
+
+	// The PEs never run, the accesses are sent to the Cache Controllers by the verifier
+	cfg := MultiprocessingSystem.DefaultConfig()
+	cfg.Protocol = options.Protocol
+	cfg.Cores = options.Caches
+	cfg.CacheLines = options.Lines
+	cfg.CacheWays = options.Ways
+	cfg.Programs = make([][]string, options.Caches)
+	for id := range cfg.Programs {
+		cfg.Programs[id] = []string{}
+	}
+	cfg.InitialMemory = make([]uint32, MultiprocessingSystem.MemorySize)
+	cfg.Latency = utils.NoLatencies()
+	cfg.LogDirectory = logDirectory
+	cfg.Quiet = true
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	mps, err := MultiprocessingSystem.StartWithConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	silenceLogs(mps)
+
+	model := &model{mps: mps, options: options}
+	report, deadlocked := model.explore()
+	// A system waiting forever can't stop, its goroutines are left behind
+	if !deadlocked {
+		mps.Stop()
+	}
+	return report, nil
+}
+
+// Function to close the log files of a system, millions of accesses would fill them
+func silenceLogs(mps *MultiprocessingSystem.MultiprocessingSystem) {
+	loggers := []*log.Logger{mps.Interconnect.Logger, mps.MainMemory.Logger}
+	for _, pe := range mps.ProcessingElements {
+		loggers = append(loggers, pe.Logger)
+	}
+	for _, cc := range mps.CacheControllers {
+		loggers = append(loggers, cc.Logger)
+	}
+	for _, logger := range loggers {
+		if file, ok := logger.Writer().(*os.File); ok {
+			file.Close()
+		}
+		logger.SetOutput(io.Discard)
+	}
+}
+
+// Simulated system where every explored state is restored before an access
+type model struct {
+	mps     *MultiprocessingSystem.MultiprocessingSystem
+	options Options
+}
+
+// Function to list every access of every processor
+func (m *model) events() []Event {
+	events := []Event{}
+	for cache := 0; cache < m.options.Caches; cache++ {
+		for address := 0; address < m.options.Blocks; address++ {
+			for _, kind := range operations {
+				if kind == "READ" || kind == "LL" {
+					events = append(events, Event{Cache: cache, Kind: kind, Address: address})
+					continue
+				}
+				for value := 0; value < m.options.Values; value++ {
+					events = append(events, Event{Cache: cache, Kind: kind, Address: address, Value: value})
+				}
+			}
+		}
+	}
+	return events
+}
+
+// Function to run the breadth first search, it also tells if the system was left in a deadlock
+func (m *model) explore() (*Report, bool) {
+	options := m.options
+	report := &Report{
+		Protocol: options.Protocol,
+		Caches:   options.Caches,
+		Blocks:   options.Blocks,
+		Lines:    options.Lines,
+		Ways:     options.Ways,
+		Values:   options.Values,
+		Verdict:  VerdictVerified,
+	}
+	initial := m.capture()
+	initial.Stored = make([]int, options.Blocks)
+	report.Initial = initial
+
+	nodes := []node{{state: initial, parent: -1}}
+	seen := map[string]bool{initial.key(): true}
+	events := m.events()
+	for head := 0; head < len(nodes); head++ {
+		for _, event := range events {
+			if len(nodes) >= options.MaxStates {
+				report.Verdict = VerdictIncomplete
+				report.States = len(nodes)
+				return report, false
+			}
+			next, transactions, failures := m.apply(nodes[head].state, event)
+			report.Transitions++
+			reached := node{state: next, parent: head, depth: nodes[head].depth + 1, event: event, transactions: transactions}
+			if len(failures) > 0 {
+				report.Verdict = VerdictViolation
+				report.Violation = &Counterexample{Failures: failures, Trace: counterexample(nodes, reached)}
+				report.States = len(nodes)
+				return report, failures[0].Invariant == InvariantDeadlock
+			}
+			if key := next.key(); !seen[key] {
+				seen[key] = true
+				nodes = append(nodes, reached)
+				report.Depth = reached.depth
+			}
+		}
+	}
+	report.States = len(nodes)
+	return report, false
+}
+
+// Function to get the accesses from the initial state to the last node
+func counterexample(nodes []node, last node) []TraceStep {
+	trace := []TraceStep{}
+	for current := last; current.parent != -1; current = nodes[current.parent] {
+		trace = append([]TraceStep{{Event: current.event, Transactions: current.transactions, State: current.state}}, trace...)
+	}
+	return trace
+}
+
+// Function to apply an access to a state, it returns the next state, the bus transactions and the invariants that failed
+func (m *model) apply(state State, event Event) (State, []utils.BusTransaction, []utils.InvariantFailure) {
+	m.restore(state)
+	mps := m.mps
+	// A SC only stores while the reservation holds
+	stores := event.Kind == "WRITE" || (event.Kind == "SC" && state.Caches[event.Cache].LinkedAddress == event.Address)
+
+	deadlock := func(detail string) (State, []utils.BusTransaction, []utils.InvariantFailure) {
+		_, transactions := mps.Interconnect.Recorder.Snapshot()
+		failure := utils.InvariantFailure{Invariant: InvariantDeadlock, Address: event.Address, Detail: fmt.Sprintf("the %s %s after %v", event, detail, m.options.Timeout), Copies: []string{}, Memory: state.Memory[event.Address], Expected: state.Stored[event.Address]}
+		return state, transactions, []utils.InvariantFailure{failure}
+	}
+	timeout := time.NewTimer(m.options.Timeout)
+	defer timeout.Stop()
+	select {
+	case mps.RequestChannelsM1[event.Cache] <- utils.RequestProcessingElement{Type: event.Kind, Address: event.Address, Data: event.Value}:
+	case <-timeout.C:
+		return deadlock("was not accepted by its Cache Controller")
+	}
+	var response utils.ResponseProcessingElement
+	select {
+	case response = <-mps.ResponseChannelsM1[event.Cache]:
+	case <-timeout.C:
+		return deadlock("got no response")
+	}
+	// The Cache Controller gives the bus back once the access is over
+	select {
+	case mps.Semaphore <- struct{}{}:
+		<-mps.Semaphore
+	case <-timeout.C:
+		return deadlock("never released the bus")
+	}
+
+	next := m.capture()
+	next.Stored = append([]int{}, state.Stored...)
+	if stores {
+		next.Stored[event.Address] = event.Value
+	}
+	failures := []utils.InvariantFailure{}
+	if (event.Kind == "READ" || event.Kind == "LL") && response.Data != next.Stored[event.Address] {
+		failures = append(failures, utils.InvariantFailure{
+			Invariant: MultiprocessingSystem.InvariantDataValue,
+			Address:   event.Address,
+			Detail:    fmt.Sprintf("CC%d read %d from the address %d but the last value stored is %d", event.Cache, response.Data, event.Address, next.Stored[event.Address]),
+			Copies:    []string{},
+			Memory:    next.Memory[event.Address],
+			Expected:  next.Stored[event.Address],
+		})
+	}
+	for address := 0; address < m.options.Blocks; address++ {
+		failures = append(failures, mps.AddressFailures(address, next.Stored[address])...)
+	}
+	_, transactions := mps.Interconnect.Recorder.Snapshot()
+	// The bus log would keep the messages of every access, the Interconnect is done with it
+	mps.Interconnect.Logs = utils.QueueS{}
+	mps.Interconnect.Transactions = utils.QueueS{}
+	return next, transactions, failures
+}
+
+// Function to load a state into the system, no component is serving an access between two of them
+func (m *model) restore(state State) {
+	mps := m.mps
+	for i, cc := range mps.CacheControllers {
+		saved := state.Caches[i]
+		for _, block := range saved.Blocks {
+			cc.Cache.SetAddress(block.Block, block.Address)
+			cc.Cache.SetData(block.Block, block.Data)
+			cc.Cache.SetState(block.Block, block.State)
+		}
+		for set, items := range saved.ReplacementQueues {
+			cc.ReplacementQueues[set] = utils.NewQueue(items)
+		}
+		cc.RestoreReservation(saved.LinkedAddress)
+	}
+	for address, data := range state.Memory {
+		mps.MainMemory.Data[address] = uint32(data)
+	}
+	// Every access starts a new trace, only the transactions of the access are kept
+	mps.Interconnect.Recorder = utils.NewTraceRecorder()
+}
+
+// Function to take the caches and the accessed words of the memory from the system
+func (m *model) capture() State {
+	mps := m.mps
+	state := State{Caches: []CacheState{}, Memory: []int{}}
+	for _, cc := range mps.CacheControllers {
+		cache := CacheState{Blocks: utils.CacheObjectList{}, ReplacementQueues: [][]int{}, LinkedAddress: cc.LinkedAddress()}
+		for i := 0; i < cc.Cache.Lines(); i++ {
+			cache.Blocks = append(cache.Blocks, utils.CacheObject{Block: i, Address: cc.Cache.GetAddress(i), Data: cc.Cache.GetData(i), State: cc.Cache.GetState(i)})
+		}
+		for _, queue := range cc.ReplacementQueues {
+			cache.ReplacementQueues = append(cache.ReplacementQueues, queue.Items())
+		}
+		state.Caches = append(state.Caches, cache)
+	}
+	for address := 0; address < m.options.Blocks; address++ {
+		state.Memory = append(state.Memory, int(mps.MainMemory.Data[address]))
+	}
+	return state
+}
+
+// Function to write a state as text, one line for every cache and one for the memory
+func (state State) Lines() []string {
+	lines := []string{}
+	for i, cache := range state.Caches {
+		blocks := []string{}
+		for _, block := range cache.Blocks {
+			if block.Address == -1 {
+				blocks = append(blocks, "-")
+				continue
+			}
+			blocks = append(blocks, fmt.Sprintf("%d:%d %s", block.Address, block.Data, block.State))
+		}
+		line := fmt.Sprintf("CC%d [%s]", i, strings.Join(blocks, ", "))
+		if cache.LinkedAddress != -1 {
+			line += fmt.Sprintf(" linked %d", cache.LinkedAddress)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, fmt.Sprintf("Memory %v, last stores %v", state.Memory, state.Stored))
+	return lines
+}
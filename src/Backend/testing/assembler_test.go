@@ -0,0 +1,62 @@
+package testing
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"Backend/components/Assembler"
+)
+
+// Test that comments, constants, labels and macros are assembled into plain instructions
+func TestAssemblerValidProgram(t *testing.T) {
+	fmt.Println("Starting Unit Test for the assembler")
+	source := `; spin until the lock is free, then write a flag
+.equ LOCK 4
+.equ FLAG 0x8
+
+.macro store_one addr
+    INC
+    write addr        # mnemonics are case insensitive
+.endm
+
+retry:  LL LOCK
+        INC
+        SC LOCK       // 1 when the lock was taken
+        BEQZ retry
+        store_one FLAG
+`
+	program, err := assembler.Assemble("lock.txt", source)
+	if err != nil {
+		t.Fatalf("Expected a valid program, got:\n%v", err)
+	}
+	expected := []string{"LL 4", "INC", "SC 4", "BEQZ 0", "INC", "WRITE 8"}
+	if !reflect.DeepEqual(program, expected) {
+		t.Errorf("Expected %v, got %v", expected, program)
+	}
+}
+
+// Test that every invalid line is reported with its line and column
+func TestAssemblerDiagnostics(t *testing.T) {
+	fmt.Println("Starting Unit Test for the assembler diagnostics")
+	source := "READ 3\nREED 2\n  WRITE 16\nINC 4\nBEQZ nowhere\nREAD\n"
+	_, err := assembler.Assemble("bad.txt", source)
+	var assemblyError *assembler.Error
+	if !errors.As(err, &assemblyError) {
+		t.Fatalf("Expected an assembler error, got %v", err)
+	}
+	expected := []assembler.Diagnostic{
+		{Line: 2, Column: 1, Message: `unknown instruction "REED", did you mean READ?`},
+		{Line: 3, Column: 9, Message: "the address 16 is out of range, the memory has the addresses 0 to 15"},
+		{Line: 4, Column: 5, Message: "INC doesn't take an operand"},
+		{Line: 5, Column: 6, Message: `unknown label "nowhere"`},
+		{Line: 6, Column: 1, Message: "READ expects an operand"},
+	}
+	if !reflect.DeepEqual(assemblyError.Diagnostics, expected) {
+		t.Errorf("Expected %v, got %v", expected, assemblyError.Diagnostics)
+	}
+	if first := "bad.txt:2:1: unknown instruction \"REED\", did you mean READ?"; err.Error()[:len(first)] != first {
+		t.Errorf("Expected the error to start with %q, got %q", first, err.Error())
+	}
+}
@@ -0,0 +1,127 @@
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/components/RESTfulAPI"
+	"Backend/utils"
+)
+
+// Test that every kind of breakpoint pauses the execution at the instruction that triggered it
+func TestBreakpoints(t *testing.T) {
+	fmt.Println("Starting Unit Test for the breakpoints and watchpoints")
+	program := []string{"INC", "INC", "WRITE 1", "READ 1"}
+	tests := map[string]struct {
+		breakpoint utils.Breakpoint
+		core       int
+		position   int
+		detail     string
+		hits       int // Times the breakpoint fires until every PE finishes
+	}{
+		"position":    {utils.Breakpoint{Kind: "position", Core: 1, Position: 2}, 1, 2, "Before", 1},
+		"instruction": {utils.Breakpoint{Kind: "instruction", Core: -1, Instruction: "write"}, 0, 2, "Before", 2},
+		"watch write": {utils.Breakpoint{Kind: "watch", Address: 1, Access: "write"}, 0, 2, "WRITE 1", 2},
+		"watch read":  {utils.Breakpoint{Kind: "watch", Address: 1, Access: "read"}, 0, 3, "READ 1", 2},
+		"watch state": {utils.Breakpoint{Kind: "watch", Address: 1, Access: "state"}, 0, 2, "from I to M", 3},
+		"bus":         {utils.Breakpoint{Kind: "bus", Address: -1, Transaction: "readexclusiverequest"}, 0, 2, "ReadExclusiveRequest 1", 2},
+	}
+	for name, test := range tests {
+		mps, channel := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+		added, err := mps.AddBreakpoint(test.breakpoint)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := mps.Run(MultiprocessingSystem.RunLimit{}); err != nil {
+			t.Fatal(err)
+		}
+		hit := waitForExecution(t, channel, MultiprocessingSystem.ExecutionPaused).Breakpoint
+		if hit == nil || hit.Breakpoint.ID != added.ID || hit.Core != test.core || hit.Position != test.position || !strings.Contains(hit.Detail, test.detail) {
+			t.Errorf("%s: expected PE%d at position %d (%s), got %+v", name, test.core, test.position, test.detail, hit)
+			continue
+		}
+
+		// The execution goes on from each breakpoint, the instruction where it stopped runs once
+		for execution := mps.Execution(); execution.State == MultiprocessingSystem.ExecutionPaused; {
+			if err := mps.Resume(); err != nil {
+				t.Fatal(err)
+			}
+			execution = waitForExecution(t, channel, MultiprocessingSystem.ExecutionPaused, MultiprocessingSystem.ExecutionFinished)
+		}
+		for _, pe := range mps.ProcessingElements {
+			if pe.Register != 2 || len(pe.Instructions.Items) != 0 {
+				t.Errorf("%s: expected PE%d to run its whole program, got register %d", name, pe.ID, pe.Register)
+			}
+		}
+		if breakpoints := mps.Breakpoints(); breakpoints[0].Hits != test.hits {
+			t.Errorf("%s: expected %d hits, got %+v", name, test.hits, breakpoints)
+		}
+	}
+
+	mps, _ := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+	invalid := []utils.Breakpoint{
+		{Kind: "position", Core: 2},
+		{Kind: "instruction", Core: -1, Instruction: "JUMP"},
+		{Kind: "watch", Address: -1, Access: "write"},
+		{Kind: "watch", Address: 1, Access: "touch"},
+		{Kind: "bus", Address: -1, Transaction: "Flush"},
+		{Kind: "step"},
+	}
+	for _, breakpoint := range invalid {
+		if _, err := mps.AddBreakpoint(breakpoint); err == nil {
+			t.Errorf("Expected an error for the breakpoint %+v", breakpoint)
+		}
+	}
+}
+
+// Test the breakpoint routes of the API v1
+func TestBreakpointRoutes(t *testing.T) {
+	fmt.Println("Starting Unit Test for the breakpoint routes")
+	server := httptest.NewServer(restfulapi.Router())
+	defer server.Close()
+
+	var created struct{ ID string }
+	if status := request(t, server, "POST", "/v1/systems", `{"cores": 2, "sources": ["INC\nWRITE 3\nINC", "READ 3"]}`, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the system to be created, got %d", status)
+	}
+	defer request(t, server, "DELETE", "/v1/systems/"+created.ID, "", nil)
+	path := "/v1/systems/" + created.ID
+
+	if status, response := requestError(t, server, "POST", path+"/breakpoints", `{"kind": "watch", "access": "write"}`); status != http.StatusBadRequest || response.Error.Code != "invalid_request" {
+		t.Errorf("Expected a watchpoint without address to be rejected, got %d %+v", status, response)
+	}
+	var breakpoint utils.Breakpoint
+	if status := request(t, server, "POST", path+"/breakpoints", `{"kind": "watch", "address": 3, "access": "write"}`, &breakpoint); status != http.StatusCreated || breakpoint.ID == 0 {
+		t.Fatalf("Expected the watchpoint to be created, got %d %+v", status, breakpoint)
+	}
+	var breakpoints []utils.Breakpoint
+	if request(t, server, "GET", path+"/breakpoints", "", &breakpoints); len(breakpoints) != 1 {
+		t.Errorf("Expected 1 breakpoint, got %+v", breakpoints)
+	}
+
+	var execution utils.ExecutionState
+	request(t, server, "POST", path+"/execution", `{"action": "run"}`, &execution)
+	for deadline := time.Now().Add(5 * time.Second); execution.State != MultiprocessingSystem.ExecutionPaused; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the watchpoint to pause the execution, got %+v", execution)
+		}
+		time.Sleep(10 * time.Millisecond)
+		request(t, server, "GET", path+"/execution", "", &execution)
+	}
+	if execution.Breakpoint == nil || execution.Breakpoint.Breakpoint.ID != breakpoint.ID || execution.Breakpoint.Core != 0 {
+		t.Errorf("Expected the watchpoint of PE0 to fire, got %+v", execution.Breakpoint)
+	}
+
+	deletePath := fmt.Sprintf("%s/breakpoints/%d", path, breakpoint.ID)
+	if status := request(t, server, "DELETE", deletePath, "", nil); status != http.StatusNoContent {
+		t.Errorf("Expected the breakpoint to be deleted, got %d", status)
+	}
+	if status, _ := requestError(t, server, "DELETE", deletePath, ""); status != http.StatusNotFound {
+		t.Errorf("Expected a deleted breakpoint to be missing, got %d", status)
+	}
+}
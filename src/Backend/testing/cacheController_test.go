@@ -4,6 +4,7 @@ import (
 	"testing"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"math/rand"
 	"time"
 	"Backend/utils"
@@ -61,7 +62,7 @@ func TestCacheControllerRun(t *testing.T) {
 	}()
 
 	// Start a thread to simulate Processing Element requests
-	peIsDone := false
+	var peIsDone atomic.Bool
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -88,7 +89,7 @@ func TestCacheControllerRun(t *testing.T) {
 			}
 		}
 		// Update the flag to terminate the main loop
-		peIsDone = true
+		peIsDone.Store(true)
 	}()
 
 	// Start a thread to simulate Interconncet responses
@@ -113,7 +114,7 @@ func TestCacheControllerRun(t *testing.T) {
 
 	// Validate if all the requests are managed until the end
 	timeout := time.After(1 * time.Minute) // Set 1 minute of timeout
-	for !peIsDone {
+	for !peIsDone.Load() {
 		select {
 		case <-timeout:
 			t.Fatal("Test timed out")
@@ -132,3 +133,260 @@ func TestCacheControllerRun(t *testing.T) {
 	close(responseChannelBroadcast)
 	close(semaphore)
 }
+
+// Test that a bus invalidation on the linked block makes the next SC fail
+func TestCacheControllerStoreConditional(t *testing.T) {
+	fmt.Println("Starting Unit Test for the LL/SC reservation of the Cache Controller Component")
+
+	// Create the communication channels for the Cache Controller
+	requestChannelProcessingElement := make(chan utils.RequestProcessingElement)
+	responseChannelProcessingElement := make(chan utils.ResponseProcessingElement)
+	requestChannelInterconnect := make(chan utils.RequestInterconnect)
+	responseChannelInterconnct := make(chan utils.ResponseInterconnect)
+	requestChannelBroadcast := make(chan utils.RequestBroadcast)
+	responseChannelBroadcast := make(chan utils.ResponseBroadcast)
+	semaphore := make(chan struct{}, 1)
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+
+	cc, err := CacheController.New(
+		0,
+		requestChannelProcessingElement,
+		responseChannelProcessingElement,
+		requestChannelInterconnect,
+		responseChannelInterconnct,
+		requestChannelBroadcast,
+		responseChannelBroadcast,
+		semaphore,
+		"MESI",
+		"../logs/CC/CC",
+		quit,
+	)
+	if err != nil {
+		t.Fatalf("Error creating Cache Controller: %v", err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cc.Run(&wg)
+	}()
+
+	// Simulate the Interconnect, every miss is served from memory
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-requestChannelInterconnect:
+				responseChannelInterconnct <- utils.ResponseInterconnect{Data: 7, NewStatus: "E"}
+			}
+		}
+	}()
+
+	// LL followed by SC without interference succeeds
+	requestChannelProcessingElement <- utils.RequestProcessingElement{Type: "LL", Address: 3}
+	if response := <-responseChannelProcessingElement; response.Data != 7 {
+		t.Fatalf("LL returned %d, expected 7", response.Data)
+	}
+	requestChannelProcessingElement <- utils.RequestProcessingElement{Type: "SC", Address: 3, Data: 8}
+	if response := <-responseChannelProcessingElement; !response.Status {
+		t.Fatal("SC failed without a remote write")
+	}
+
+	// A remote write between LL and SC clears the reservation
+	requestChannelProcessingElement <- utils.RequestProcessingElement{Type: "LL", Address: 3}
+	<-responseChannelProcessingElement
+	if linked := cc.LinkedAddress(); linked != 3 {
+		t.Fatalf("Expected the reservation on the address 3, got %d", linked)
+	}
+	requestChannelBroadcast <- utils.RequestBroadcast{Type: "ReadExclusiveRequest", Address: 3}
+	<-responseChannelBroadcast
+	if linked := cc.LinkedAddress(); linked != -1 {
+		t.Fatalf("Expected the broadcast to clear the reservation, got %d", linked)
+	}
+	requestChannelProcessingElement <- utils.RequestProcessingElement{Type: "SC", Address: 3, Data: 9}
+	if response := <-responseChannelProcessingElement; response.Status {
+		t.Fatal("SC succeeded after the linked block was invalidated")
+	}
+	if cc.SCSuccesses != 1 || cc.SCFailures != 1 {
+		t.Fatalf("Expected 1 SC success and 1 failure, got %d and %d", cc.SCSuccesses, cc.SCFailures)
+	}
+
+	// Close everything
+	close(quit)
+	wg.Wait()
+}
+
+// Test that the counters can be read while the Cache Controller serves the requests of its PE
+func TestCacheControllerCounters(t *testing.T) {
+	fmt.Println("Starting Unit Test for the counters of the Cache Controller Component")
+	requestChannelProcessingElement := make(chan utils.RequestProcessingElement)
+	responseChannelProcessingElement := make(chan utils.ResponseProcessingElement)
+	requestChannelInterconnect := make(chan utils.RequestInterconnect)
+	responseChannelInterconnct := make(chan utils.ResponseInterconnect)
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+
+	cc, err := CacheController.New(
+		0,
+		requestChannelProcessingElement,
+		responseChannelProcessingElement,
+		requestChannelInterconnect,
+		responseChannelInterconnct,
+		make(chan utils.RequestBroadcast),
+		make(chan utils.ResponseBroadcast),
+		make(chan struct{}, 1),
+		"MESI",
+		"../logs/CC/CC",
+		quit,
+	)
+	if err != nil {
+		t.Fatalf("Error creating Cache Controller: %v", err)
+	}
+	cc.Latency = utils.NoLatencies()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cc.Run(&wg)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-requestChannelInterconnect:
+				responseChannelInterconnct <- utils.ResponseInterconnect{Data: 1, NewStatus: "E"}
+			}
+		}
+	}()
+
+	// The PE reads while the counters are read, they only grow
+	const requests = 200
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < requests; i++ {
+			requestChannelProcessingElement <- utils.RequestProcessingElement{Type: "READ", Address: i % 8}
+			<-responseChannelProcessingElement
+		}
+	}()
+	last := 0
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		counters := cc.Counters()
+		if counters.Hits+counters.Misses < last {
+			t.Fatalf("The accesses went down from %d to %d", last, counters.Hits+counters.Misses)
+		}
+		last = counters.Hits + counters.Misses
+	}
+	if counters := cc.Counters(); counters.Hits+counters.Misses != requests || counters.Misses == 0 {
+		t.Errorf("Expected %d accesses with some misses, got %+v", requests, counters)
+	}
+
+	// Close everything
+	close(quit)
+	wg.Wait()
+}
+
+// Test that a snooped block has its new state before the Interconnect gets the answer
+func TestCacheControllerSnoopStatus(t *testing.T) {
+	fmt.Println("Starting Unit Test for the snoop answers of the Cache Controller Component")
+	for _, protocol := range []string{"MESI", "MOESI"} {
+		requestChannelBroadcast := make(chan utils.RequestBroadcast)
+		responseChannelBroadcast := make(chan utils.ResponseBroadcast)
+		quit := make(chan struct{})
+		var wg sync.WaitGroup
+
+		cc, err := CacheController.New(
+			0,
+			make(chan utils.RequestProcessingElement),
+			make(chan utils.ResponseProcessingElement),
+			nil,
+			nil,
+			requestChannelBroadcast,
+			responseChannelBroadcast,
+			make(chan struct{}, 1),
+			protocol,
+			"../logs/CC/CC",
+			quit,
+		)
+		if err != nil {
+			t.Fatalf("Error creating Cache Controller: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cc.Run(&wg)
+		}()
+
+		// The requester may use the bus again as soon as it has the answer, the block can't change after it
+		shared := map[string]string{"MESI": "S", "MOESI": "O"}[protocol]
+		initial := map[string]string{"MESI": "E", "MOESI": "M"}[protocol]
+		for i := 0; i < 100; i++ {
+			cc.WriteDataToCache(3, i, initial)
+			requestChannelBroadcast <- utils.RequestBroadcast{Type: "ReadRequest", Address: 3}
+			if response := <-responseChannelBroadcast; !response.Match || response.Data != i {
+				t.Fatalf("%s: expected the value %d in the answer, got %+v", protocol, i, response)
+			}
+			if status := cc.GetAddressStatus(3); status != shared {
+				t.Fatalf("%s: expected the block in %s after answering a ReadRequest, got %s", protocol, shared, status)
+			}
+			requestChannelBroadcast <- utils.RequestBroadcast{Type: "ReadExclusiveRequest", Address: 3}
+			<-responseChannelBroadcast
+			if status := cc.GetAddressStatus(3); status != "I" {
+				t.Fatalf("%s: expected the block in I after answering a ReadExclusiveRequest, got %s", protocol, status)
+			}
+		}
+
+		// Close everything
+		close(quit)
+		wg.Wait()
+	}
+}
+
+// Test that a set-associative cache only replaces the blocks of the set of the address
+func TestCacheGeometry(t *testing.T) {
+	fmt.Println("Starting Unit Test for the cache geometry")
+	cc, err := CacheController.New(0, nil, nil, nil, nil, nil, nil, nil, "MESI", "../logs/CC/CC", nil)
+	if err != nil {
+		t.Fatalf("Error creating Cache Controller: %v", err)
+	}
+
+	// 4 blocks in 2 sets of 2 ways, the even addresses go to the set 0
+	cc.SetCacheGeometry(4, 2)
+	if cc.Cache.Sets() != 2 || cc.Cache.SetOf(6) != 0 || cc.Cache.SetOf(5) != 1 {
+		t.Fatalf("Expected 2 sets, got %d", cc.Cache.Sets())
+	}
+	cc.WriteDataToCache(0, 10, "E")
+	cc.WriteDataToCache(1, 11, "E")
+	cc.WriteDataToCache(2, 12, "E")
+	// The set 0 is full, the address 4 replaces the oldest even address
+	cc.WriteDataToCache(4, 14, "E")
+	if cc.DataInCache(0) {
+		t.Errorf("The address 0 should have been replaced")
+	}
+	for _, address := range []int{1, 2, 4} {
+		if !cc.DataInCache(address) || cc.GetDataFromCache(address) != 10+address {
+			t.Errorf("The address %d should be in the cache with the value %d", address, 10+address)
+		}
+	}
+	if cc.GetAddressStatus(3) != "I" || cc.Cache.Find(3) != -1 {
+		t.Errorf("The address 3 was never stored")
+	}
+
+	// A direct-mapped cache keeps a single address of every set
+	cc.SetCacheGeometry(4, 1)
+	cc.WriteDataToCache(1, 1, "E")
+	cc.WriteDataToCache(5, 5, "M")
+	if cc.DataInCache(1) || cc.GetAddressStatus(5) != "M" || cc.Cache.Find(5) != 1 {
+		t.Errorf("The address 5 should replace the address 1 in the block 1")
+	}
+}
@@ -0,0 +1,314 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/components/RESTfulAPI"
+	"Backend/utils"
+)
+
+// Function to check that two systems reached the same state, results and traces
+func compareSystems(t *testing.T, original *MultiprocessingSystem.MultiprocessingSystem, restored *MultiprocessingSystem.MultiprocessingSystem) {
+	t.Helper()
+	if !reflect.DeepEqual(original.State(), restored.State()) {
+		t.Errorf("Expected the same state\noriginal: %+v\nrestored: %+v", original.State(), restored.State())
+	}
+	if !reflect.DeepEqual(original.Results(), restored.Results()) {
+		t.Errorf("Expected the same results\noriginal: %+v\nrestored: %+v", original.Results(), restored.Results())
+	}
+	originalOperations, originalTransactions := original.Recorder.Snapshot()
+	restoredOperations, restoredTransactions := restored.Recorder.Snapshot()
+	if !reflect.DeepEqual(originalOperations, restoredOperations) || !reflect.DeepEqual(originalTransactions, restoredTransactions) {
+		t.Errorf("Expected the same traces\noriginal: %+v\nrestored: %+v", originalTransactions, restoredTransactions)
+	}
+	for id, pe := range original.ProcessingElements {
+		if !reflect.DeepEqual(pe.Loads, restored.ProcessingElements[id].Loads) {
+			t.Errorf("Expected PE%d to load %v, got %v", id, pe.Loads, restored.ProcessingElements[id].Loads)
+		}
+	}
+}
+
+// Test that a system restored in the middle of a cycle of its execution finishes as the original
+func TestCheckpointExecution(t *testing.T) {
+	fmt.Println("Starting Unit Test for the checkpoint of a paused execution")
+	program := []string{"WRITE 1", "READ 2", "INC", "WRITE 2", "READ 1", "WRITE 3", "LL 3", "SC 3", "READ 3"}
+	original, channel := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+	if _, err := original.AddBreakpoint(utils.Breakpoint{Kind: "position", Core: 1, Position: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 5 instructions of 2 PEs leave the pause in the middle of a cycle
+	if err := original.Run(MultiprocessingSystem.RunLimit{Instructions: 5}); err != nil {
+		t.Fatal(err)
+	}
+	waitForExecution(t, channel, MultiprocessingSystem.ExecutionPaused)
+	filename := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := original.SaveCheckpoint(filename); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint, err := MultiprocessingSystem.LoadCheckpoint(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := checkpoint.RestoreConfig()
+	restored, restoredChannel := startSystem(t, systemOptions{Config: &restore})
+	compareSystems(t, original, restored)
+
+	// Both systems stop at the breakpoint and then run to the end
+	for _, system := range []struct {
+		mps     *MultiprocessingSystem.MultiprocessingSystem
+		channel <-chan utils.Event
+	}{{original, channel}, {restored, restoredChannel}} {
+		if err := system.mps.Resume(); err != nil {
+			t.Fatal(err)
+		}
+		hit := waitForExecution(t, system.channel, MultiprocessingSystem.ExecutionPaused).Breakpoint
+		if hit == nil || hit.Core != 1 || hit.Position != 5 {
+			t.Errorf("Expected the breakpoint of PE1 to fire, got %+v", hit)
+		}
+		if err := system.mps.Resume(); err != nil {
+			t.Fatal(err)
+		}
+		waitForExecution(t, system.channel, MultiprocessingSystem.ExecutionFinished)
+	}
+	compareSystems(t, original, restored)
+
+	// The log of the restored PE keeps the lines written before the checkpoint
+	if !strings.Contains(checkpoint.ProcessingElements[0].Log, "WRITE") {
+		t.Errorf("Expected the checkpoint to keep the log of PE0, got %q", checkpoint.ProcessingElements[0].Log)
+	}
+}
+
+// Test that a system stepped by hand with stores in the store buffers continues as the original
+func TestCheckpointStoreBuffers(t *testing.T) {
+	fmt.Println("Starting Unit Test for the checkpoint of the store buffers")
+	cfg := MultiprocessingSystem.DefaultConfig()
+	cfg.Consistency = "PSO"
+	cfg.CacheLines = 2
+	cfg.CacheWays = 1
+	original, _ := startSystem(t, systemOptions{Config: &cfg, Protocol: "MOESI", Programs: [][]string{
+		{"INC", "WRITE 1", "WRITE 2", "READ 3", "READ 1"},
+		{"READ 1", "WRITE 3", "READ 2", "INC"},
+		{"WRITE 5", "READ 7", "INC", "WRITE 1", "READ 5"},
+	}})
+	for _, id := range []int{0, 0, 1, 2, 1} {
+		if err := original.StepAndWait(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(original.ProcessingElements[0].StoreBuffer) == 0 {
+		t.Fatalf("Expected PE0 to keep its stores in the store buffer")
+	}
+
+	checkpoint, err := original.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The checkpoint is a JSON document that can be shared
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := &MultiprocessingSystem.Checkpoint{}
+	if err := json.Unmarshal(data, shared); err != nil {
+		t.Fatal(err)
+	}
+	restore := shared.RestoreConfig()
+	restored, _ := startSystem(t, systemOptions{Config: &restore})
+	if !reflect.DeepEqual(restored.ProcessingElements[0].StoreBuffer, original.ProcessingElements[0].StoreBuffer) {
+		t.Errorf("Expected the store buffer %v, got %v", original.ProcessingElements[0].StoreBuffer, restored.ProcessingElements[0].StoreBuffer)
+	}
+
+	// The same scheduler gives the same interleaving to both systems
+	for _, mps := range []*MultiprocessingSystem.MultiprocessingSystem{original, restored} {
+		if finished, err := mps.RunSeeded(7, 1000); err != nil || !finished {
+			t.Fatalf("Expected the system to finish, got %v", err)
+		}
+	}
+	compareSystems(t, original, restored)
+
+	// A checkpoint of another system can't be restored
+	other := checkpoint.RestoreConfig()
+	other.Cores = 2
+	other.Programs, other.ProgramNames = other.Programs[:2], other.ProgramNames[:2]
+	other.LogDirectory = t.TempDir()
+	if _, err := MultiprocessingSystem.StartWithConfig(other); err == nil {
+		t.Errorf("Expected an error restoring a checkpoint in a system with other cores")
+	}
+	// A store buffer without room would drain an empty buffer at the first WRITE
+	broken := &MultiprocessingSystem.Checkpoint{}
+	json.Unmarshal(data, broken)
+	broken.ProcessingElements[0].StoreBufferSize = 0
+	data, _ = json.Marshal(broken)
+	filename := writeFile(t, t.TempDir(), "checkpoint.json", string(data))
+	if _, err := MultiprocessingSystem.LoadCheckpoint(filename); err == nil {
+		t.Errorf("Expected an error loading a checkpoint with a store buffer of size 0")
+	}
+	checkpoint.Version = 0
+	if err := checkpoint.Validate(); err == nil {
+		t.Errorf("Expected an error validating a checkpoint of another version")
+	}
+}
+
+// Test that a running system can't be saved
+func TestCheckpointRunning(t *testing.T) {
+	fmt.Println("Starting Unit Test for the checkpoint of a running system")
+	program := []string{"INC", "INC", "INC"}
+	mps, channel := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+	if err := mps.Run(MultiprocessingSystem.RunLimit{Delay: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	waitForExecution(t, channel, MultiprocessingSystem.ExecutionRunning)
+	if _, err := mps.Checkpoint(); err == nil {
+		t.Errorf("Expected an error saving a running system")
+	}
+	if err := mps.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mps.Checkpoint(); err != nil {
+		t.Errorf("Expected a paused system to be saved: %v", err)
+	}
+}
+
+// Test that a system is replaced only when the new one starts, and that a stopped system can't be stepped
+func TestCheckpointReplace(t *testing.T) {
+	fmt.Println("Starting Unit Test for the replacement of a system")
+	program := []string{"INC", "INC", "INC"}
+	mps, _ := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+
+	invalid := mps.Config
+	invalid.Programs = [][]string{{"JUMP"}, program}
+	if _, err := MultiprocessingSystem.Replace(mps, invalid); err == nil {
+		t.Fatalf("Expected an invalid program to be rejected")
+	}
+	if mps.Stopped() {
+		t.Fatalf("Expected the system to keep running when its replacement fails")
+	}
+	if err := mps.StepAndWait(0); err != nil {
+		t.Errorf("Expected the system to take a step after the failed replacement: %v", err)
+	}
+
+	replaced, err := MultiprocessingSystem.Replace(mps, mps.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(replaced.Stop)
+	if !mps.Stopped() {
+		t.Errorf("Expected the replaced system to be stopped")
+	}
+	if _, err := mps.SteppingProcessingElement(1); err == nil {
+		t.Errorf("Expected an error stepping a stopped system")
+	}
+	if _, err := mps.DrainStoreBuffer(1, 0); err == nil {
+		t.Errorf("Expected an error draining a stopped system")
+	}
+}
+
+// Test that a checkpoint with indices, addresses or states out of range is rejected before it's restored
+func TestCheckpointValidate(t *testing.T) {
+	fmt.Println("Starting Unit Test for the validation of the checkpoints")
+	program := []string{"WRITE 1", "LL 2", "READ 3"}
+	mps, _ := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+	for _, id := range []int{0, 0, 1} {
+		if err := mps.StepAndWait(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved, err := mps.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saved.Validate(); err != nil {
+		t.Fatalf("Expected the checkpoint of the system to be valid: %v", err)
+	}
+	data, _ := json.Marshal(saved)
+
+	changes := map[string]func(checkpoint *MultiprocessingSystem.Checkpoint){
+		"block index": func(c *MultiprocessingSystem.Checkpoint) { c.CacheControllers[0].Cache[1].Block = 0 },
+		"block address": func(c *MultiprocessingSystem.Checkpoint) {
+			c.CacheControllers[0].Cache[0].Address = MultiprocessingSystem.MemorySize
+		},
+		"MOESI state":    func(c *MultiprocessingSystem.Checkpoint) { c.CacheControllers[1].Cache[0].State = "O" },
+		"unknown state":  func(c *MultiprocessingSystem.Checkpoint) { c.CacheControllers[1].Cache[2].State = "X" },
+		"repeated block": func(c *MultiprocessingSystem.Checkpoint) { c.CacheControllers[0].ReplacementQueues[0] = []int{0, 0} },
+		"unused block":   func(c *MultiprocessingSystem.Checkpoint) { c.CacheControllers[1].ReplacementQueues[0] = []int{3} },
+		"long queue": func(c *MultiprocessingSystem.Checkpoint) {
+			c.CacheControllers[1].ReplacementQueues[0] = []int{0, 1, 2, 3, 4}
+		},
+		"reservation": func(c *MultiprocessingSystem.Checkpoint) { c.CacheControllers[0].LinkedAddress = -2 },
+		"store buffer": func(c *MultiprocessingSystem.Checkpoint) {
+			c.ProcessingElements[1].StoreBuffer = []utils.StoreBufferEntry{{Address: MultiprocessingSystem.MemorySize, Data: 1}}
+		},
+		"store buffer size": func(c *MultiprocessingSystem.Checkpoint) { c.ProcessingElements[0].StoreBufferSize = 0 },
+		"full store buffer": func(c *MultiprocessingSystem.Checkpoint) {
+			c.ProcessingElements[0].StoreBuffer = make([]utils.StoreBufferEntry, c.ProcessingElements[0].StoreBufferSize+1)
+		},
+		"turn": func(c *MultiprocessingSystem.Checkpoint) { c.Execution.Turn = 3 },
+	}
+	for name, change := range changes {
+		checkpoint := &MultiprocessingSystem.Checkpoint{}
+		if err := json.Unmarshal(data, checkpoint); err != nil {
+			t.Fatal(err)
+		}
+		change(checkpoint)
+		if err := checkpoint.Validate(); err == nil {
+			t.Errorf("%s: expected the checkpoint to be rejected", name)
+		}
+	}
+}
+
+// Test the checkpoint route of the API v1 and the creation of a system from a checkpoint
+func TestCheckpointRoutes(t *testing.T) {
+	fmt.Println("Starting Unit Test for the checkpoint routes")
+	server := httptest.NewServer(restfulapi.Router())
+	defer server.Close()
+
+	var created struct{ ID string }
+	if status := request(t, server, "POST", "/v1/systems", `{"cores": 2, "sources": ["WRITE 3\nINC\nREAD 4", "READ 3\nWRITE 4"]}`, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the system to be created, got %d", status)
+	}
+	defer request(t, server, "DELETE", "/v1/systems/"+created.ID, "", nil)
+	path := "/v1/systems/" + created.ID
+	request(t, server, "POST", path+"/pes/0/step", "", nil)
+	request(t, server, "POST", path+"/pes/1/step", "", nil)
+
+	var checkpoint json.RawMessage
+	if status := request(t, server, "GET", path+"/checkpoint", "", &checkpoint); status != http.StatusOK {
+		t.Fatalf("Expected the checkpoint of the system, got %d", status)
+	}
+	var restored struct{ ID string }
+	if status := request(t, server, "POST", "/v1/systems", `{"checkpoint": `+string(checkpoint)+`}`, &restored); status != http.StatusCreated {
+		t.Fatalf("Expected the system to be restored, got %d", status)
+	}
+	defer request(t, server, "DELETE", "/v1/systems/"+restored.ID, "", nil)
+
+	// Both sessions run with the scheduler of the same seed
+	type finalState struct {
+		Memory struct{ MainMemory, Coherent []int }
+		PEs    []utils.AboutProcessingElement
+	}
+	states := []finalState{}
+	for _, id := range []string{created.ID, restored.ID} {
+		state := finalState{}
+		request(t, server, "POST", "/v1/systems/"+id+"/run", "", nil)
+		request(t, server, "GET", "/v1/systems/"+id+"/memory", "", &state.Memory)
+		request(t, server, "GET", "/v1/systems/"+id+"/pes", "", &state.PEs)
+		states = append(states, state)
+	}
+	if !reflect.DeepEqual(states[0], states[1]) {
+		t.Errorf("Expected the restored system to finish as the original\noriginal: %+v\nrestored: %+v", states[0], states[1])
+	}
+
+	if status, response := requestError(t, server, "POST", "/v1/systems", `{"checkpoint": {"Version": 9, "Config": {}}}`); status != http.StatusBadRequest || response.Error.Code != "invalid_request" {
+		t.Errorf("Expected an invalid checkpoint to be rejected, got %d %+v", status, response)
+	}
+}
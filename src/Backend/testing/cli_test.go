@@ -0,0 +1,71 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"Backend/components/CLI"
+)
+
+// Test the headless run command with flags, a configuration file and its exit statuses
+func TestCLIRun(t *testing.T) {
+	fmt.Println("Starting Unit Test for the headless command line")
+	directory := t.TempDir()
+	program := filepath.Join(directory, "increment.txt")
+	os.WriteFile(program, []byte("READ 2\nINC\nWRITE 2\n"), 0644)
+
+	// Two PEs increment the same address, the memory keeps both increments
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	status := cli.Main([]string{"run", "-cores", "2", "-programs", program + "," + program, "-memory", "0,0,5", "-format", "json"}, stdout, stderr)
+	if status != cli.ExitSuccess {
+		t.Fatalf("Expected a successful run, got status %d: %s", status, stderr.String())
+	}
+	report := cli.RunReport{}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Complete || report.Cores != 2 || report.Memory[2] != 7 || len(report.State.PEs) != 2 {
+		t.Errorf("Unexpected report: complete %v, %d cores, memory %v", report.Complete, report.Cores, report.Memory)
+	}
+
+	// The flags override the configuration file, the text report goes to the output file
+	config := filepath.Join(directory, "run.json")
+	output := filepath.Join(directory, "report.txt")
+	os.WriteFile(config, []byte(`{"protocol": "MOESI", "cores": 4, "workload": "migratory", "seed": 9}`), 0644)
+	status = cli.Main([]string{"run", "-config", config, "-cores", "3", "-output", output}, stdout, stderr)
+	text, _ := os.ReadFile(output)
+	if status != cli.ExitSuccess || !strings.Contains(string(text), "Protocol: MOESI, consistency: SC, cores: 3, seed: 9") {
+		t.Errorf("Unexpected report with status %d:\n%s", status, text)
+	}
+
+	// Failures give a non-zero status
+	failures := map[string][]string{
+		"unknown flag":         {"run", "-unknown"},
+		"invalid protocol":     {"run", "-protocol", "MSI"},
+		"unknown field":        {"run", "-config", writeFile(t, directory, "bad.json", `{"protocols": "MESI"}`)},
+		"missing program":      {"run", "-programs", filepath.Join(directory, "missing.txt")},
+		"invalid program":      {"run", "-programs", writeFile(t, directory, "bad.txt", "LOAD 1")},
+		"unfinished run":       {"run", "-max-steps", "2"},
+		"unknown command":      {"walk"},
+		"missing command args": {},
+	}
+	for name, args := range failures {
+		if status := cli.Main(args, &bytes.Buffer{}, &bytes.Buffer{}); status == cli.ExitSuccess {
+			t.Errorf("%s: expected a failure status", name)
+		}
+	}
+}
+
+// Function to write a file of a test and get its path
+func writeFile(t *testing.T, directory string, name string, contents string) string {
+	path := filepath.Join(directory, name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,65 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"Backend/components/Comparison"
+	"Backend/utils"
+)
+
+// Test that MESI and MOESI run the same programs and memory and report their differences
+func TestComparison(t *testing.T) {
+	fmt.Println("Starting Unit Test for the protocol comparison")
+	options := comparison.DefaultOptions()
+	options.Base.Cores = 3
+	workload := utils.DefaultWorkloadOptions("migratory")
+	workload.Instructions = 9
+	options.Base.Workload = &workload
+	options.Seed = 5
+
+	report, err := comparison.Compare(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rows) != 2 || report.Baseline != "MESI" {
+		t.Fatalf("expected the rows of MESI and MOESI, got %+v", report.Rows)
+	}
+	if len(report.Programs) != 3 || len(report.InitialMemory) != utils.MemorySize {
+		t.Errorf("the report doesn't have the inputs of the runs: %d programs, %d words", len(report.Programs), len(report.InitialMemory))
+	}
+	for name, delta := range report.Rows[0].Delta {
+		if delta != 0 {
+			t.Errorf("the baseline has a delta of %v in %s", delta, name)
+		}
+	}
+	moesi := report.Rows[1]
+	if moesi.Delta["MemoryWrites"] != float64(moesi.Metrics.MemoryWrites-report.Rows[0].Metrics.MemoryWrites) {
+		t.Errorf("wrong delta of MemoryWrites: %v", moesi.Delta["MemoryWrites"])
+	}
+	if !report.SameFinalMemory {
+		t.Errorf("both protocols are coherent under SC, but the memory differs: %v and %v", report.Rows[0].FinalMemory, moesi.FinalMemory)
+	}
+
+	// The same seed gives the same report
+	again, err := comparison.Compare(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Rows, again.Rows) {
+		t.Errorf("the same seed gave different results")
+	}
+
+	table := &bytes.Buffer{}
+	if err := report.WriteTable(table); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"Metric", "MESI", "MOESI", "CacheMisses", "BusTransactions", "MemoryAccesses", "Energy"} {
+		if !strings.Contains(table.String(), expected) {
+			t.Errorf("the table doesn't have %q:\n%s", expected, table.String())
+		}
+	}
+}
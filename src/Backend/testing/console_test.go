@@ -0,0 +1,70 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"Backend/components/CLI"
+)
+
+// Test the interactive console with a script of commands
+func TestConsole(t *testing.T) {
+	fmt.Println("Starting Unit Test for the interactive console")
+	directory := t.TempDir()
+	program := writeFile(t, directory, "increment.txt", "READ 2\nINC\nWRITE 2\n")
+
+	output := &bytes.Buffer{}
+	console := cli.NewConsole(output)
+	quitted := false
+	console.OnQuit = func() { quitted = true }
+	script := []string{
+		"step 0",
+		"load 0 " + program,
+		"load 1 " + program,
+		"init MOESI cores=2 memory=0,0,5",
+		"step 0 3",
+		"!!",
+		"run 100",
+		"memory",
+		"set memory 3 9",
+		"set cache 0 0 7 4 M",
+		"set cache 0 0 7 4 X",
+		"caches 0",
+		"rewind 9",
+		"rewind 3",
+		"step 1",
+		"bus 2",
+		"metrics",
+		"walk",
+		"history",
+		"quit",
+	}
+	console.Run(strings.NewReader(strings.Join(script, "\n") + "\nmemory\n"))
+	text := output.String()
+
+	expected := []string{
+		"step: there is no system, use init",
+		"Started a MOESI system with 2 PEs",
+		"PE0 register 6",
+		"step: PE0 is not available",
+		"Every PE finished",
+		"7",
+		"set: invalid state \"X\"",
+		"7:4 M",
+		"rewind: the history goes from step 0 to step 6",
+		"Back at step 3",
+		"PE1 register 6 (Free)",
+		"unknown command \"walk\"",
+		"   6  step 0 3",
+	}
+	for _, value := range expected {
+		if !strings.Contains(text, value) {
+			t.Errorf("Expected %q in the output of the console:\n%s", value, text)
+		}
+	}
+	if !quitted || strings.Count(text, "Main Memory") != 1 {
+		t.Errorf("Expected the console to end at quit:\n%s", text)
+	}
+}
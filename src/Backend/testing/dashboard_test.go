@@ -0,0 +1,56 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"Backend/components/CLI"
+)
+
+// Test the keyboard shortcuts and the screen of the terminal dashboard
+func TestDashboard(t *testing.T) {
+	fmt.Println("Starting Unit Test for the terminal dashboard")
+	program := writeFile(t, t.TempDir(), "increment.txt", "READ 2\nINC\nWRITE 2\n")
+	spec := cli.DefaultRunSpec()
+	spec.Protocol, spec.Cores, spec.Programs, spec.Memory = "MESI", 2, []string{program, program}, []uint32{0, 0, 5}
+	cfg, err := spec.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.LogDirectory = t.TempDir()
+
+	screen := &bytes.Buffer{}
+	dashboard := cli.NewDashboard(screen, spec, cfg)
+	dashboard.Colors = false
+	if err := dashboard.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer dashboard.Stop()
+
+	// PE0 runs its program with its shortcut, PE1 with the scheduler
+	for _, key := range []byte("000" + "5" + "     ") {
+		if !dashboard.Key(key) {
+			t.Fatalf("The key %q left the dashboard", key)
+		}
+	}
+	dashboard.Render()
+	text := screen.String()
+	expected := []string{"MESI SC, 2 PEs, seed 1", "PE0  R=6", "PE1  R=7", "2:7", "Every PE finished", "Bus log", "r run/pause"}
+	for _, value := range expected {
+		if !strings.Contains(text, value) {
+			t.Errorf("Expected %q on the screen:\n%s", value, text)
+		}
+	}
+
+	// The colours mark the states and q leaves the dashboard
+	dashboard.Colors = true
+	dashboard.Render()
+	if !strings.Contains(screen.String(), "\x1b[1;31mM") {
+		t.Errorf("Expected the modified block in red:\n%s", screen.String())
+	}
+	if dashboard.Key('q') {
+		t.Errorf("Expected q to leave the dashboard")
+	}
+}
@@ -0,0 +1,190 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"Backend/components/RESTfulAPI"
+	"Backend/utils"
+)
+
+// Test the kept events, the resume of a subscription and the drop of a slow subscriber
+func TestEventStream(t *testing.T) {
+	fmt.Println("Starting Unit Test for the event stream")
+	stream := utils.NewEventStream(3)
+	for i := 0; i < 5; i++ {
+		stream.Publish(utils.EventMemoryAccess, i)
+	}
+	if stream.Last() != 5 {
+		t.Errorf("Expected 5 events, got %d", stream.Last())
+	}
+
+	// Only the last three events are kept
+	backlog, _, cancel, complete := stream.Subscribe(3)
+	if !complete || len(backlog) != 2 || backlog[0].Sequence != 4 {
+		t.Errorf("Expected to resume after the event 3, got %v (complete %v)", backlog, complete)
+	}
+	cancel()
+	if _, _, cancel, complete := stream.Subscribe(1); complete {
+		t.Errorf("Expected the events 2 to 5 to be incomplete")
+	} else {
+		cancel()
+	}
+
+	// A subscriber that doesn't read is dropped with its channel closed
+	_, channel, cancel, _ := stream.Subscribe(stream.Last())
+	defer cancel()
+	for i := 0; i < 1000; i++ {
+		stream.Publish(utils.EventMemoryAccess, i)
+	}
+	received := 0
+	for range channel {
+		received++
+	}
+	if received == 0 || received >= 1000 {
+		t.Errorf("Expected the slow subscriber to be dropped, it received %d events", received)
+	}
+}
+
+// Test the events published by a seeded run of a system
+func TestSystemEvents(t *testing.T) {
+	fmt.Println("Starting Unit Test for the events of the simulation")
+	mps, channel := startSystem(t, systemOptions{Programs: [][]string{{"READ 2", "INC", "WRITE 2"}, {"READ 2", "INC", "WRITE 2"}}})
+	if complete, err := mps.RunSeeded(1, 1000); err != nil || !complete {
+		t.Fatalf("Expected the run to complete: %v", err)
+	}
+
+	counts := map[string]int{}
+	var last utils.Event
+	for last.Type != utils.EventRunFinished {
+		select {
+		case last = <-channel:
+			counts[last.Type]++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected a run-finished event, got %v", counts)
+		}
+	}
+	if counts[utils.EventInstructionStart] != 6 || counts[utils.EventInstructionFinish] != 6 {
+		t.Errorf("Expected 6 instructions, got %v", counts)
+	}
+	if counts[utils.EventBusTransaction] != len(mps.Recorder.BusTransactions) || counts[utils.EventCacheState] == 0 || counts[utils.EventMemoryAccess] == 0 {
+		t.Errorf("Expected the bus, cache and memory events, got %v", counts)
+	}
+	if results, ok := last.Data.(utils.MultiprocessingSystemResults); !ok || results.CacheHits+results.CacheMisses != 4 {
+		t.Errorf("Expected the results in the run-finished event, got %+v", last.Data)
+	}
+}
+
+// Test the WebSocket stream of the REST API: snapshot, live events and resume
+func TestEventsWebSocket(t *testing.T) {
+	fmt.Println("Starting Unit Test for the WebSocket event stream")
+	server := httptest.NewServer(http.HandlerFunc(restfulapi.GetEvents))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	// The server works in its folder, the saved programs and the logs go to a temporary one
+	working, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(working)
+	os.Mkdir("programs", 0755)
+	os.WriteFile("programs/count.txt", []byte("INC\nINC\n"), 0644)
+
+	request := httptest.NewRequest("POST", "/setinitialize", bytes.NewBufferString(`{"type": "MESI", "cores": 2, "programs": ["count", "count"]}`))
+	recorder := httptest.NewRecorder()
+	restfulapi.SetInitialize(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected the system to start: %s", recorder.Body.String())
+	}
+	defer restfulapi.SetLj(httptest.NewRecorder(), httptest.NewRequest("POST", "/setlj", bytes.NewBufferString(`{"action": "close"}`)))
+
+	connection, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connection.Close()
+	first := readEvent(t, connection)
+	if first.Type != utils.EventSnapshot || first.Data.(map[string]interface{})["PEs"] == nil {
+		t.Fatalf("Expected a snapshot first, got %+v", first)
+	}
+
+	// A step of a PE reaches the stream
+	step := httptest.NewRequest("POST", "/setaction", bytes.NewBufferString(`{"action": "step", "number": "0"}`))
+	restfulapi.SetAction(httptest.NewRecorder(), step)
+	event := readEvent(t, connection)
+	if event.Type != utils.EventInstructionStart || event.Sequence != first.Sequence+1 {
+		t.Errorf("Expected the start of the instruction after the snapshot, got %+v", event)
+	}
+
+	// A resumed stream starts after the given event without a snapshot
+	resumed, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("%s?after=%d", url, first.Sequence), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	if again := readEvent(t, resumed); again.Sequence != event.Sequence || again.Type != event.Type {
+		t.Errorf("Expected the resumed stream to repeat %+v, got %+v", event, again)
+	}
+	if response, err := http.Get(server.URL + "?after=x"); err != nil || response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected an invalid after to be rejected")
+	}
+
+	// The system stops once the step finishes
+	for event.Type != utils.EventInstructionFinish {
+		event = readEvent(t, connection)
+	}
+}
+
+// Test that the WebSocket stream only accepts the origins allowed by CORS
+func TestEventsOrigin(t *testing.T) {
+	fmt.Println("Starting Unit Test for the origins of the WebSocket event stream")
+	cfg := restfulapi.DefaultServerConfig()
+	cfg.AllowedOrigins = []string{"http://localhost:3000"}
+	if _, err := restfulapi.NewServer(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer restfulapi.NewServer(restfulapi.DefaultServerConfig())
+	server := httptest.NewServer(http.HandlerFunc(restfulapi.GetEvents))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	// A client without Origin isn't a browser, the allowed origin is the frontend
+	for _, origin := range []string{"", "http://localhost:3000"} {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		connection, _, err := websocket.DefaultDialer.Dial(url, header)
+		if err != nil {
+			t.Fatalf("Expected the origin %q to be accepted: %v", origin, err)
+		}
+		connection.Close()
+	}
+
+	header := http.Header{"Origin": {"http://attacker.example"}}
+	connection, response, err := websocket.DefaultDialer.Dial(url, header)
+	if err == nil {
+		connection.Close()
+		t.Fatal("Expected the other origins to be rejected")
+	}
+	if response == nil || response.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for another origin, got %v", response)
+	}
+}
+
+// Function to read the next event of a WebSocket
+func readEvent(t *testing.T, connection *websocket.Conn) utils.Event {
+	connection.SetReadDeadline(time.Now().Add(5 * time.Second))
+	event := utils.Event{}
+	if err := connection.ReadJSON(&event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
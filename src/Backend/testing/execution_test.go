@@ -0,0 +1,210 @@
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/components/RESTfulAPI"
+	"Backend/utils"
+)
+
+// Function to wait for the execution event with one of the states given
+func waitForExecution(t *testing.T, channel <-chan utils.Event, states ...string) utils.ExecutionState {
+	t.Helper()
+	for {
+		select {
+		case event := <-channel:
+			execution, ok := event.Data.(utils.ExecutionState)
+			for _, state := range states {
+				if ok && event.Type == utils.EventExecution && execution.State == state {
+					return execution
+				}
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the execution to be %v", states)
+		}
+	}
+}
+
+// Test the limits of a free-running execution, stepping while it's paused and resuming it to the end
+func TestExecutionLimits(t *testing.T) {
+	fmt.Println("Starting Unit Test for the limits of the execution")
+	program := []string{"INC", "INC", "INC", "INC", "WRITE 1"}
+	mps, channel := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+
+	if err := mps.Run(MultiprocessingSystem.RunLimit{Instructions: 3}); err != nil {
+		t.Fatal(err)
+	}
+	paused := waitForExecution(t, channel, MultiprocessingSystem.ExecutionPaused)
+	if paused.Instructions != 3 || paused.Cycles != 1 {
+		t.Errorf("Expected a pause after 3 instructions and 1 cycle, got %+v", paused)
+	}
+	for _, pe := range mps.State().PEs {
+		if pe.Status != "Paused" {
+			t.Errorf("Expected PE%d to show the pause, got %q", pe.ID, pe.Status)
+		}
+	}
+	if err := mps.Pause(); err == nil {
+		t.Errorf("Expected an error pausing a paused execution")
+	}
+	if err := mps.StepAndWait(1); err != nil {
+		t.Errorf("Expected a paused system to be stepped by hand: %v", err)
+	}
+
+	if err := mps.Run(MultiprocessingSystem.RunLimit{Cycles: 1}); err != nil {
+		t.Fatal(err)
+	}
+	paused = waitForExecution(t, channel, MultiprocessingSystem.ExecutionPaused)
+	if paused.Cycles != 2 || paused.CycleLimit != 1 {
+		t.Errorf("Expected a pause after the next cycle, got %+v", paused)
+	}
+
+	if err := mps.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	waitForExecution(t, channel, MultiprocessingSystem.ExecutionFinished)
+	if !mps.AreWeFinished() || mps.Execution().State != MultiprocessingSystem.ExecutionFinished {
+		t.Errorf("Expected every PE to finish, got %+v", mps.Execution())
+	}
+	for _, pe := range mps.ProcessingElements {
+		if pe.Register != 4 {
+			t.Errorf("Expected PE%d to execute its 4 increments, got %d", pe.ID, pe.Register)
+		}
+	}
+	if err := mps.Run(MultiprocessingSystem.RunLimit{}); err == nil {
+		t.Errorf("Expected an error running a finished system")
+	}
+}
+
+// Test that a running system can't be stepped by hand and that a cancelled one can
+func TestExecutionCancel(t *testing.T) {
+	fmt.Println("Starting Unit Test for the cancellation of the execution")
+	program := []string{"INC", "INC", "INC"}
+	mps, channel := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+
+	// The long delay keeps the execution waiting after its first cycle
+	if err := mps.Run(MultiprocessingSystem.RunLimit{Delay: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	waitForExecution(t, channel, MultiprocessingSystem.ExecutionRunning)
+	if err := mps.StepAndWait(0); err == nil {
+		t.Errorf("Expected an error stepping a running system")
+	}
+	if err := mps.Run(MultiprocessingSystem.RunLimit{}); err == nil {
+		t.Errorf("Expected an error running a running system")
+	}
+	if err := mps.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if execution := mps.Execution(); execution.State != MultiprocessingSystem.ExecutionCancelled || mps.Running() {
+		t.Errorf("Expected the execution to be cancelled, got %+v", execution)
+	}
+	if err := mps.StepAndWait(0); err != nil {
+		t.Errorf("Expected a cancelled system to be stepped by hand: %v", err)
+	}
+	if err := mps.Resume(); err == nil {
+		t.Errorf("Expected an error resuming a cancelled execution")
+	}
+}
+
+// Test the execution route of the API v1
+func TestExecutionRoute(t *testing.T) {
+	fmt.Println("Starting Unit Test for the execution route")
+	server := httptest.NewServer(restfulapi.Router())
+	defer server.Close()
+
+	var created struct{ ID string }
+	if status := request(t, server, "POST", "/v1/systems", `{"cores": 2, "sources": ["INC\nINC\nINC", "INC\nINC\nINC"]}`, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the system to be created, got %d", status)
+	}
+	defer request(t, server, "DELETE", "/v1/systems/"+created.ID, "", nil)
+	path := "/v1/systems/" + created.ID + "/execution"
+
+	var execution utils.ExecutionState
+	if status := request(t, server, "POST", path, `{"action": "run", "instructions": 2, "delay": 1}`, &execution); status != http.StatusOK {
+		t.Fatalf("Expected the execution to start, got %d", status)
+	}
+	for deadline := time.Now().Add(5 * time.Second); execution.State != MultiprocessingSystem.ExecutionPaused; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the execution to pause after 2 instructions, got %+v", execution)
+		}
+		time.Sleep(10 * time.Millisecond)
+		request(t, server, "GET", path, "", &execution)
+	}
+	if execution.Instructions != 2 {
+		t.Errorf("Expected 2 instructions, got %+v", execution)
+	}
+	if status, response := requestError(t, server, "POST", path, `{"action": "pause"}`); status != http.StatusConflict || response.Error.Code != "conflict" {
+		t.Errorf("Expected a conflict pausing a paused execution, got %d %+v", status, response)
+	}
+	if status, response := requestError(t, server, "POST", path, `{"action": "jump"}`); status != http.StatusBadRequest || response.Error.Code != "validation_failed" {
+		t.Errorf("Expected an unknown action to be rejected, got %d %+v", status, response)
+	}
+	if status := request(t, server, "POST", path, `{"action": "cancel"}`, &execution); status != http.StatusOK || execution.State != MultiprocessingSystem.ExecutionCancelled {
+		t.Errorf("Expected the execution to be cancelled, got %d %+v", status, execution)
+	}
+	var resource struct{ Execution utils.ExecutionState }
+	request(t, server, "GET", "/v1/systems/"+created.ID, "", &resource)
+	if resource.Execution.State != MultiprocessingSystem.ExecutionCancelled {
+		t.Errorf("Expected the system to show its execution, got %+v", resource.Execution)
+	}
+}
+
+// Test that the state read while the system runs is always taken between two instructions
+func TestExecutionStateSnapshot(t *testing.T) {
+	fmt.Println("Starting Unit Test for the state of a running system")
+	program := []string{}
+	for i := 0; i < 200; i++ {
+		program = append(program, "INC")
+	}
+	mps, _ := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+
+	if err := mps.Run(MultiprocessingSystem.RunLimit{}); err != nil {
+		t.Fatal(err)
+	}
+	for mps.Running() {
+		// Every increment that left the queue of a PE is already in its register
+		for _, pe := range mps.StateSnapshot().PEs {
+			if pe.Register+len(pe.Instructions) != len(program) {
+				t.Fatalf("PE%d: %d increments done and %d left in the middle of an instruction", pe.ID, pe.Register, len(pe.Instructions))
+			}
+		}
+	}
+	if execution := mps.Execution(); execution.State != MultiprocessingSystem.ExecutionFinished {
+		t.Errorf("Expected every PE to finish, got %+v", execution)
+	}
+}
+
+// Test that the state read while the PEs are stepped by hand is also taken between two instructions
+func TestExecutionManualSteps(t *testing.T) {
+	fmt.Println("Starting Unit Test for the state of a system stepped by hand")
+	program := []string{}
+	for i := 0; i < 50; i++ {
+		program = append(program, "INC")
+	}
+	mps, _ := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+
+	deadline := time.After(time.Minute)
+	for !mps.AreWeFinished() {
+		select {
+		case <-deadline:
+			t.Fatal("Expected the PEs stepped by hand to finish")
+		default:
+		}
+		// A PE still executing its last step is not available, it's stepped again later
+		for id := range mps.ProcessingElements {
+			if _, err := mps.SteppingProcessingElement(id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, pe := range mps.StateSnapshot().PEs {
+			if pe.Register+len(pe.Instructions) != len(program) {
+				t.Fatalf("PE%d: %d increments done and %d left in the middle of an instruction", pe.ID, pe.Register, len(pe.Instructions))
+			}
+		}
+	}
+}
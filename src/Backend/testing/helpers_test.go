@@ -0,0 +1,55 @@
+package testing
+
+import (
+	"testing"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Changes of a system started by a test, the zero value starts the default configuration
+type systemOptions struct {
+	Config     *MultiprocessingSystem.Config // Configuration to start from, like the one of a checkpoint, the default one when nil
+	Protocol   string                        // Coherence protocol, empty keeps the one of the configuration
+	Programs   [][]string                    // Program of every PE, nil keeps the programs of the configuration
+	ZeroMemory bool                          // The Main Memory starts at zero instead of random values
+	Invariants bool                          // Check the coherence invariants after every access
+}
+
+// Function to start a system for a test without latencies, console messages or logs shared with other tests.
+// The system has one core for every program or trace, it stops at the end of the test and the channel gets its events.
+func startSystem(t *testing.T, options systemOptions) (*MultiprocessingSystem.MultiprocessingSystem, <-chan utils.Event) {
+	t.Helper()
+	cfg := MultiprocessingSystem.DefaultConfig()
+	if options.Config != nil {
+		cfg = *options.Config
+	}
+	if options.Protocol != "" {
+		cfg.Protocol = options.Protocol
+	}
+	if options.Programs != nil {
+		cfg.Programs = options.Programs
+	}
+	if cfg.Programs != nil {
+		cfg.Cores = len(cfg.Programs)
+	}
+	if cfg.Traces != nil {
+		cfg.Cores = len(cfg.Traces)
+	}
+	if options.ZeroMemory {
+		cfg.InitialMemory = make([]uint32, MultiprocessingSystem.MemorySize)
+	}
+	cfg.CheckInvariants = cfg.CheckInvariants || options.Invariants
+	cfg.LogDirectory = t.TempDir()
+	cfg.Latency = utils.NoLatencies()
+	cfg.Quiet = true
+	cfg.Events = utils.NewEventStream(1000)
+	_, channel, cancel, _ := cfg.Events.Subscribe(0)
+	t.Cleanup(cancel)
+	mps, err := MultiprocessingSystem.StartWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mps.Stop)
+	return mps, channel
+}
@@ -0,0 +1,259 @@
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/components/RESTfulAPI"
+	"Backend/utils"
+)
+
+// Registers, cache blocks and memory of a system, to compare them with the states of the history
+type pastState struct {
+	Registers []int
+	Blocks    []string
+	Memory    []int
+}
+
+// Function to take the registers, the cache blocks and the memory of a system between two steps
+func takePastState(mps *MultiprocessingSystem.MultiprocessingSystem) pastState {
+	state := pastState{}
+	for _, pe := range mps.ProcessingElements {
+		state.Registers = append(state.Registers, pe.Register)
+	}
+	for _, cc := range mps.CacheControllers {
+		for i := 0; i < cc.Cache.Lines(); i++ {
+			state.Blocks = append(state.Blocks, fmt.Sprintf("%d %d %s", cc.Cache.GetAddress(i), cc.Cache.GetData(i), cc.Cache.GetState(i)))
+		}
+	}
+	for _, data := range mps.MainMemory.Data {
+		state.Memory = append(state.Memory, int(data))
+	}
+	return state
+}
+
+// Function to take the same values from a state of the history
+func historyPastState(state utils.HistoryState) pastState {
+	past := pastState{}
+	for _, pe := range state.PEs {
+		past.Registers = append(past.Registers, pe.Register)
+	}
+	for _, block := range state.Blocks {
+		past.Blocks = append(past.Blocks, fmt.Sprintf("%d %d %s", block.Address, block.Data, block.State))
+	}
+	for _, word := range state.Memory {
+		past.Memory = append(past.Memory, word.Data)
+	}
+	return past
+}
+
+// Test that the state at every step of the history is the state the system had after that step
+func TestHistorySteps(t *testing.T) {
+	fmt.Println("Starting Unit Test for the states of the history")
+	program := []string{"INC", "WRITE 1", "READ 2", "INC", "WRITE 2", "READ 1"}
+	mps, _ := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+	states := []pastState{takePastState(mps)}
+	for _, id := range []int{0, 0, 1, 1, 0, 1, 1, 0, 0, 1, 0, 1} {
+		if err := mps.StepAndWait(id); err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, takePastState(mps))
+	}
+
+	steps := mps.History()
+	if len(steps) != len(states)-1 {
+		t.Fatalf("Expected %d steps, got %d", len(states)-1, len(steps))
+	}
+	if steps[1].Core != 0 || steps[1].Position != 1 || steps[1].Instruction != "WRITE 1" {
+		t.Errorf("Expected the second step to be WRITE 1 of PE0, got %+v", steps[1])
+	}
+	for step, expected := range states {
+		state, err := mps.StateAtStep(step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := historyPastState(state); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Step %d: expected %+v, got %+v", step, expected, got)
+		}
+	}
+	if _, err := mps.StateAtStep(len(states)); err == nil {
+		t.Errorf("Expected an error for a step after the last one")
+	}
+
+	// The WRITE of PE0 takes the block of address 1 to M in its cache
+	diff, err := mps.DiffSteps(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, change := range diff.Changes {
+		if change.Component == "CC0" && change.After == "Address 1, Data 1, State M" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the block of address 1 to change to M in CC0, got %+v", diff.Changes)
+	}
+	if diff, _ := mps.DiffSteps(3, 3); len(diff.Changes) != 0 {
+		t.Errorf("Expected no changes between a step and itself, got %+v", diff.Changes)
+	}
+}
+
+// Test that the drains of the store buffers and the steps of the execution are part of the history
+func TestHistoryStoreBuffers(t *testing.T) {
+	fmt.Println("Starting Unit Test for the history of the store buffers")
+	cfg := MultiprocessingSystem.DefaultConfig()
+	cfg.Consistency = "TSO"
+	mps, _ := startSystem(t, systemOptions{Config: &cfg, Programs: [][]string{{"INC", "WRITE 1", "READ 2"}, {"READ 1"}}})
+	for _, id := range []int{0, 0} {
+		if err := mps.StepAndWait(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mps.DrainAndWait(0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := mps.History()
+	if len(steps) != 3 || steps[2].Instruction != "Drain WRITE 1" {
+		t.Fatalf("Expected the drain to be the third step, got %+v", steps)
+	}
+	buffered, _ := mps.StateAtStep(2)
+	drained, _ := mps.StateAtStep(3)
+	if len(buffered.PEs[0].StoreBuffer) != 1 || len(drained.PEs[0].StoreBuffer) != 0 {
+		t.Errorf("Expected the store to leave the store buffer at step 3, got %+v and %+v", buffered.PEs[0], drained.PEs[0])
+	}
+
+	if finished, err := mps.RunSeeded(3, 100); err != nil || !finished {
+		t.Fatalf("Expected the system to finish, got %v", err)
+	}
+	last, _ := mps.StateAtStep(len(mps.History()))
+	for _, pe := range last.PEs {
+		if !pe.Done {
+			t.Errorf("Expected PE%d to be done at the last step, got %+v", pe.ID, pe)
+		}
+	}
+}
+
+// Test that a system rewound to an earlier step has the state of that step and goes on as if it never left it
+func TestHistoryRewind(t *testing.T) {
+	fmt.Println("Starting Unit Test for the rewind of the history")
+	program := []string{}
+	for i := 0; i < 8; i++ {
+		program = append(program, "INC", fmt.Sprintf("WRITE %d", i%3), fmt.Sprintf("READ %d", (i+1)%3))
+	}
+	schedule := []int{}
+	for i := 0; i < len(program); i++ {
+		schedule = append(schedule, i%2, (i/3)%2)
+	}
+	original, _ := startSystem(t, systemOptions{Programs: [][]string{program, program}})
+	// The twin starts with the same memory and never goes back
+	checkpoint, err := original.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := checkpoint.RestoreConfig()
+	twin, _ := startSystem(t, systemOptions{Config: &restore})
+	states := []pastState{takePastState(original)}
+	for _, id := range schedule[:45] {
+		if err := original.StepAndWait(id); err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, takePastState(original))
+	}
+	for _, id := range schedule {
+		if err := twin.StepAndWait(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The step is after the second snapshot, the rewind replays the steps after it
+	rewound, err := original.Rewind(37)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rewound.Stop)
+	if !original.Stopped() || rewound.Stopped() {
+		t.Fatalf("Expected the rewound system to replace the original")
+	}
+	if got := takePastState(rewound); !reflect.DeepEqual(got, states[37]) {
+		t.Errorf("Expected the state of step 37 %+v, got %+v", states[37], got)
+	}
+	if steps := rewound.History(); len(steps) != 37 || steps[36].Step != 37 {
+		t.Fatalf("Expected the history to end at step 37, got %d steps", len(steps))
+	}
+	for _, id := range schedule[37:] {
+		if err := rewound.StepAndWait(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	compareSystems(t, twin, rewound)
+
+	// A rewound system can go back again, to any step it kept
+	for _, step := range []int{5, 0} {
+		if rewound, err = rewound.Rewind(step); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(rewound.Stop)
+		if got := takePastState(rewound); !reflect.DeepEqual(got, states[step]) {
+			t.Errorf("Expected the state of step %d %+v, got %+v", step, states[step], got)
+		}
+	}
+	if _, err := rewound.Rewind(1); err == nil || rewound.Stopped() {
+		t.Errorf("Expected a step after the last one to be rejected without stopping the system, got %v", err)
+	}
+}
+
+// Test the history routes of the API v1
+func TestHistoryRoutes(t *testing.T) {
+	fmt.Println("Starting Unit Test for the history routes")
+	server := httptest.NewServer(restfulapi.Router())
+	defer server.Close()
+
+	var created struct{ ID string }
+	if status := request(t, server, "POST", "/v1/systems", `{"cores": 2, "sources": ["WRITE 3\nINC", "READ 3"]}`, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the system to be created, got %d", status)
+	}
+	defer request(t, server, "DELETE", "/v1/systems/"+created.ID, "", nil)
+	path := "/v1/systems/" + created.ID
+	request(t, server, "POST", path+"/pes/0/step", "", nil)
+	request(t, server, "POST", path+"/pes/1/step", "", nil)
+
+	var steps []utils.HistoryStep
+	if status := request(t, server, "GET", path+"/history", "", &steps); status != http.StatusOK || len(steps) != 2 {
+		t.Fatalf("Expected 2 steps, got %d %+v", status, steps)
+	}
+	var state utils.HistoryState
+	if status := request(t, server, "GET", path+"/history/1", "", &state); status != http.StatusOK || state.Step != 1 {
+		t.Errorf("Expected the state at step 1, got %d %+v", status, state)
+	}
+	var diff utils.HistoryDiff
+	if status := request(t, server, "GET", path+"/history/diff?from=0&to=2", "", &diff); status != http.StatusOK || len(diff.Changes) == 0 {
+		t.Errorf("Expected the changes of both steps, got %d %+v", status, diff)
+	}
+
+	if status, response := requestError(t, server, "GET", path+"/history/5", ""); status != http.StatusNotFound || response.Error.Code != "not_found" {
+		t.Errorf("Expected a step after the last one to be missing, got %d %+v", status, response)
+	}
+	if status, _ := requestError(t, server, "GET", path+"/history/diff?from=0", ""); status != http.StatusBadRequest {
+		t.Errorf("Expected a diff without to to be rejected, got %d", status)
+	}
+
+	// The rewind goes back to step 1 and the system continues from it
+	if status := request(t, server, "POST", path+"/history/1/rewind", "", nil); status != http.StatusOK {
+		t.Fatalf("Expected the system to go back to step 1, got %d", status)
+	}
+	if status := request(t, server, "GET", path+"/history", "", &steps); status != http.StatusOK || len(steps) != 1 {
+		t.Errorf("Expected 1 step after the rewind, got %d %+v", status, steps)
+	}
+	if status := request(t, server, "POST", path+"/pes/1/step", "", nil); status != http.StatusOK {
+		t.Errorf("Expected PE1 to step after the rewind, got %d", status)
+	}
+	if status, response := requestError(t, server, "POST", path+"/history/5/rewind", ""); status != http.StatusNotFound || response.Error.Code != "not_found" {
+		t.Errorf("Expected a rewind after the last step to be missing, got %d %+v", status, response)
+	}
+}
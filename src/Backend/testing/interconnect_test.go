@@ -4,6 +4,7 @@ import (
 	"testing"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"math/rand"
 	"time"
 	"Backend/utils"
@@ -37,7 +38,7 @@ func TestInterconnectRun(t *testing.T) {
 	// Create 3 threats simulating the Cache Controllers
 	// Create the Bus semaphore
 	semaphore := make(chan struct{}, 1)
-	cacheControllersDone := [3]bool{false, false, false}
+	var cacheControllersDone [3]atomic.Bool
 	for i := 0; i < 3; i++ {
 		requestChannelInterconnect := make(chan utils.RequestInterconnect)
 		responseChannelInterconnect := make(chan utils.ResponseInterconnect)
@@ -106,7 +107,7 @@ func TestInterconnectRun(t *testing.T) {
 					}
 				}
 				// Update the cache controller done status
-				cacheControllersDone[i] = true
+				cacheControllersDone[i].Store(true)
 			}(i)
 			
 			// Create a thread to simulate the broadcast responses
@@ -198,7 +199,7 @@ func TestInterconnectRun(t *testing.T) {
 
 	// Validate if all the requests are managed until the end
 	timeout := time.After(1 * time.Minute) // Set 1 minute of timeout
-	for (!cacheControllersDone[0]) && (!cacheControllersDone[1]) && (!cacheControllersDone[2]) {
+	for (!cacheControllersDone[0].Load()) && (!cacheControllersDone[1].Load()) && (!cacheControllersDone[2].Load()) {
 		select {
 		case <-timeout:
 			t.Fatal("Test timed out")
@@ -220,3 +221,81 @@ func TestInterconnectRun(t *testing.T) {
 	close(responseChannelMainMemory)
 	close(semaphore)
 }
+
+// Test that a bus transaction is counted and recorded before the requester gets its response
+func TestInterconnectRecordsBeforeResponse(t *testing.T) {
+	fmt.Println("Starting Unit Test for the bus transactions recorded by the Interconnect")
+	requestChannelsInterconnect := []chan utils.RequestInterconnect{make(chan utils.RequestInterconnect), make(chan utils.RequestInterconnect)}
+	responseChannelsInterconnect := []chan utils.ResponseInterconnect{make(chan utils.ResponseInterconnect), make(chan utils.ResponseInterconnect)}
+	requestChannelsBroadcast := []chan utils.RequestBroadcast{make(chan utils.RequestBroadcast), make(chan utils.RequestBroadcast)}
+	responseChannelsBroadcast := []chan utils.ResponseBroadcast{make(chan utils.ResponseBroadcast), make(chan utils.ResponseBroadcast)}
+	requestChannelMainMemory := make(chan utils.RequestMainMemory)
+	responseChannelMainMemory := make(chan utils.ResponseMainMemory)
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+
+	// The other Cache Controller never has the address and the Main Memory always has the value 100
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-requestChannelsBroadcast[1]:
+				responseChannelsBroadcast[1] <- utils.ResponseBroadcast{Match: false, Status: "I"}
+			case <-quit:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-requestChannelMainMemory:
+				responseChannelMainMemory <- utils.ResponseMainMemory{Status: true, Value: 100}
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	ic, err := interconnect.New(
+		requestChannelsInterconnect,
+		responseChannelsInterconnect,
+		requestChannelMainMemory,
+		responseChannelMainMemory,
+		requestChannelsBroadcast,
+		responseChannelsBroadcast,
+		"MESI",
+		"../logs/IC/",
+		quit,
+	)
+	if err != nil {
+		t.Fatalf("Error initializing Interconnect: %v\n", err)
+	}
+	ic.Latency = utils.NoLatencies()
+	ic.Recorder = utils.NewTraceRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ic.Run(&wg)
+	}()
+
+	// The requester may finish its step as soon as it has the response, nothing can be missing after it
+	for i := 1; i <= 100; i++ {
+		requestChannelsInterconnect[0] <- utils.RequestInterconnect{Type: "ReadRequest", AR: "DataResponse", Address: 2}
+		if response := <-responseChannelsInterconnect[0]; response.Data != 100 || response.NewStatus != "E" {
+			t.Fatalf("Expected the value 100 in E, got %+v", response)
+		}
+		if ic.DataResponses != i {
+			t.Fatalf("Expected %d data responses counted before the response, got %d", i, ic.DataResponses)
+		}
+		if _, transactions := ic.Recorder.Snapshot(); len(transactions) != i || transactions[i-1].NewStatus != "E" || transactions[i-1].MemoryReads != 1 {
+			t.Fatalf("Expected %d bus transactions recorded before the response, got %+v", i, transactions)
+		}
+	}
+
+	// Close everything
+	close(quit)
+	wg.Wait()
+}
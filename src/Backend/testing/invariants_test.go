@@ -0,0 +1,163 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Backend/components/CLI"
+	"Backend/components/MultiprocessingSystem"
+	"Backend/components/RESTfulAPI"
+	"Backend/utils"
+)
+
+// Test that the invariants hold while both protocols share, upgrade and migrate blocks
+func TestInvariantsHold(t *testing.T) {
+	fmt.Println("Starting Unit Test for the coherence invariants of a valid execution")
+	programs := [][]string{
+		{"READ 1", "INC", "WRITE 1", "READ 2", "WRITE 3", "LL 2", "SC 2"},
+		{"READ 1", "READ 2", "INC", "WRITE 2", "READ 3", "WRITE 1"},
+		{"WRITE 3", "READ 1", "INC", "WRITE 2", "READ 2", "READ 3"},
+	}
+	for _, protocol := range []string{"MESI", "MOESI"} {
+		for seed := int64(1); seed <= 5; seed++ {
+			mps, _ := startSystem(t, systemOptions{Protocol: protocol, Programs: programs, ZeroMemory: true, Invariants: true})
+			if finished, err := mps.RunSeeded(seed, 1000); err != nil || !finished {
+				t.Fatalf("%s seed %d: expected the system to finish, got %v", protocol, seed, err)
+			}
+			if violation := mps.Violation(); violation != nil {
+				t.Errorf("%s seed %d: unexpected violation %+v", protocol, seed, violation)
+			}
+		}
+	}
+}
+
+// Test that a corrupted cache stops the system with a report of the access and the invariants that failed
+func TestInvariantViolation(t *testing.T) {
+	fmt.Println("Starting Unit Test for the coherence invariant violations")
+	mps, _ := startSystem(t, systemOptions{Protocol: "MOESI", Programs: [][]string{{"READ 1", "READ 2", "READ 3"}, {"INC"}}, ZeroMemory: true, Invariants: true})
+	if err := mps.StepAndWait(0); err != nil {
+		t.Fatal(err)
+	}
+
+	// CC1 takes a copy of the address 1 in E with another value, as if it had missed the bus
+	cache := mps.CacheControllers[1].Cache
+	cache.SetAddress(0, 1)
+	cache.SetData(0, 7)
+	cache.SetState(0, "E")
+	err := mps.StepAndWait(0)
+	if err == nil || !strings.Contains(err.Error(), "SingleOwner") {
+		t.Fatalf("Expected the step to stop at a violation, got %v", err)
+	}
+	violation := mps.Violation()
+	if violation == nil || violation.Access.Kind != "READ" || violation.Access.Address != 2 || len(violation.Transactions) != 1 {
+		t.Fatalf("Expected the report of the READ 2, got %+v", violation)
+	}
+	invariants := []string{}
+	for _, failure := range violation.Failures {
+		if failure.Address != 1 || len(failure.Copies) != 2 {
+			t.Errorf("Expected the failures of the address 1 with its 2 copies, got %+v", failure)
+		}
+		invariants = append(invariants, failure.Invariant)
+	}
+	expected := []string{MultiprocessingSystem.InvariantOwner, MultiprocessingSystem.InvariantExclusive, MultiprocessingSystem.InvariantDataValue}
+	if strings.Join(invariants, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected the invariants %v, got %v", expected, invariants)
+	}
+
+	// The system doesn't go on, the step that broke the invariants is in the history
+	if err := mps.StepAndWait(1); err == nil {
+		t.Errorf("Expected a stopped system to refuse a step")
+	}
+	if err := mps.Run(MultiprocessingSystem.RunLimit{}); err == nil {
+		t.Errorf("Expected a stopped system to refuse to run")
+	}
+	if steps := mps.History(); len(steps) != 2 || steps[1].Instruction != "READ 2" {
+		t.Errorf("Expected the READ 2 to be the last step, got %+v", steps)
+	}
+}
+
+// Test that a shared copy upgraded to E without invalidating the other copy is reported
+func TestInvariantUpgrade(t *testing.T) {
+	fmt.Println("Starting Unit Test for the upgrade of a shared copy")
+	mps, _ := startSystem(t, systemOptions{Protocol: "MOESI", Programs: [][]string{{"READ 1", "READ 2"}, {"READ 1", "READ 3"}}, ZeroMemory: true, Invariants: true})
+	for _, id := range []int{0, 1} {
+		if err := mps.StepAndWait(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// CC0 moves its copy from S to E as if the bus had answered E to a cache with sharers
+	cache := mps.CacheControllers[0].Cache
+	block := cache.Find(1)
+	if block == -1 || cache.GetState(block) != "S" {
+		t.Fatalf("Expected CC0 to share the address 1, got the block %d", block)
+	}
+	cache.SetState(block, "E")
+	if err := mps.StepAndWait(0); err == nil {
+		t.Fatalf("Expected the step to stop at a violation")
+	}
+	violation := mps.Violation()
+	if violation == nil || len(violation.Failures) != 1 {
+		t.Fatalf("Expected one failure, got %+v", violation)
+	}
+	if failure := violation.Failures[0]; failure.Invariant != MultiprocessingSystem.InvariantExclusive || failure.Address != 1 || len(failure.Copies) != 2 {
+		t.Errorf("Expected the address 1 in E with a sharer, got %+v", failure)
+	}
+}
+
+// Test that the free-running execution stops at a violation
+func TestInvariantExecution(t *testing.T) {
+	fmt.Println("Starting Unit Test for the execution stopped by a violation")
+	mps, channel := startSystem(t, systemOptions{Protocol: "MESI", Programs: [][]string{{"WRITE 1", "READ 2"}, {"INC", "INC"}}, ZeroMemory: true, Invariants: true})
+
+	// CC1 holds the address 2 with a value that was never stored, the first access finds it
+	cache := mps.CacheControllers[1].Cache
+	cache.SetAddress(0, 2)
+	cache.SetData(0, 9)
+	cache.SetState(0, "E")
+	if err := mps.Run(MultiprocessingSystem.RunLimit{}); err != nil {
+		t.Fatal(err)
+	}
+	execution := waitForExecution(t, channel, MultiprocessingSystem.ExecutionStopped, MultiprocessingSystem.ExecutionFinished)
+	if execution.State != MultiprocessingSystem.ExecutionStopped || mps.Violation() == nil {
+		t.Errorf("Expected the execution to stop at a violation, got %+v", execution)
+	}
+}
+
+// Test the invariants route of the API v1 and the report of the run command
+func TestInvariantRoutes(t *testing.T) {
+	fmt.Println("Starting Unit Test for the invariants route and the run command")
+	server := httptest.NewServer(restfulapi.Router())
+	defer server.Close()
+
+	var created struct{ ID string }
+	if status := request(t, server, "POST", "/v1/systems", `{"cores": 2, "checkInvariants": true, "sources": ["WRITE 3\nREAD 1", "READ 3\nINC\nWRITE 1"]}`, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the system to be created, got %d", status)
+	}
+	defer request(t, server, "DELETE", "/v1/systems/"+created.ID, "", nil)
+	path := "/v1/systems/" + created.ID
+	request(t, server, "POST", path+"/run", "", nil)
+
+	var invariants struct {
+		Enabled   bool
+		Violation *utils.InvariantViolation
+	}
+	if status := request(t, server, "GET", path+"/invariants", "", &invariants); status != http.StatusOK || !invariants.Enabled || invariants.Violation != nil {
+		t.Errorf("Expected the invariants to hold, got %d %+v", status, invariants)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	status := cli.Main([]string{"run", "-check-invariants", "-cores", "2", "-workload", "migratory", "-format", "json"}, stdout, stderr)
+	report := cli.RunReport{}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if (status == cli.ExitSuccess) != (report.Violation == nil) {
+		t.Errorf("Expected the status to tell the violation, got %d with %+v", status, report.Violation)
+	}
+}
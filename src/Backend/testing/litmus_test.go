@@ -0,0 +1,100 @@
+package testing
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"Backend/components/Litmus"
+)
+
+// Test that the reference model permits the classic relaxed outcomes only under the right models
+func TestLitmusReferenceModels(t *testing.T) {
+	fmt.Println("Starting Unit Test for the litmus reference models")
+	expected := map[string]map[string]bool{
+		"SB":   {"SC": false, "TSO": true, "PSO": true},
+		"MP":   {"SC": false, "TSO": false, "PSO": true},
+		"LB":   {"SC": false, "TSO": false, "PSO": false},
+		"IRIW": {"SC": false, "TSO": false, "PSO": false},
+		"2+2W": {"SC": false, "TSO": false, "PSO": true},
+	}
+	for _, test := range litmus.Catalog() {
+		for model, allowed := range expected[test.Name] {
+			conditionAllowed := false
+			outcomes, complete := litmus.Allowed(test, model, 4)
+			if !complete {
+				t.Errorf("%s under %s: expected the enumeration to cover every path", test.Name, model)
+			}
+			for _, outcome := range outcomes {
+				if test.Satisfies(outcome) {
+					conditionAllowed = true
+				}
+			}
+			if conditionAllowed != allowed {
+				t.Errorf("%s under %s: expected allowed=%v, got %v", test.Name, model, allowed, conditionAllowed)
+			}
+		}
+	}
+}
+
+// Test that the simulated system only shows outcomes of its consistency model and shows SB under TSO
+func TestLitmusRun(t *testing.T) {
+	fmt.Println("Starting Unit Test for the litmus runner")
+	test, _ := litmus.Find("SB")
+	for _, model := range []string{"SC", "TSO"} {
+		options := litmus.DefaultOptions()
+		options.Consistency = model
+		options.Runs = 200
+		report, err := litmus.Run(test, options)
+		if err != nil {
+			t.Fatalf("Error running SB under %s: %v", model, err)
+		}
+		if report.Verdict != "Ok" {
+			t.Fatalf("SB under %s observed outcomes the model forbids: %v", model, report.Unexpected)
+		}
+		if report.ConditionObserved != (model == "TSO") {
+			t.Fatalf("SB under %s: the relaxed outcome observed=%v", model, report.ConditionObserved)
+		}
+	}
+}
+
+// Test that an outcome missing from a truncated reference enumeration is reported as inconclusive, not as a violation
+func TestLitmusReferenceTruncated(t *testing.T) {
+	fmt.Println("Starting Unit Test for the truncated litmus reference model")
+	// The program is longer than the steps the reference model explores
+	test, err := litmus.Parse("name LONG\ninit x=0\nP0: " + strings.Repeat("INC; ", 70) + "WRITE x\nexists x=70")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcomes, complete := litmus.Allowed(test, "SC", 4); complete || len(outcomes) != 0 {
+		t.Fatalf("Expected a truncated enumeration without outcomes, got %v and %v", complete, outcomes)
+	}
+
+	options := litmus.DefaultOptions()
+	options.Runs = 2
+	report, err := litmus.Run(test, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ReferenceComplete || report.Verdict != "Inconclusive" || !report.ConditionObserved || len(report.Unexpected) != 1 {
+		t.Errorf("Expected an inconclusive report with the observed outcome, got %+v", report)
+	}
+}
+
+// Test that an initial value given to an address outside the memory is a parse error of its line
+func TestLitmusParseAddresses(t *testing.T) {
+	fmt.Println("Starting Unit Test for the addresses of the litmus tests")
+	for _, init := range []string{"20=1", "16=0", "-1=0"} {
+		_, err := litmus.Parse("name ADDRESS\ninit x=0 " + init + "\nP0: WRITE x\nexists x=0")
+		if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+			t.Errorf("Expected a parse error on line 2 for %s, got %v", init, err)
+		}
+	}
+	test, err := litmus.Parse("name ADDRESS\ninit 15=7\nP0: READ 15\nexists 0:r0=7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if memory := test.InitialMemory(); memory[15] != 7 {
+		t.Errorf("Expected the last word of the memory to start at 7, got %v", memory)
+	}
+}
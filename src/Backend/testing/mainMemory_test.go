@@ -3,6 +3,7 @@ package testing
 import (
 	"testing"
 	"sync"
+	"sync/atomic"
 	"time"
 	"math/rand"
 	"fmt"
@@ -34,12 +35,13 @@ func TestMainMemoryRun(t *testing.T) {
 	}()
 
 	// Start a thread with the simulation of the Interconnect requests
-	counter := 10
+	var counter atomic.Int32
+	counter.Store(10)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		operation := "READ"
-		for counter > 0 {
+		for counter.Load() > 0 {
 			// Create a struct for the main memory requests
 			request := utils.RequestMainMemory{
 				Type: operation,
@@ -58,13 +60,13 @@ func TestMainMemoryRun(t *testing.T) {
 				operation = "READ"
 			}
 			// Decrease the counter
-			counter--
+			counter.Add(-1)
 		}
 	}()
 
 	// Validate if all the requests are managed until the end
 	timeout := time.After(1 * time.Minute) // Set 1 minute of timeout
-	for counter > 0 {
+	for counter.Load() > 0 {
 		select {
 		case <-timeout:
 			t.Fatal("Test timed out")
@@ -0,0 +1,87 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"Backend/components/CLI"
+	"Backend/components/MultiprocessingSystem"
+	"Backend/utils"
+)
+
+// Test that the invalidations and the writes to shared copies are coherence misses of the word shared
+func TestMissesCoherence(t *testing.T) {
+	fmt.Println("Starting Unit Test for the coherence misses")
+	for _, protocol := range []string{"MESI", "MOESI"} {
+		mps, _ := startSystem(t, systemOptions{Protocol: protocol, Programs: [][]string{{"READ 1", "WRITE 1", "READ 1"}, {"READ 1", "READ 1"}}, ZeroMemory: true, Invariants: true})
+		// PE1 shares the block of PE0, PE0 upgrades its copy and PE1 reads it again after the invalidation
+		for _, id := range []int{0, 1, 0, 1, 0} {
+			if err := mps.StepAndWait(id); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		results := mps.Results()
+		coherence := utils.MissCounts{Compulsory: 1, Coherence: 1, TrueSharing: 1}
+		for _, cache := range results.CacheMissCounts[:2] {
+			if cache.MissCounts != coherence || cache.Misses != 2 || len(cache.Addresses) != 1 || cache.Addresses[0].Address != 1 {
+				t.Errorf("%s: expected a compulsory and a coherence miss in CC%d, got %+v", protocol, cache.Cache, cache)
+			}
+		}
+		// The last READ of PE0 finds its own copy, the write to the shared copy is not a hit
+		if results.CacheHits != 1 || results.CacheMisses != 4 {
+			t.Errorf("%s: expected 1 hit and 4 misses, got %d and %d", protocol, results.CacheHits, results.CacheMisses)
+		}
+		total := utils.MissCounts{Compulsory: 2, Coherence: 2, TrueSharing: 2}
+		if results.Misses != total || len(results.AddressMissCounts) != 1 || results.AddressMissCounts[0].MissCounts != total {
+			t.Errorf("%s: expected the misses of both caches in the address 1, got %+v and %+v", protocol, results.Misses, results.AddressMissCounts)
+		}
+	}
+}
+
+// Test that the replacements are conflict misses when a fully associative cache would keep the block, capacity misses otherwise
+func TestMissesReplacement(t *testing.T) {
+	fmt.Println("Starting Unit Test for the capacity and conflict misses")
+	cfg := MultiprocessingSystem.DefaultConfig()
+	cfg.CacheLines = 2
+	cfg.CacheWays = 1
+	mps, _ := startSystem(t, systemOptions{Config: &cfg, Protocol: "MESI", Programs: [][]string{{"READ 0", "READ 2", "READ 0", "READ 1", "READ 4", "READ 0", "READ 1"}}, ZeroMemory: true, Invariants: true})
+	for step := 0; step < 7; step++ {
+		if err := mps.StepAndWait(0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results := mps.Results()
+	expected := utils.MissCounts{Compulsory: 4, Capacity: 1, Conflict: 1}
+	if results.Misses != expected || results.CacheHits != 1 {
+		t.Fatalf("Expected %+v and 1 hit, got %+v and %d hits", expected, results.Misses, results.CacheHits)
+	}
+	addresses := results.CacheMissCounts[0].Addresses
+	if len(addresses) != 4 || addresses[0].Address != 0 || addresses[0].MissCounts != (utils.MissCounts{Compulsory: 1, Capacity: 1, Conflict: 1}) {
+		t.Errorf("Expected the address 0 to have a miss of every kind of replacement, got %+v", addresses)
+	}
+
+	// The run command reports the misses of every cache
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cli.Main([]string{"run", "-cores", "2", "-workload", "migratory"}, stdout, stderr)
+	if !strings.Contains(stdout.String(), "Misses by kind:") || !strings.Contains(stdout.String(), "CC1 ") {
+		t.Errorf("Expected the misses by kind in the report, got %s", stdout.String())
+	}
+
+	// The words of the false-sharing hot spot never share a block of one word, there are no coherence misses
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	cli.Main([]string{"run", "-cores", "3", "-workload", "false-sharing", "-format", "json"}, stdout, stderr)
+	report := struct {
+		Results utils.MultiprocessingSystemResults
+	}{}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Expected the report of the run: %v %s", err, stderr.String())
+	}
+	if misses := report.Results.Misses; misses.Compulsory == 0 || misses.Coherence != 0 || misses.FalseSharing != 0 {
+		t.Errorf("Expected only compulsory misses with blocks of one word, got %+v", misses)
+	}
+}
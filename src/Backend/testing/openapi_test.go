@@ -0,0 +1,120 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Backend/components/RESTfulAPI"
+)
+
+// Body of the error answers of the API
+type apiErrorResponse struct {
+	Error struct {
+		Code    string
+		Message string
+		Details []struct {
+			Field   string
+			Message string
+		}
+		Diagnostics []struct {
+			Line    int
+			Column  int
+			Message string
+		}
+	}
+}
+
+// Test the published OpenAPI document and the validation of the requests against it
+func TestOpenAPIValidation(t *testing.T) {
+	fmt.Println("Starting Unit Test for the OpenAPI document and the request validation")
+	server := httptest.NewServer(restfulapi.Router())
+	defer server.Close()
+
+	// The document is served and describes both APIs
+	response, err := http.Get(server.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&document); err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if document.OpenAPI != "3.0.3" || document.Paths["/setinitialize"] == nil || document.Paths["/v1/systems/{id}/pes/{pe}/step"] == nil {
+		t.Errorf("Expected the OpenAPI document of the API, got version %q with %d paths", document.OpenAPI, len(document.Paths))
+	}
+
+	// Every invalid request answers with the same error object
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{"wrong type", "POST", "/v1/systems", `{"cores": "3"}`, 400, "validation_failed", "body.cores"},
+		{"out of range", "POST", "/v1/systems", `{"workload": {"readRatio": 2}}`, 400, "validation_failed", "body.workload.readRatio"},
+		{"unknown field", "POST", "/v1/systems", `{"protocols": "MESI"}`, 400, "validation_failed", "body.protocols"},
+		{"not an integer", "POST", "/v1/systems", `{"memory": [1.5]}`, 400, "validation_failed", "body.memory[0]"},
+		{"missing field", "POST", "/setlj", `{}`, 400, "validation_failed", "body.action"},
+		{"missing body", "POST", "/setaction", ``, 400, "validation_failed", "body"},
+		{"legacy type", "POST", "/setaction", `{"action": "step", "number": 0}`, 400, "validation_failed", "body.number"},
+		{"enum", "POST", "/setinitialize", `{"type": "MSI"}`, 400, "validation_failed", "body.type"},
+		{"query", "GET", "/trace/bus?format=xml", ``, 400, "validation_failed", "query.format"},
+		{"path", "GET", "/v1/systems/missing/pes/first", ``, 400, "validation_failed", "path.pe"},
+		{"invalid JSON", "POST", "/compare", `{"cores": `, 400, "invalid_json", ""},
+		{"trailing data", "POST", "/litmus", `{} {}`, 400, "invalid_json", ""},
+		{"invalid program", "POST", "/v1/systems", `{"cores": 1, "sources": ["INC\nJUMP 1"]}`, 400, "invalid_program", ""},
+		{"invalid configuration", "POST", "/v1/systems", `{"protocol": "msi"}`, 400, "invalid_request", ""},
+		{"unknown system", "GET", "/v1/systems/missing", ``, 404, "not_found", ""},
+		{"unknown route", "GET", "/missing", ``, 404, "not_found", ""},
+		{"wrong method", "DELETE", "/litmus", ``, 405, "method_not_allowed", ""},
+	}
+	for _, test := range tests {
+		status, body := requestError(t, server, test.method, test.path, test.body)
+		if status != test.status || body.Error.Code != test.code || body.Error.Message == "" {
+			t.Errorf("%s: expected %d %s, got %d %+v", test.name, test.status, test.code, status, body.Error)
+			continue
+		}
+		if test.field != "" && (len(body.Error.Details) == 0 || body.Error.Details[0].Field != test.field) {
+			t.Errorf("%s: expected a problem in %s, got %+v", test.name, test.field, body.Error.Details)
+		}
+		if test.code == "invalid_program" && (len(body.Error.Diagnostics) == 0 || body.Error.Diagnostics[0].Line != 2) {
+			t.Errorf("%s: expected the diagnostics of the program, got %+v", test.name, body.Error.Diagnostics)
+		}
+	}
+
+	// A valid request still reaches its handler with the whole body
+	var created struct{ ID string }
+	if status := request(t, server, "POST", "/v1/systems", `{"cores": 1, "sources": ["INC"], "workload": {"preset": "uniform"}}`, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the system to be created, got %d", status)
+	}
+	if status := request(t, server, "POST", "/v1/systems/"+created.ID+"/run", "", nil); status != http.StatusOK {
+		t.Errorf("Expected a run without body to be valid, got %d", status)
+	}
+	request(t, server, "DELETE", "/v1/systems/"+created.ID, "", nil)
+}
+
+// Function to send a request that fails and decode its error
+func requestError(t *testing.T, server *httptest.Server, method string, path string, body string) (int, apiErrorResponse) {
+	req, _ := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	result := apiErrorResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		t.Errorf("%s %s: the error is not JSON: %v", method, path, err)
+	}
+	return response.StatusCode, result
+}
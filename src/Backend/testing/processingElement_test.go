@@ -67,11 +67,11 @@ func TestProcessingElementRun(t *testing.T) {
 		case <-timeout:
 			t.Fatal("Test timed out")
 		case <-ticker.C:
-			if !pe.IsDone && !pe.IsExecutingInstruction {
+			if !pe.Finished() && !pe.Executing() {
 				// Send a control signal to the Processing Element
 				pe.Control <- true
 			}
-			if pe.IsDone {
+			if pe.Finished() {
 				done = true
 				// Close everything
 				close(quit)
@@ -86,3 +86,115 @@ func TestProcessingElementRun(t *testing.T) {
 	// Stop the ticker when done
 	ticker.Stop()
 }
+
+// Test that under TSO the stores wait in the store buffer, loads are forwarded and FENCE drains them
+func TestProcessingElementStoreBuffer(t *testing.T) {
+	fmt.Println("Starting Unit Test for the store buffer of the Processing Element Component")
+	dir := t.TempDir()
+	filename := dir + "/program.txt"
+	program := "INC\nWRITE 5\nREAD 5\nREAD 6\nFENCE"
+	if err := os.WriteFile(filename, []byte(program), 0644); err != nil {
+		t.Fatalf("Could not create the program: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	requestChannel := make(chan utils.RequestProcessingElement)
+	responseChannel := make(chan utils.ResponseProcessingElement)
+	pe, err := processingElement.New(0, requestChannel, responseChannel, filename, dir+"/PE", quit)
+	if err != nil {
+		t.Fatalf("Error creating ProcessingElement: %v", err)
+	}
+	pe.Consistency = "TSO"
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pe.Run(&wg)
+	}()
+
+	// Simulate the private Cache Controller and keep every request it receives
+	requests := make(chan utils.RequestProcessingElement, 10)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-quit:
+				return
+			case request := <-requestChannel:
+				requests <- request
+				responseChannel <- utils.ResponseProcessingElement{Data: 10, Status: true}
+			}
+		}
+	}()
+
+	timeout := time.After(1 * time.Minute)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for !pe.Finished() {
+		select {
+		case <-timeout:
+			t.Fatal("Test timed out")
+		case <-ticker.C:
+			if !pe.Finished() && !pe.Executing() {
+				pe.Control <- true
+			}
+		}
+	}
+	close(quit)
+	wg.Wait()
+	pe.Logger.Writer().(*os.File).Close()
+
+	// Only the READ 6 and the drained WRITE 5 reach the Cache Controller
+	close(requests)
+	received := []utils.RequestProcessingElement{}
+	for request := range requests {
+		received = append(received, request)
+	}
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 requests to the Cache Controller, got %v", received)
+	}
+	if received[0].Type != "READ" || received[0].Address != 6 {
+		t.Fatalf("Expected READ 6 first, got %v", received[0])
+	}
+	if received[1].Type != "WRITE" || received[1].Address != 5 || received[1].Data != 1 {
+		t.Fatalf("Expected the buffered WRITE 5 with data 1, got %v", received[1])
+	}
+	if len(pe.StoreBuffer) != 0 {
+		t.Fatalf("The store buffer should be empty after FENCE, it has %d stores", len(pe.StoreBuffer))
+	}
+}
+
+// Test that the status can be read while the Processing Element waits for its Cache Controller
+func TestProcessingElementStatus(t *testing.T) {
+	fmt.Println("Starting Unit Test for the status of the Processing Element Component")
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	requestChannel := make(chan utils.RequestProcessingElement)
+	responseChannel := make(chan utils.ResponseProcessingElement)
+	pe, err := processingElement.NewWithProgram(0, requestChannel, responseChannel, []string{"READ 3"}, dir+"/PE", quit)
+	if err != nil {
+		t.Fatalf("Error creating ProcessingElement: %v", err)
+	}
+	defer pe.Logger.Writer().(*os.File).Close()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pe.Run(&wg)
+	}()
+
+	// The request reached the Cache Controller, the PE shows it until the response arrives
+	pe.Control <- true
+	<-requestChannel
+	if status := pe.GetStatus(); status != "Executing READ 3" {
+		t.Errorf("Expected the PE to show the READ it's waiting for, got %q", status)
+	}
+	responseChannel <- utils.ResponseProcessingElement{Data: 10, Status: true}
+	<-pe.Completed
+	if status := pe.GetStatus(); status != "Done" {
+		t.Errorf("Expected the PE to be done, got %q", status)
+	}
+	close(quit)
+	wg.Wait()
+}
@@ -0,0 +1,74 @@
+package testing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/components/ProgramLibrary"
+	"Backend/utils"
+)
+
+// Test that programs can be saved, listed, fetched and deleted, and that invalid ones are rejected
+func TestProgramLibrary(t *testing.T) {
+	fmt.Println("Starting Unit Test for the program library")
+	library := programLibrary.New(t.TempDir())
+
+	if replaced, err := library.Save("counter", "; add one\nREAD 4\nINC\nWRITE 4\n"); err != nil || replaced {
+		t.Fatalf("Expected a new program, got replaced=%v err=%v", replaced, err)
+	}
+	if replaced, err := library.Save("counter", "READ 4\nINC\nINC\nWRITE 4\n"); err != nil || !replaced {
+		t.Fatalf("Expected the program to be replaced, got replaced=%v err=%v", replaced, err)
+	}
+	if _, err := library.Save("broken", "READ 16\n"); err == nil || !strings.Contains(err.Error(), "broken.txt:1:6:") {
+		t.Errorf("Expected the assembler error of the invalid program, got %v", err)
+	}
+	if _, err := library.Save("../escape", "INC\n"); err == nil {
+		t.Errorf("Expected an invalid name to be rejected")
+	}
+
+	programs, err := library.List()
+	if err != nil || len(programs) != 1 || programs[0].Name != "counter" || programs[0].Instructions != 4 {
+		t.Fatalf("Expected only the counter program with 4 instructions, got %+v (%v)", programs, err)
+	}
+	program, err := library.Get("counter")
+	if err != nil || strings.Join(program.Instructions, ";") != "READ 4;INC;INC;WRITE 4" {
+		t.Errorf("Unexpected program %+v (%v)", program, err)
+	}
+
+	if err := library.Delete("counter"); err != nil {
+		t.Errorf("Expected the program to be deleted, got %v", err)
+	}
+	var notFound *programLibrary.NotFoundError
+	if _, err := library.Get("counter"); !errors.As(err, &notFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+
+	// A system with an invalid program is never started
+	cfg := MultiprocessingSystem.DefaultConfig()
+	cfg.Cores = 2
+	cfg.Programs = [][]string{{"INC", "WRITE 2"}, {"READ 2", "WRTE 3"}}
+	cfg.ProgramNames = []string{"writer", "reader"}
+	cfg.Latency = utils.NoLatencies()
+	cfg.LogDirectory = t.TempDir()
+	cfg.Quiet = true
+	if _, err := MultiprocessingSystem.StartWithConfig(cfg); err == nil || !strings.Contains(err.Error(), "reader:2:1:") {
+		t.Errorf("Expected the system to reject the reader program, got %v", err)
+	}
+
+	// Every PE runs the program assigned to it
+	cfg.Programs[1] = []string{"READ 2", "WRITE 3"}
+	mps, err := MultiprocessingSystem.StartWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Expected the system to start, got %v", err)
+	}
+	defer mps.Stop()
+	for id, name := range cfg.ProgramNames {
+		pe := mps.ProcessingElements[id]
+		if pe.Filename != name || strings.Join(pe.Program, ";") != strings.Join(cfg.Programs[id], ";") {
+			t.Errorf("PE%d: expected the program %s, got %s %v", id, name, pe.Filename, pe.Program)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package testing
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"Backend/components/RESTfulAPI"
+)
+
+// Test the counters of a system and the request latencies in the Prometheus text format
+func TestPrometheusMetrics(t *testing.T) {
+	fmt.Println("Starting Unit Test for the Prometheus metrics")
+	server := httptest.NewServer(restfulapi.Router())
+	defer server.Close()
+
+	var created struct{ ID string }
+	if status := request(t, server, "POST", "/v1/systems", `{"cores": 2, "sources": ["WRITE 1\nREAD 2", "READ 1"]}`, &created); status != http.StatusCreated {
+		t.Fatalf("Expected the system to be created, got %d", status)
+	}
+	defer request(t, server, "DELETE", "/v1/systems/"+created.ID, "", nil)
+	if status := request(t, server, "POST", "/v1/systems/"+created.ID+"/run", "", nil); status != http.StatusOK {
+		t.Fatalf("Expected the system to run, got %d", status)
+	}
+	var results struct {
+		MemoryAccesses int
+		ReadRequests   int
+	}
+	request(t, server, "GET", "/v1/systems/"+created.ID+"/metrics", "", &results)
+
+	response, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if !strings.HasPrefix(response.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("Expected the text format, got %s", response.Header.Get("Content-Type"))
+	}
+
+	// Every line is a comment or a sample, the samples of the system are found by their labels
+	sample := regexp.MustCompile(`^([a-z_]+)(\{.*\})? (\S+)$`)
+	session := fmt.Sprintf(`session="%s",protocol="MESI"`, created.ID)
+	accesses, readRequests, runs := 0.0, -1.0, 0.0
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE ") {
+			continue
+		}
+		match := sample.FindStringSubmatch(line)
+		if match == nil {
+			t.Errorf("Expected a sample, got %q", line)
+			continue
+		}
+		value, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			t.Errorf("Expected a number in %q", line)
+		}
+		switch {
+		case (match[1] == "simulator_cache_hits_total" || match[1] == "simulator_cache_misses_total") && strings.Contains(match[2], session):
+			accesses += value
+		case match[1] == "simulator_bus_read_requests_total" && match[2] == "{"+session+"}":
+			readRequests = value
+		case match[1] == "simulator_http_request_duration_seconds_count" && strings.Contains(match[2], `route="/v1/systems/{id}/run"`):
+			runs += value
+		}
+	}
+
+	if int(accesses) != results.MemoryAccesses || results.MemoryAccesses == 0 {
+		t.Errorf("Expected the hits and misses of every core to add up to %d accesses, got %v", results.MemoryAccesses, accesses)
+	}
+	if int(readRequests) != results.ReadRequests {
+		t.Errorf("Expected %d ReadRequests, got %v", results.ReadRequests, readRequests)
+	}
+	if runs < 1 {
+		t.Errorf("Expected the latency of the run request under its route template")
+	}
+}
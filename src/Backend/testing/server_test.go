@@ -0,0 +1,132 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Backend/components/CLI"
+	"Backend/components/RESTfulAPI"
+)
+
+// Test the precedence of the configuration file and the environment, and the invalid configurations
+func TestServerConfig(t *testing.T) {
+	fmt.Println("Starting Unit Test for the server configuration")
+	directory := t.TempDir()
+
+	cfg := restfulapi.DefaultServerConfig()
+	path := writeFile(t, directory, "server.json", `{"address": ":9000", "allowedOrigins": ["http://localhost:3000"], "shutdownTimeout": "3s"}`)
+	if err := cfg.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	environment := map[string]string{"SIMULATOR_ADDRESS": ":9100", "SIMULATOR_IDLE_TIMEOUT": "1m"}
+	lookup := func(name string) (string, bool) {
+		value, found := environment[name]
+		return value, found
+	}
+	if err := cfg.ApplyEnvironment(lookup); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Address != ":9100" || len(cfg.AllowedOrigins) != 1 || cfg.AllowedOrigins[0] != "http://localhost:3000" {
+		t.Errorf("Expected the environment to replace the address of the file, got %+v", cfg)
+	}
+	if cfg.ShutdownTimeout != restfulapi.Duration(3*time.Second) || cfg.IdleTimeout != restfulapi.Duration(time.Minute) {
+		t.Errorf("Expected the timeouts of the file and the environment, got %+v", cfg)
+	}
+	if cfg.LogDirectory != "logs" || cfg.ReadHeaderTimeout != restfulapi.Duration(10*time.Second) {
+		t.Errorf("Expected the defaults for the missing fields, got %+v", cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected a valid configuration, got %v", err)
+	}
+
+	environment["SIMULATOR_READ_TIMEOUT"] = "soon"
+	if err := cfg.ApplyEnvironment(lookup); err == nil {
+		t.Errorf("Expected an error for an invalid duration")
+	}
+	unknown := restfulapi.DefaultServerConfig()
+	if err := unknown.Load(writeFile(t, directory, "unknown.json", `{"port": 8080}`)); err == nil {
+		t.Errorf("Expected an error for an unknown field")
+	}
+	tls := restfulapi.DefaultServerConfig()
+	tls.TLSCertFile = "server.crt"
+	if err := tls.Validate(); err == nil {
+		t.Errorf("Expected an error for a certificate without its key")
+	}
+	if status := cli.Main([]string{"serve", "-tls-cert", "server.crt"}, &bytes.Buffer{}, &bytes.Buffer{}); status != cli.ExitUsage {
+		t.Errorf("Expected the serve command to reject the configuration, got %d", status)
+	}
+}
+
+// Test that the /close route shuts the server down and the simulations write their logs
+func TestServerShutdown(t *testing.T) {
+	fmt.Println("Starting Unit Test for the server shutdown")
+	directory := t.TempDir()
+	cfg := restfulapi.DefaultServerConfig()
+	cfg.Address = "127.0.0.1:0"
+	cfg.LogDirectory = filepath.Join(directory, "logs")
+	cfg.LibraryDirectory = filepath.Join(directory, "programs")
+	cfg.GeneratedDirectory = filepath.Join(directory, "generated")
+	server, err := restfulapi.NewServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The next tests use the folders of the default configuration
+	defer restfulapi.NewServer(restfulapi.DefaultServerConfig())
+
+	listener, err := net.Listen("tcp", cfg.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	url := "http://" + listener.Addr().String()
+
+	response, err := http.Post(url+"/setinitialize", "application/json", bytes.NewBufferString(`{"type": "MESI", "cores": 2, "lastCode": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the system to start, got %d", response.StatusCode)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.GeneratedDirectory, "program0.txt")); err != nil {
+		t.Errorf("Expected the generated programs in the configured folder: %v", err)
+	}
+
+	response, err = http.Post(url+"/close", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected the close request to be accepted, got %d", response.StatusCode)
+	}
+	select {
+	case <-server.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the close request to ask the server to shut down")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Expected the server to end without errors, got %v", err)
+	}
+	for _, folder := range []string{"CC", "PE", "IC", "MM"} {
+		if _, err := os.Stat(filepath.Join(cfg.LogDirectory, folder)); err != nil {
+			t.Errorf("Expected the %s logs in the configured folder: %v", folder, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"Backend/components/Sweep"
+)
+
+// Test the ranges accepted by the sweeps
+func TestSweepParseRange(t *testing.T) {
+	fmt.Println("Starting Unit Test for the sweep ranges")
+	valid := map[string][]int{
+		"3":       {3},
+		"2,4,8":   {2, 4, 8},
+		"1-4":     {1, 2, 3, 4},
+		"1-8:3":   {1, 4, 7},
+		"1-2, 16": {1, 2, 16},
+	}
+	for text, expected := range valid {
+		values, err := sweep.ParseRange(text)
+		if err != nil || !reflect.DeepEqual(values, expected) {
+			t.Errorf("%q: expected %v, got %v (%v)", text, expected, values, err)
+		}
+	}
+	for _, text := range []string{"", "a", "4-2", "1-4:0", "3:2"} {
+		if _, err := sweep.ParseRange(text); err == nil {
+			t.Errorf("%q should be rejected", text)
+		}
+	}
+}
+
+// Test that a sweep runs every combination and seed and aggregates the seeds
+func TestSweep(t *testing.T) {
+	fmt.Println("Starting Unit Test for the parameter sweeps")
+	options := sweep.DefaultOptions()
+	options.Parameters.Cores = []int{2, 3}
+	options.Parameters.CacheLines = []int{2, 4}
+	// 4 ways don't fit in 2 lines, that combination is skipped
+	options.Parameters.CacheWays = []int{1, 4}
+	options.Parameters.Protocols = []string{"MESI"}
+	options.Parameters.Presets = []string{"migratory"}
+	options.Parameters.Seeds = []int64{1, 2, 3}
+	options.Parallel = 4
+
+	report, err := sweep.Run(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 6 || len(report.Runs) != 18 {
+		t.Fatalf("Expected 6 combinations and 18 runs, got %d and %d", len(report.Results), len(report.Runs))
+	}
+	for _, result := range report.Results {
+		if result.Runs != 3 || result.Failures != 0 {
+			t.Errorf("%s: %d runs and %d failures", result.Label(), result.Runs, result.Failures)
+		}
+	}
+
+	// The mean and the standard deviation of the first combination
+	first := report.Runs[:3]
+	mean := float64(first[0].Metrics.CacheMisses+first[1].Metrics.CacheMisses+first[2].Metrics.CacheMisses) / 3
+	squares := 0.0
+	for _, run := range first {
+		squares += math.Pow(float64(run.Metrics.CacheMisses)-mean, 2)
+	}
+	statistic := report.Results[0].Metrics["CacheMisses"]
+	if math.Abs(statistic.Mean-mean) > 1e-9 || math.Abs(statistic.StdDev-math.Sqrt(squares/2)) > 1e-9 {
+		t.Errorf("Expected a mean of %v, got %+v", mean, statistic)
+	}
+
+	// The runs don't depend on the parallelism
+	options.Parallel = 1
+	sequential, err := sweep.Run(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range report.Runs {
+		if !reflect.DeepEqual(report.Runs[i], sequential.Runs[i]) {
+			t.Errorf("The parallel and the sequential sweeps gave different runs: %+v and %+v", report.Runs[i], sequential.Runs[i])
+		}
+	}
+
+	output := &bytes.Buffer{}
+	if err := report.WriteCSV(output); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(output).ReadAll()
+	if err != nil || len(rows) != 7 || rows[0][0] != "cores" || rows[1][1] != "2" || rows[1][2] != "1" {
+		t.Errorf("Unexpected CSV: %v (%v)", rows, err)
+	}
+	output.Reset()
+	report.WriteRunsCSV(output)
+	if rows, _ := csv.NewReader(output).ReadAll(); len(rows) != 19 {
+		t.Errorf("Expected a row for every run, got %d rows", len(rows))
+	}
+
+	options.Parameters.Presets = []string{"unknown"}
+	if _, err := sweep.Run(options); err == nil {
+		t.Errorf("An unknown preset should stop the sweep")
+	}
+}
@@ -0,0 +1,150 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"Backend/components/MultiprocessingSystem"
+	"Backend/components/Trace"
+)
+
+// Test that the text and lackey traces are parsed and folded into the simulated memory
+func TestTraceLoad(t *testing.T) {
+	fmt.Println("Starting Unit Test for the trace loader")
+	text := trace.Source{Format: "text", Text: "# core op address value gap\n0 W 0x13 7\n1 R 19 - 2\n0 r 4\n"}
+	operations, err := trace.Load([]trace.Source{text}, trace.DefaultMapping(), 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := [][]string{{"WRITE 3 7", "READ 4"}, {"IDLE", "IDLE", "READ 3"}}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("Expected %v, got %v", expected, operations)
+	}
+
+	lackey := trace.Source{Format: "lackey", Core: 1, Text: "==123== Lackey\nI  0400a0b0,3\n L 1ffefff0a0,8\n S 1ffefff0a8,8\n M 1ffefff0a0,8\n"}
+	mapping := trace.Mapping{WordSize: 8, Base: 0x1ffefff000, Fold: "first-touch"}
+	operations, err = trace.Load([]trace.Source{lackey}, mapping, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = [][]string{{}, {"READ 0", "WRITE 1", "READ 0", "WRITE 0"}}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("Expected %v, got %v", expected, operations)
+	}
+
+	if _, err := trace.Load([]trace.Source{{Format: "text", Text: "2 R 0\n"}}, trace.DefaultMapping(), 2); err == nil {
+		t.Errorf("Expected an error for a core that doesn't exist")
+	}
+	if _, err := trace.Load([]trace.Source{{Format: "text", Text: "0 X 0\n"}}, trace.DefaultMapping(), 2); err == nil {
+		t.Errorf("Expected an error for an unknown operation")
+	}
+
+	// The addresses outside of the traced region are rejected with their line instead of wrapping around
+	region := trace.Mapping{WordSize: 8, Base: 0x1000, Size: 0x100, Fold: "modulo"}
+	for text, line := range map[string]string{"0 R 0x1000\n0 R 0xfff\n": "line 2", "# region\n0 W 0x10f8\n\n1 R 0x1100\n": "line 4"} {
+		_, err := trace.Load([]trace.Source{{Format: "text", Text: text}}, region, 2)
+		if err == nil || !strings.Contains(err.Error(), line) {
+			t.Errorf("Expected an error in the %s, got %v", line, err)
+		}
+	}
+	if _, err := trace.Load(nil, trace.Mapping{WordSize: 1, Base: 1 << 63, Size: 1 << 63, Fold: "modulo"}, 2); err == nil {
+		t.Errorf("Expected an error for a region beyond the 64 bits addresses")
+	}
+}
+
+// Test that a trace-driven system replays the accesses through the Cache Controllers
+func TestTraceDrivenSystem(t *testing.T) {
+	fmt.Println("Starting Unit Test for the trace-driven mode")
+	source := trace.Source{Format: "text", Text: "0 W 2 5\n1 R 2 - 1\n1 W 3 9\n0 R 3\n"}
+	operations, err := trace.Load([]trace.Source{source}, trace.DefaultMapping(), 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cfg := MultiprocessingSystem.DefaultConfig()
+	cfg.Traces = operations
+	mps, _ := startSystem(t, systemOptions{Config: &cfg, ZeroMemory: true})
+
+	// PE0 writes first, then PE1 reads, writes, and PE0 reads the value of PE1
+	for _, id := range []int{0, 1, 1, 1, 0} {
+		if err := mps.StepAndWait(id); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(mps.ProcessingElements[1].Loads, []int{5}) || !reflect.DeepEqual(mps.ProcessingElements[0].Loads, []int{9}) {
+		t.Errorf("Unexpected loads: PE0 %v, PE1 %v", mps.ProcessingElements[0].Loads, mps.ProcessingElements[1].Loads)
+	}
+	if mps.CoherentValue(2) != 5 || mps.CoherentValue(3) != 9 {
+		t.Errorf("Expected the values 5 and 9, got %d and %d", mps.CoherentValue(2), mps.CoherentValue(3))
+	}
+}
+
+// Test that an exported execution replays to the same memory and loads in the trace-driven mode
+func TestTraceExportReplay(t *testing.T) {
+	fmt.Println("Starting Unit Test for the trace export")
+	cfg := MultiprocessingSystem.DefaultConfig()
+	cfg.Programs = [][]string{
+		{"INC", "WRITE 1", "READ 2", "INC", "INC", "WRITE 2", "READ 1"},
+		{"READ 1", "INC", "WRITE 2", "INC", "WRITE 1", "FENCE", "READ 2"},
+	}
+	original, _ := startSystem(t, systemOptions{Config: &cfg, ZeroMemory: true})
+	for _, id := range []int{0, 1, 1, 0, 0, 1, 0, 1, 1, 0, 1, 0, 1, 0} {
+		if err := original.StepAndWait(id); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	operations, transactions := original.Recorder.Snapshot()
+	if len(operations) != 8 || len(transactions) == 0 {
+		t.Fatalf("Expected 8 memory operations and some bus transactions, got %d and %d", len(operations), len(transactions))
+	}
+
+	// The text and binary exports describe the same trace
+	text, binaryTrace := &bytes.Buffer{}, &bytes.Buffer{}
+	trace.WriteMemoryText(text, operations)
+	trace.WriteMemoryBinary(binaryTrace, operations)
+	fromText, err := trace.ParseText(bytes.NewReader(text.Bytes()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The binary trace has no lines
+	for i := range fromText {
+		fromText[i].Line = 0
+	}
+	fromBinary, err := trace.ReadMemoryBinary(binaryTrace.Bytes())
+	if err != nil || !reflect.DeepEqual(fromText, fromBinary) {
+		t.Fatalf("The binary trace differs from the text trace: %v (%v)", fromBinary, err)
+	}
+
+	busTrace := &bytes.Buffer{}
+	trace.WriteBusBinary(busTrace, transactions)
+	if decoded, err := trace.ReadBusBinary(busTrace.Bytes()); err != nil || !reflect.DeepEqual(decoded, transactions) {
+		t.Errorf("The binary bus trace doesn't round trip: %v (%v)", decoded, err)
+	}
+
+	// Replay the trace in the order of its records
+	replayed, err := trace.Load([]trace.Source{{Format: "binary", Data: binaryTrace.Bytes()}}, trace.DefaultMapping(), 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cfg = MultiprocessingSystem.DefaultConfig()
+	cfg.Traces = replayed
+	replay, _ := startSystem(t, systemOptions{Config: &cfg, ZeroMemory: true})
+	for _, id := range trace.Schedule(fromBinary) {
+		if err := replay.StepAndWait(id); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	for address := 0; address < 16; address++ {
+		if original.CoherentValue(address) != replay.CoherentValue(address) {
+			t.Errorf("Address %d: expected %d, got %d", address, original.CoherentValue(address), replay.CoherentValue(address))
+		}
+	}
+	for id := range original.ProcessingElements {
+		if !reflect.DeepEqual(original.ProcessingElements[id].Loads, replay.ProcessingElements[id].Loads) {
+			t.Errorf("PE%d: expected the loads %v, got %v", id, original.ProcessingElements[id].Loads, replay.ProcessingElements[id].Loads)
+		}
+	}
+}
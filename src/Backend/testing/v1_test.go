@@ -0,0 +1,136 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"Backend/components/RESTfulAPI"
+	"Backend/utils"
+)
+
+// Test the resource API with two systems running side by side
+func TestV1Systems(t *testing.T) {
+	fmt.Println("Starting Unit Test for the v1 resource API")
+	server := httptest.NewServer(restfulapi.Router())
+	defer server.Close()
+
+	// Every system increments the address 2 with its own number of INC
+	ids := []string{}
+	for _, increments := range []string{"INC", `INC\nINC\nINC`} {
+		body := fmt.Sprintf(`{"protocol": "MOESI", "cores": 2, "memory": [0, 0, 10], "sources": ["READ 2\n%s\nWRITE 2", "READ 2"]}`, increments)
+		resource := map[string]interface{}{}
+		if status := request(t, server, "POST", "/v1/systems", body, &resource); status != http.StatusCreated {
+			t.Fatalf("Expected the system to be created, got %d", status)
+		}
+		ids = append(ids, resource["ID"].(string))
+	}
+	defer func() {
+		for _, id := range ids {
+			request(t, server, "DELETE", "/v1/systems/"+id, "", nil)
+		}
+	}()
+
+	// The systems step at the same time without sharing anything
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			run := map[string]interface{}{}
+			if status := request(t, server, "POST", "/v1/systems/"+id+"/run", "", &run); status != http.StatusOK || run["Finished"] != true {
+				t.Errorf("Expected the run of %s to finish, got %d %v", id, status, run)
+			}
+		}(id)
+	}
+	wg.Wait()
+	for i, id := range ids {
+		memory := struct{ Coherent []int }{}
+		request(t, server, "GET", "/v1/systems/"+id+"/memory", "", &memory)
+		if expected := []int{11, 13}[i]; memory.Coherent[2] != expected {
+			t.Errorf("Expected %d at the address 2 of the system %d, got %v", expected, i, memory.Coherent)
+		}
+	}
+
+	// The parts of a system are resources too
+	pe := utils.AboutProcessingElement{}
+	if request(t, server, "GET", "/v1/systems/"+ids[0]+"/pes/0", "", &pe); pe.Status != "Done" || pe.Register != 11 {
+		t.Errorf("Unexpected PE0: %+v", pe)
+	}
+	cache := utils.AboutCacheController{}
+	if request(t, server, "GET", "/v1/systems/"+ids[0]+"/caches/1", "", &cache); cache.ID != 1 || len(cache.Cache) != 4 {
+		t.Errorf("Unexpected CC1: %+v", cache)
+	}
+	transactions := []utils.BusTransaction{}
+	results := utils.MultiprocessingSystemResults{}
+	request(t, server, "GET", "/v1/systems/"+ids[0]+"/bus", "", &transactions)
+	request(t, server, "GET", "/v1/systems/"+ids[0]+"/metrics", "", &results)
+	if len(transactions) == 0 || results.CacheHits+results.CacheMisses != 3 {
+		t.Errorf("Unexpected bus %v and metrics %+v", transactions, results)
+	}
+	list := []map[string]interface{}{}
+	if request(t, server, "GET", "/v1/systems", "", &list); len(list) < 2 {
+		t.Errorf("Expected both systems in the list, got %v", list)
+	}
+
+	// Errors of the requests
+	failures := map[string][3]string{
+		"invalid protocol": {"POST", "/v1/systems", `{"protocol": "MSI"}`},
+		"unknown field":    {"POST", "/v1/systems", `{"protocols": "MESI"}`},
+		"unknown system":   {"GET", "/v1/systems/missing/memory", ""},
+		"unknown PE":       {"GET", "/v1/systems/" + ids[0] + "/pes/7", ""},
+		"finished PE":      {"POST", "/v1/systems/" + ids[0] + "/pes/0/step", ""},
+		"empty buffer":     {"POST", "/v1/systems/" + ids[0] + "/pes/0/drain", `{"entry": 0}`},
+	}
+	expected := map[string]int{"invalid protocol": 400, "unknown field": 400, "unknown system": 404, "unknown PE": 404, "finished PE": 409, "empty buffer": 409}
+	for name, failure := range failures {
+		if status := request(t, server, failure[0], failure[1], failure[2], nil); status != expected[name] {
+			t.Errorf("%s: expected the status %d, got %d", name, expected[name], status)
+		}
+	}
+
+	// The events of a system end when it is deleted
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/systems/" + ids[1] + "/events"
+	connection, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connection.Close()
+	if event := readEvent(t, connection); event.Type != utils.EventSnapshot {
+		t.Errorf("Expected a snapshot of the system, got %+v", event)
+	}
+
+	// A deleted system is gone
+	if status := request(t, server, "DELETE", "/v1/systems/"+ids[1], "", nil); status != http.StatusNoContent {
+		t.Errorf("Expected the system to be deleted, got %d", status)
+	}
+	if status := request(t, server, "GET", "/v1/systems/"+ids[1], "", nil); status != http.StatusNotFound {
+		t.Errorf("Expected the deleted system to be missing, got %d", status)
+	}
+	if _, _, err := connection.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("Expected the stream to close, got %v", err)
+	}
+}
+
+// Function to send a request to the server and decode its JSON answer, it returns the status
+func request(t *testing.T, server *httptest.Server, method string, path string, body string, result interface{}) int {
+	req, _ := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if result != nil && response.StatusCode < 300 {
+		if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+			t.Errorf("%s %s: %v", method, path, err)
+		}
+	}
+	return response.StatusCode
+}
@@ -0,0 +1,90 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"Backend/components/CLI"
+	"Backend/components/MultiprocessingSystem"
+	"Backend/components/Verifier"
+)
+
+// Test that both protocols hold the invariants in every reachable state of caches that fit every block
+func TestVerifierProtocols(t *testing.T) {
+	fmt.Println("Starting Unit Test for the verification of the protocols")
+	for _, protocol := range []string{"MESI", "MOESI"} {
+		options := verifier.DefaultOptions()
+		options.Protocol = protocol
+		report, err := verifier.Run(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Verdict != verifier.VerdictVerified || report.Violation != nil {
+			t.Fatalf("%s: expected the protocol to be verified, got %s with %+v", protocol, report.Verdict, report.Violation)
+		}
+		if report.States < 100 || report.Transitions != report.States*24 || report.Lines != 2 || report.Ways != 2 {
+			t.Errorf("%s: expected every event to be applied to every state, got %+v", protocol, report)
+		}
+	}
+
+	options := verifier.DefaultOptions()
+	options.MaxStates = 10
+	if report, _ := verifier.Run(options); report.Verdict != verifier.VerdictIncomplete || report.States != 10 {
+		t.Errorf("Expected the search to stop at 10 states, got %s with %d", report.Verdict, report.States)
+	}
+	options.Protocol = "MSI"
+	if _, err := verifier.Run(options); err == nil {
+		t.Errorf("Expected an unknown protocol to be rejected")
+	}
+}
+
+// Test that a replacement of a dirty block gives the shortest counterexample
+func TestVerifierCounterexample(t *testing.T) {
+	fmt.Println("Starting Unit Test for the counterexamples of the verifier")
+	options := verifier.DefaultOptions()
+	options.Lines = 1
+	report, err := verifier.Run(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Verdict != verifier.VerdictViolation || report.Violation == nil {
+		t.Fatalf("Expected a violation, got %s", report.Verdict)
+	}
+	trace := report.Violation.Trace
+	if len(trace) != 2 || trace[0].Event.Kind != "WRITE" || trace[0].Event.Value != 1 || len(trace[1].Transactions) != 1 {
+		t.Fatalf("Expected a WRITE and the access that replaces its block, got %+v", trace)
+	}
+	failure := report.Violation.Failures[0]
+	if failure.Invariant != MultiprocessingSystem.InvariantDataValue || failure.Address != trace[0].Event.Address || failure.Expected != 1 || failure.Memory != 0 {
+		t.Errorf("Expected the memory to miss the value written, got %+v", failure)
+	}
+}
+
+// Test the verify command
+func TestVerifyCommand(t *testing.T) {
+	fmt.Println("Starting Unit Test for the verify command")
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if status := cli.Main([]string{"verify", "-protocol", "MOESI", "-blocks", "1", "-caches", "3"}, stdout, stderr); status != cli.ExitSuccess {
+		t.Fatalf("Expected the verification to succeed, got %d: %s", status, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Verdict: verified") {
+		t.Errorf("Expected the verdict in the report, got %s", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	status := cli.Main([]string{"verify", "-lines", "1", "-format", "json"}, stdout, stderr)
+	report := verifier.Report{}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if status != cli.ExitFailure || report.Violation == nil || !strings.Contains(stderr.String(), "DataValue") {
+		t.Errorf("Expected the violation to fail the command, got %d %s", status, stderr.String())
+	}
+	if status := cli.Main([]string{"verify", "-blocks", "0"}, stdout, stderr); status != cli.ExitUsage {
+		t.Errorf("Expected invalid options to be a usage error, got %d", status)
+	}
+}
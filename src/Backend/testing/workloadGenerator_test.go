@@ -0,0 +1,141 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"Backend/components/Assembler"
+	"Backend/components/CLI"
+	"Backend/utils"
+)
+
+// Function to write a generated program in the text format of the PEs
+func workloadSource(program []utils.Instruction) string {
+	lines := []string{}
+	for _, instruction := range program {
+		if instruction.Type == "INC" || instruction.Type == "FENCE" {
+			lines = append(lines, instruction.Type)
+		} else {
+			lines = append(lines, fmt.Sprintf("%s %d", instruction.Type, instruction.Address))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Test that every preset gives valid and repeatable programs with its sharing pattern
+func TestWorkloadGenerator(t *testing.T) {
+	fmt.Println("Starting Unit Test for the workload generator")
+	for _, preset := range utils.WorkloadPresets() {
+		options := utils.DefaultWorkloadOptions(preset[0])
+		options.Instructions = 12
+		options.Seed = 7
+		first, err := utils.GenerateWorkload(4, options)
+		if err != nil {
+			t.Fatalf("%s: %v", preset[0], err)
+		}
+		second, _ := utils.GenerateWorkload(4, options)
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("%s: the same seed gave different programs", preset[0])
+		}
+		for core, program := range first {
+			// The lock iterations are never cut, the program stops at the last whole iteration
+			if preset[0] == "lock-contention" && len(program) <= options.Instructions && len(program) > options.Instructions-13 {
+				continue
+			}
+			if len(program) != options.Instructions {
+				t.Errorf("%s: core %d has %d instructions, expected %d", preset[0], core, len(program), options.Instructions)
+			}
+			if _, err := assembler.Assemble(preset[0], workloadSource(program)); err != nil {
+				t.Errorf("%s: core %d has an invalid program:\n%v", preset[0], core, err)
+			}
+		}
+	}
+
+	// The private-only cores never touch the same address
+	programs, _ := utils.GenerateWorkload(4, utils.DefaultWorkloadOptions("private-only"))
+	owner := map[int]int{}
+	for core, program := range programs {
+		for _, instruction := range program {
+			if instruction.Type == "INC" {
+				continue
+			}
+			if previous, found := owner[instruction.Address]; found && previous != core {
+				t.Errorf("private-only: the address %d is used by cores %d and %d", instruction.Address, previous, core)
+			}
+			owner[instruction.Address] = core
+		}
+	}
+
+	// The false-sharing cores of a group use their own adjacent words of the hot spot
+	options := utils.DefaultWorkloadOptions("false-sharing")
+	options.SharingDegree = 2
+	programs, _ = utils.GenerateWorkload(4, options)
+	for core, program := range programs {
+		for _, instruction := range program {
+			if instruction.Type != "INC" && instruction.Address != core {
+				t.Errorf("false-sharing: core %d uses the address %d", core, instruction.Address)
+			}
+		}
+	}
+
+	// Two lock groups contend on different locks, the retry loop jumps back to its LL
+	options = utils.DefaultWorkloadOptions("lock-contention")
+	options.SharingDegree = 2
+	options.Instructions = 30
+	programs, _ = utils.GenerateWorkload(4, options)
+	if programs[0][0] != programs[1][0] || programs[0][0] == programs[2][0] || programs[0][0].Type != "LL" {
+		t.Errorf("lock-contention: unexpected locks %v %v %v", programs[0][0], programs[1][0], programs[2][0])
+	}
+	for core, program := range programs {
+		lock := program[0].Address
+		if program[1] != (utils.Instruction{Type: "BEQZ", Address: 4}) || program[3] != (utils.Instruction{Type: "BEQZ", Address: 0}) || program[5] != (utils.Instruction{Type: "SC", Address: lock}) {
+			t.Errorf("lock-contention: core %d doesn't start with the acquire loop, got %v", core, program[:7])
+		}
+		// Every iteration ends releasing the lock, so the program does too
+		if release := program[len(program)-1]; release != (utils.Instruction{Type: "WRITE", Address: lock}) || len(program) > options.Instructions {
+			t.Errorf("lock-contention: core %d doesn't end with a whole iteration, got %v", core, program)
+		}
+	}
+	if words := utils.WorkloadInitialMemory(4, options); len(words) != 4 || words[programs[0][0].Address] != 0 || words[programs[2][2].Address] != 0 {
+		t.Errorf("lock-contention: expected the locks and zero words to start at 0, got %v", words)
+	}
+
+	// The 3 words of every lock group must fit in the memory, 6 groups would wrap around into the first ones
+	options.SharingDegree = 1
+	if _, err := utils.GenerateWorkload(6, options); err == nil || options.ValidateFor(5) != nil {
+		t.Errorf("lock-contention: expected only 5 groups to fit in the memory, got %v", err)
+	}
+	options.SharingDegree = 2
+	if err := options.ValidateFor(8); err != nil {
+		t.Errorf("lock-contention: expected 4 groups of 2 cores to fit in the memory, got %v", err)
+	}
+
+	// The cores take the lock in turns and release it at the end
+	for seed := 1; seed <= 3; seed++ {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		status := cli.Main([]string{"run", "-cores", "3", "-workload", "lock-contention", "-instructions", "26", "-seed", fmt.Sprint(seed), "-format", "json"}, stdout, stderr)
+		report := struct{ Memory []int }{}
+		if err := json.Unmarshal(stdout.Bytes(), &report); err != nil || status != cli.ExitSuccess {
+			t.Fatalf("lock-contention: expected the run to finish, got %d %v: %s", status, err, stderr.String())
+		}
+		if report.Memory[0] != 0 {
+			t.Errorf("lock-contention: expected the lock to be free at the end, got %d", report.Memory[0])
+		}
+	}
+
+	// A read ratio of 1 only gives READs
+	options = utils.DefaultWorkloadOptions("read-mostly")
+	options.ReadRatio = 1
+	programs, _ = utils.GenerateWorkload(3, options)
+	for _, program := range programs {
+		for _, instruction := range program {
+			if instruction.Type != "READ" {
+				t.Errorf("read-mostly: expected only READs, got %s", instruction.Type)
+			}
+		}
+	}
+}
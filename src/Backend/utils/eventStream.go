@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"sync"
+)
+
+// Types of the events of the simulation
+const (
+	EventSnapshot          = "snapshot"            // Whole state of the system, sent when a stream starts
+	EventInstructionStart  = "instruction-start"   // A PE took the next instruction of its program
+	EventInstructionFinish = "instruction-finish"  // A PE finished an instruction
+	EventCacheState        = "cache-state"         // A cache block changed its state or its address
+	EventBusTransaction    = "bus-transaction"     // The Interconnect answered a request
+	EventMemoryAccess      = "memory-access"       // The Interconnect read or wrote the Main Memory
+	EventRunFinished       = "run-finished"        // Every PE finished its program
+	EventExecution         = "execution"           // The free-running execution started, paused, resumed, finished or was cancelled
+	EventViolation         = "invariant-violation" // A coherence invariant failed after an access, the system stopped
+)
+
+// Event of the simulation, the sequence numbers grow by one and let a client resume a stream
+type Event struct {
+	Sequence int         `json:"Sequence"`
+	Type     string      `json:"Type"`
+	Data     interface{} `json:"Data"`
+}
+
+// Data of the instruction events
+type InstructionEvent struct {
+	Core        int    `json:"Core"`
+	Position    int    `json:"Position"` // Position of the instruction in the program
+	Instruction string `json:"Instruction"`
+	Register    int    `json:"Register"`
+	Status      string `json:"Status"`
+}
+
+// Data of a cache state event, the previous address and state of the block
+type CacheStateEvent struct {
+	Cache           int    `json:"Cache"`
+	Block           int    `json:"Block"`
+	Address         int    `json:"Address"`
+	Data            int    `json:"Data"`
+	State           string `json:"State"`
+	PreviousAddress int    `json:"PreviousAddress"`
+	PreviousState   string `json:"PreviousState"`
+}
+
+// Data of a memory access event
+type MemoryAccessEvent struct {
+	Address int  `json:"Address"`
+	Value   int  `json:"Value"`
+	Write   bool `json:"Write"`
+}
+
+// Stream of events shared by the components, it keeps the last events so the clients can resume
+type EventStream struct {
+	mutex       sync.Mutex
+	capacity    int
+	events      []Event // Last events, the oldest first
+	next        int     // Sequence of the next event
+	subscribers map[int]chan Event
+	nextID      int
+	closed      bool
+}
+
+// Events a slow subscriber can fall behind before it is dropped
+const subscriberBuffer = 256
+
+// NewEventStream creates a stream that keeps the last capacity events.
+func NewEventStream(capacity int) *EventStream {
+	return &EventStream{capacity: capacity, next: 1, subscribers: map[int]chan Event{}}
+}
+
+// Function to send an event to every subscriber, a subscriber that can't keep up is dropped
+// and its channel closed so the client can resume from its last event
+func (stream *EventStream) Publish(kind string, data interface{}) {
+	if stream == nil {
+		return
+	}
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	if stream.closed {
+		return
+	}
+	event := Event{Sequence: stream.next, Type: kind, Data: data}
+	stream.next++
+	stream.events = append(stream.events, event)
+	if len(stream.events) > stream.capacity {
+		stream.events = stream.events[len(stream.events)-stream.capacity:]
+	}
+	for id, channel := range stream.subscribers {
+		select {
+		case channel <- event:
+		default:
+			close(channel)
+			delete(stream.subscribers, id)
+		}
+	}
+}
+
+// Function to get the sequence of the last event, 0 before the first one
+func (stream *EventStream) Last() int {
+	if stream == nil {
+		return 0
+	}
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	return stream.next - 1
+}
+
+// Function to subscribe to the events after a sequence number. It returns the kept events after it,
+// the channel of the new events, the function that ends the subscription and false when some events
+// after the sequence are no longer kept.
+func (stream *EventStream) Subscribe(after int) ([]Event, <-chan Event, func(), bool) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	backlog := []Event{}
+	for _, event := range stream.events {
+		if event.Sequence > after {
+			backlog = append(backlog, event)
+		}
+	}
+	complete := after >= stream.next-1 || (len(backlog) > 0 && backlog[0].Sequence == after+1)
+
+	id := stream.nextID
+	stream.nextID++
+	channel := make(chan Event, subscriberBuffer)
+	if stream.closed {
+		close(channel)
+		return backlog, channel, func() {}, complete
+	}
+	stream.subscribers[id] = channel
+	cancel := func() {
+		stream.mutex.Lock()
+		defer stream.mutex.Unlock()
+		if _, found := stream.subscribers[id]; found {
+			close(channel)
+			delete(stream.subscribers, id)
+		}
+	}
+	return backlog, channel, cancel, complete
+}
+
+// Function to end the stream, the channels of the subscribers are closed and no more events are published
+func (stream *EventStream) Close() {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	stream.closed = true
+	for id, channel := range stream.subscribers {
+		close(channel)
+		delete(stream.subscribers, id)
+	}
+}
+
+// Function to know if the stream ended, otherwise a closed channel means the subscriber fell behind
+func (stream *EventStream) Closed() bool {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	return stream.closed
+}
@@ -1,6 +1,11 @@
 package utils
 
-import ()
+import (
+	"time"
+)
+
+// Number of words in the Main Memory, the valid addresses go from 0 to MemorySize-1
+const MemorySize = 16
 
 // Request structure for the PE - CacheController communication
 type RequestProcessingElement struct {
@@ -42,6 +47,12 @@ type ResponseBroadcast struct {
     Data int 
 }
 
+// Store waiting in the store buffer of a Processing Element
+type StoreBufferEntry struct {
+    Address int    // The address to WRITE to
+    Data    int    // The data to store
+}
+
 // Request structure for the Interconnect - Main Memory communication
 type RequestMainMemory struct {
 	Type    string // WRITE or READ operation
@@ -87,6 +98,16 @@ func (q *Queue) Size() int {
 	return len(q.items)
 }
 
+// Items returns a copy of the items in the queue, from the front to the end.
+func (q *Queue) Items() []int {
+	return append([]int{}, q.items...)
+}
+
+// NewQueue creates a queue with the items given, from the front to the end.
+func NewQueue(items []int) Queue {
+	return Queue{items: append([]int{}, items...)}
+}
+
 // Queue Struct for string ******************************************************************************************************************
 type QueueS struct {
 	Items []string
@@ -118,3 +139,28 @@ func (q *QueueS) Size() int {
 }
 
 // Queue Struct for int ******************************************************************************************************************
+
+// Simulated time costs of the components **************************************************************************************
+type Latencies struct {
+	CacheToInterconnect      time.Duration // Time a Cache Controller waits before using the bus
+	CacheToProcessingElement time.Duration // Time a Cache Controller takes to answer its Processing Element
+	BusTransaction           time.Duration // Time the Interconnect takes to serve a request
+	MemoryRead               time.Duration // Time the Main Memory takes to serve a READ
+	MemoryWrite              time.Duration // Time the Main Memory takes to serve a WRITE
+}
+
+// DefaultLatencies returns the time costs used by the interactive simulation.
+func DefaultLatencies() Latencies {
+	return Latencies{
+		CacheToInterconnect:      2 * time.Second,
+		CacheToProcessingElement: time.Second,
+		BusTransaction:           3 * time.Second,
+		MemoryRead:               3 * time.Second,
+		MemoryWrite:              5 * time.Second,
+	}
+}
+
+// NoLatencies returns zero time costs, used by batch runs that only care about the results.
+func NoLatencies() Latencies {
+	return Latencies{}
+}
@@ -7,11 +7,20 @@ type InstructionObject struct {
 }
 type InstructionObjectList [] InstructionObject
 
+type StoreBufferObject struct {
+	Position	int	`json:"Position"`
+	Address		int	`json:"Address"`
+	Data		int	`json:"Data"`
+}
+type StoreBufferObjectList [] StoreBufferObject
+
 type AboutProcessingElement struct {
 	ID          int       `json:"ID"`
 	Register    int       `json:"Register"`
 	Status      string    `json:"Status"`
 	Instructions InstructionObjectList `json:"Instructions"`
+	Consistency string    `json:"Consistency"`
+	StoreBuffer StoreBufferObjectList `json:"StoreBuffer"`
 }
 
 type AboutProcessingElementList [] AboutProcessingElement
@@ -74,6 +83,142 @@ type MultiprocessingSystemState struct {
 	CCs AboutCacheControllerList `json:"CCs"`
 	IC AboutInterconnect `json:"IC"`
 	MM AboutMainMemory
+	Execution ExecutionState `json:"Execution"`
+}
+
+// Object Structure for the free-running execution of the PEs
+type ExecutionState struct {
+	State            string         `json:"State"`            // Idle, Running, Paused, Finished or Cancelled
+	Instructions     int            `json:"Instructions"`     // Instructions executed since the execution started
+	Cycles           int            `json:"Cycles"`           // Rounds in which every PE that hadn't finished executed an instruction
+	InstructionLimit int            `json:"InstructionLimit"` // The execution pauses when it reaches this many instructions, 0 without limit
+	CycleLimit       int            `json:"CycleLimit"`       // The execution pauses when it reaches this many cycles, 0 without limit
+	Breakpoint       *BreakpointHit `json:"Breakpoint"`       // Breakpoint that paused the execution, nil otherwise
+}
+
+// Object Structure for a breakpoint of the free-running execution
+type Breakpoint struct {
+	ID          int    `json:"ID"`
+	Kind        string `json:"Kind"`        // position, instruction, watch or bus
+	Core        int    `json:"Core"`        // PE of a position or instruction breakpoint, -1 for every PE
+	Position    int    `json:"Position"`    // Position of the instruction in the program
+	Instruction string `json:"Instruction"` // Type of instruction: READ, WRITE, INC, LL, SC, FENCE, BEQZ or IDLE
+	Address     int    `json:"Address"`     // Address of a watchpoint, or of a bus transaction where -1 is any address
+	Access      string `json:"Access"`      // Access of a watchpoint: read, write or state
+	Transaction string `json:"Transaction"` // ReadRequest, ReadExclusiveRequest, DataResponse or Invalidate
+	Hits        int    `json:"Hits"`        // Times the breakpoint paused the execution
+}
+
+// Object Structure for the breakpoint that paused an execution and what triggered it
+type BreakpointHit struct {
+	Breakpoint  Breakpoint `json:"Breakpoint"`
+	Core        int        `json:"Core"`     // PE whose instruction triggered the breakpoint
+	Position    int        `json:"Position"` // Position of that instruction
+	Instruction string     `json:"Instruction"`
+	Detail      string     `json:"Detail"` // Access, state change or transaction found
+}
+
+// Object Structure for an access of a PE served by its Cache Controller, checked by the coherence invariant checker
+type CacheAccess struct {
+	Cache   int    `json:"Cache"` // Cache Controller that served the access
+	Kind    string `json:"Kind"`  // READ, WRITE, LL or SC
+	Address int    `json:"Address"`
+	Value   int    `json:"Value"` // Value stored by a write
+	Write   bool   `json:"Write"` // The access stored a value, a SC without reservation doesn't
+}
+
+// Object Structure for a coherence invariant that doesn't hold for an address
+type InvariantFailure struct {
+	Invariant string   `json:"Invariant"` // SWMR, SingleOwner, ExclusiveNoSharers or DataValue
+	Address   int      `json:"Address"`
+	Detail    string   `json:"Detail"`
+	Copies    []string `json:"Copies"`   // State and data of the address in every cache that holds it, like CC0: M 5
+	Memory    int      `json:"Memory"`   // Value of the address in the Main Memory
+	Expected  int      `json:"Expected"` // Value of the last store to the address, kept by the shadow memory
+}
+
+// Object Structure for the report of the coherence invariants that failed after an access
+type InvariantViolation struct {
+	Access       CacheAccess        `json:"Access"`       // Access after which the invariants failed
+	Transactions []BusTransaction   `json:"Transactions"` // Bus transactions of the access
+	Failures     []InvariantFailure `json:"Failures"`
+}
+
+// Object Structure for a PE at a step of the history
+type HistoryProcessingElement struct {
+	ID          int                   `json:"ID"`
+	Register    int                   `json:"Register"`
+	PC          int                   `json:"PC"`
+	Done        bool                  `json:"Done"`
+	StoreBuffer StoreBufferObjectList `json:"StoreBuffer"`
+}
+
+// Object Structure for a cache block at a step of the history
+type HistoryBlock struct {
+	Cache   int    `json:"Cache"` // Cache Controller of the block
+	Block   int    `json:"Block"`
+	Address int    `json:"Address"`
+	Data    int    `json:"Data"`
+	State   string `json:"State"`
+}
+
+// Object Structure for the registers, the caches and the memory at a step of the history
+type HistoryState struct {
+	Step   int                        `json:"Step"`
+	PEs    []HistoryProcessingElement `json:"PEs"`
+	Blocks []HistoryBlock             `json:"Blocks"` // Every block of every cache, in order
+	Memory BlockObjectList            `json:"Memory"`
+}
+
+// Object Structure for a step of the history with what it changed
+type HistoryStep struct {
+	Step        int                        `json:"Step"`
+	Core        int                        `json:"Core"`        // PE that executed the step
+	Position    int                        `json:"Position"`    // Position of the instruction in the program
+	Instruction string                     `json:"Instruction"` // Instruction executed, or the store drained from the store buffer
+	PEs         []HistoryProcessingElement `json:"PEs"`         // New state of the PEs that changed
+	Blocks      []HistoryBlock             `json:"Blocks"`      // New state of the cache blocks that changed
+	Memory      BlockObjectList            `json:"Memory"`      // New value of the addresses that changed
+}
+
+// Object Structure for a difference between two steps of the history
+type HistoryChange struct {
+	Component string `json:"Component"` // PE0, CC1 or MM
+	Field     string `json:"Field"`     // Register, PC, Done, StoreBuffer, Block 2 or Address 5
+	Before    string `json:"Before"`
+	After     string `json:"After"`
+}
+
+// Object Structure for the differences between two steps of the history
+type HistoryDiff struct {
+	From    int             `json:"From"`
+	To      int             `json:"To"`
+	Changes []HistoryChange `json:"Changes"`
+}
+
+// Object Structure for the misses of every kind, the coherence misses are split in true and false sharing
+type MissCounts struct {
+	Compulsory   int `json:"Compulsory"`   // First access of the cache to the block
+	Capacity     int `json:"Capacity"`     // The block was replaced and a fully associative cache of the same size would have replaced it too
+	Conflict     int `json:"Conflict"`     // The block was replaced but a fully associative cache of the same size would still hold it
+	Coherence    int `json:"Coherence"`    // The block was invalidated by another cache, or a write had to upgrade a shared copy
+	TrueSharing  int `json:"TrueSharing"`  // Coherence misses for a word that another cache used
+	FalseSharing int `json:"FalseSharing"` // Coherence misses caused only by other words of the same block, 0 with blocks of one word
+}
+
+// Object Structure for the misses of an address
+type AddressMisses struct {
+	Address int `json:"Address"`
+	MissCounts
+}
+
+// Object Structure for the hits and the classified misses of a cache
+type CacheMisses struct {
+	Cache     int             `json:"Cache"`
+	Hits      int             `json:"Hits"`
+	Misses    int             `json:"Misses"`
+	Addresses []AddressMisses `json:"Addresses"` // Addresses with misses, in order
+	MissCounts
 }
 
 // Object Structure for executio results
@@ -91,4 +236,9 @@ type MultiprocessingSystemResults struct {
 	Invalidates				int			`json:"Invalidates"`
 	MemoryReads				int			`json:"MemoryReads"`
 	MemoryWrites			int			`json:"MemoryWrites"`
+	SCSuccesses				int			`json:"SCSuccesses"`
+	SCFailures				int			`json:"SCFailures"`
+	Misses					MissCounts		`json:"Misses"`			// Misses of every kind of all the caches
+	CacheMissCounts			[]CacheMisses	`json:"CacheMissCounts"`	// Hits and misses of every cache and its addresses
+	AddressMissCounts		[]AddressMisses	`json:"AddressMissCounts"`	// Misses of every address in all the caches
 }
\ No newline at end of file
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"sync"
+)
+
+// Memory operation performed by a Processing Element, in the order the operations reach the caches
+type MemoryOperation struct {
+	Sequence  int    `json:"Sequence"`  // Position of the operation in the execution
+	Core      int    `json:"Core"`      // PE that performed the operation
+	Kind      string `json:"Kind"`      // READ, WRITE, LL or SC
+	Write     bool   `json:"Write"`     // The operation stored a value
+	Address   int    `json:"Address"`   // Address of the operation
+	Value     int    `json:"Value"`     // Value read or written
+	Gap       int    `json:"Gap"`       // Steps of the PE without memory operations since its previous operation
+	Forwarded bool   `json:"Forwarded"` // A READ served by the store buffer, it never reached the cache
+}
+
+// Bus transaction served by the Interconnect
+type BusTransaction struct {
+	Sequence     int    `json:"Sequence"`     // Position of the transaction in the execution
+	Requester    int    `json:"Requester"`    // Cache Controller that used the bus
+	Type         string `json:"Type"`         // ReadRequest or ReadExclusiveRequest
+	AR           string `json:"AR"`           // Action required: DataResponse or Invalidate
+	Address      int    `json:"Address"`      // Address of the transaction
+	Data         int    `json:"Data"`         // Data sent back to the requester
+	RemoteStatus string `json:"RemoteStatus"` // Best state found in the other caches, I when no cache had the address
+	NewStatus    string `json:"NewStatus"`    // State given to the requester
+	MemoryReads  int    `json:"MemoryReads"`  // Reads of the Main Memory caused by the transaction
+	MemoryWrites int    `json:"MemoryWrites"` // Writes to the Main Memory (flushes) caused by the transaction
+}
+
+// Recorder shared by the PEs and the Interconnect to keep the execution as traces
+type TraceRecorder struct {
+	mutex            sync.Mutex
+	gaps             map[int]int
+	MemoryOperations []MemoryOperation
+	BusTransactions  []BusTransaction
+}
+
+// NewTraceRecorder creates an empty recorder.
+func NewTraceRecorder() *TraceRecorder {
+	return &TraceRecorder{gaps: map[int]int{}, MemoryOperations: []MemoryOperation{}, BusTransactions: []BusTransaction{}}
+}
+
+// Function to count a step of a PE that didn't perform a memory operation
+func (recorder *TraceRecorder) RecordStep(core int) {
+	if recorder == nil {
+		return
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.gaps[core]++
+}
+
+// Function to add a memory operation, its sequence number and gap are filled by the recorder
+func (recorder *TraceRecorder) RecordMemoryOperation(operation MemoryOperation) {
+	if recorder == nil {
+		return
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	operation.Sequence = len(recorder.MemoryOperations)
+	operation.Gap = recorder.gaps[operation.Core]
+	recorder.gaps[operation.Core] = 0
+	recorder.MemoryOperations = append(recorder.MemoryOperations, operation)
+}
+
+// Function to add a bus transaction, its sequence number is filled by the recorder and the transaction returned
+func (recorder *TraceRecorder) RecordBusTransaction(transaction BusTransaction) BusTransaction {
+	if recorder == nil {
+		return transaction
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	transaction.Sequence = len(recorder.BusTransactions)
+	recorder.BusTransactions = append(recorder.BusTransactions, transaction)
+	return transaction
+}
+
+// Function to get a copy of the recorded memory operations and bus transactions
+func (recorder *TraceRecorder) Snapshot() ([]MemoryOperation, []BusTransaction) {
+	if recorder == nil {
+		return []MemoryOperation{}, []BusTransaction{}
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]MemoryOperation{}, recorder.MemoryOperations...), append([]BusTransaction{}, recorder.BusTransactions...)
+}
+
+// Function to get how many memory operations and bus transactions were recorded
+func (recorder *TraceRecorder) Lengths() (int, int) {
+	if recorder == nil {
+		return 0, 0
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return len(recorder.MemoryOperations), len(recorder.BusTransactions)
+}
+
+// Function to get a copy of the memory operations and bus transactions recorded after the lengths given
+func (recorder *TraceRecorder) Since(operations int, transactions int) ([]MemoryOperation, []BusTransaction) {
+	if recorder == nil {
+		return []MemoryOperation{}, []BusTransaction{}
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]MemoryOperation{}, recorder.MemoryOperations[operations:]...), append([]BusTransaction{}, recorder.BusTransactions[transactions:]...)
+}
+
+// Everything kept by a recorder, to save it with a checkpoint and restore it later
+type RecorderState struct {
+	MemoryOperations []MemoryOperation `json:"MemoryOperations"`
+	BusTransactions  []BusTransaction  `json:"BusTransactions"`
+	Gaps             map[int]int       `json:"Gaps"` // Steps without memory operations of every PE since its last one
+}
+
+// Function to get a copy of everything the recorder keeps
+func (recorder *TraceRecorder) State() RecorderState {
+	state := RecorderState{}
+	state.MemoryOperations, state.BusTransactions = recorder.Snapshot()
+	state.Gaps = recorder.Gaps()
+	return state
+}
+
+// Function to get a copy of the steps without memory operations of every PE since its last one
+func (recorder *TraceRecorder) Gaps() map[int]int {
+	gaps := map[int]int{}
+	if recorder == nil {
+		return gaps
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	for core, gap := range recorder.gaps {
+		gaps[core] = gap
+	}
+	return gaps
+}
+
+// RestoreTraceRecorder creates a recorder that goes on from a saved state.
+func RestoreTraceRecorder(state RecorderState) *TraceRecorder {
+	recorder := NewTraceRecorder()
+	recorder.MemoryOperations = append(recorder.MemoryOperations, state.MemoryOperations...)
+	recorder.BusTransactions = append(recorder.BusTransactions, state.BusTransactions...)
+	for core, gap := range state.Gaps {
+		recorder.gaps[core] = gap
+	}
+	return recorder
+}
@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Sharing patterns of the workload generator
+var workloadPresets = map[string]string{
+	"uniform":           "INC, READ and WRITE picked uniformly over the whole memory",
+	"private-only":      "Every core only touches its own words, there is no coherence traffic after the first misses",
+	"producer-consumer": "The first core of every group writes a buffer that the other cores of the group read",
+	"migratory":         "Every core reads, increments and writes the same shared words in turns",
+	"read-mostly":       "A shared table is read by every core of the group and written rarely",
+	"false-sharing":     "Every core writes its own word, the words of a group are adjacent in a hot spot",
+	"lock-contention":   "The cores of a group take a LL/SC spin lock, update the data it protects and release it",
+}
+
+// Parameters of a synthetic workload
+type WorkloadOptions struct {
+	Preset        string  `json:"Preset"`        // One of the presets of WorkloadPresets
+	Instructions  int     `json:"Instructions"`  // Number of instructions of every program
+	Seed          int64   `json:"Seed"`          // Seed of the generator, the same seed gives the same programs
+	ReadRatio     float64 `json:"ReadRatio"`     // Fraction of the memory operations that are READs
+	SharingDegree int     `json:"SharingDegree"` // Number of cores that share the same locations, 0 shares them with every core
+	Locality      float64 `json:"Locality"`      // Probability of using again the last address
+}
+
+// Function to get the names and descriptions of the presets, sorted by name
+func WorkloadPresets() [][2]string {
+	presets := [][2]string{}
+	for name, description := range workloadPresets {
+		presets = append(presets, [2]string{name, description})
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i][0] < presets[j][0] })
+	return presets
+}
+
+// Function to get the options of a preset with its usual read ratio, sharing degree and locality
+func DefaultWorkloadOptions(preset string) WorkloadOptions {
+	options := WorkloadOptions{Preset: preset, Instructions: 8, Seed: 1, ReadRatio: 0.5, SharingDegree: 0, Locality: 0.3}
+	switch preset {
+	case "producer-consumer":
+		options.ReadRatio = 0.8
+	case "migratory":
+		options.Locality = 0.5
+	case "read-mostly":
+		options.ReadRatio = 0.95
+	case "lock-contention":
+		options.Locality = 0
+	}
+	return options
+}
+
+// Function to check that the options describe a workload the generator can build
+func (options WorkloadOptions) Validate() error {
+	if _, found := workloadPresets[options.Preset]; !found {
+		return fmt.Errorf("unknown workload preset %q", options.Preset)
+	}
+	if options.Instructions < 0 {
+		return fmt.Errorf("the number of instructions can't be negative")
+	}
+	if options.ReadRatio < 0 || options.ReadRatio > 1 {
+		return fmt.Errorf("the read ratio must be between 0 and 1")
+	}
+	if options.Locality < 0 || options.Locality > 1 {
+		return fmt.Errorf("the locality must be between 0 and 1")
+	}
+	if options.SharingDegree < 0 {
+		return fmt.Errorf("the sharing degree can't be negative")
+	}
+	return nil
+}
+
+// Function to check that the options describe a workload the generator can build for a number of cores
+func (options WorkloadOptions) ValidateFor(cores int) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	// Every lock has its own data and zero words, the words of two groups can't wrap around into each other
+	if groups := workloadGroups(cores, options.SharingDegree); options.Preset == "lock-contention" && groups*3 > MemorySize {
+		return fmt.Errorf("lock-contention needs 3 words for each of its %d groups, the memory only has %d words", groups, MemorySize)
+	}
+	return nil
+}
+
+// Addresses given to every core: the ones shared with its group and its private ones
+type workloadLayout struct {
+	Group   []int   // Group of every core
+	Leader  []bool  // The first core of every group (the producer in producer-consumer)
+	Shared  [][]int // Shared words of every group
+	Private [][]int // Private words of every core
+}
+
+// Function to get the number of groups of cores that share the same locations
+func workloadGroups(cores int, degree int) int {
+	if degree <= 0 || degree > cores {
+		degree = cores
+	}
+	return (cores + degree - 1) / degree
+}
+
+// Function to split the memory between the groups and the cores
+func newWorkloadLayout(cores int, degree int, sharedWords int) workloadLayout {
+	if degree <= 0 || degree > cores {
+		degree = cores
+	}
+	groups := workloadGroups(cores, degree)
+	layout := workloadLayout{Shared: make([][]int, groups), Private: make([][]int, cores)}
+
+	// The shared words go first, the rest of the memory is split between the cores
+	next := 0
+	for group := 0; group < groups; group++ {
+		for i := 0; i < sharedWords; i++ {
+			layout.Shared[group] = append(layout.Shared[group], next%MemorySize)
+			next++
+		}
+	}
+	free := MemorySize - next
+	for core := 0; core < cores; core++ {
+		layout.Group = append(layout.Group, core/degree)
+		layout.Leader = append(layout.Leader, core%degree == 0)
+		words := 1
+		if free >= cores {
+			words = free / cores
+		}
+		for i := 0; i < words; i++ {
+			// Without enough memory the private words wrap around
+			layout.Private[core] = append(layout.Private[core], (next+core*words+i)%MemorySize)
+		}
+	}
+	return layout
+}
+
+// GenerateWorkload builds a program for every core following a sharing pattern.
+func GenerateWorkload(cores int, options WorkloadOptions) ([][]Instruction, error) {
+	if err := options.ValidateFor(cores); err != nil {
+		return nil, err
+	}
+	generator := rand.New(rand.NewSource(options.Seed))
+	programs := make([][]Instruction, cores)
+
+	// Keep an address with the probability of the locality, or pick a new one
+	pick := func(last int, addresses []int) int {
+		if last >= 0 && generator.Float64() < options.Locality {
+			return last
+		}
+		return addresses[generator.Intn(len(addresses))]
+	}
+	memoryOperation := func(readRatio float64) string {
+		if generator.Float64() < readRatio {
+			return "READ"
+		}
+		return "WRITE"
+	}
+
+	switch options.Preset {
+	case "uniform":
+		all := []int{}
+		for address := 0; address < MemorySize; address++ {
+			all = append(all, address)
+		}
+		for core := 0; core < cores; core++ {
+			last := -1
+			for i := 0; i < options.Instructions; i++ {
+				if generator.Intn(3) == 0 {
+					programs[core] = append(programs[core], Instruction{Type: "INC"})
+					continue
+				}
+				last = pick(last, all)
+				programs[core] = append(programs[core], Instruction{Type: memoryOperation(options.ReadRatio), Address: last})
+			}
+		}
+
+	case "private-only":
+		layout := newWorkloadLayout(cores, 1, 0)
+		for core := 0; core < cores; core++ {
+			last := -1
+			for len(programs[core]) < options.Instructions {
+				last = pick(last, layout.Private[core])
+				programs[core] = appendAccess(programs[core], memoryOperation(options.ReadRatio), last)
+			}
+		}
+
+	case "producer-consumer":
+		layout := newWorkloadLayout(cores, options.SharingDegree, 2)
+		for core := 0; core < cores; core++ {
+			buffer := layout.Shared[layout.Group[core]]
+			// The producer writes as often as the consumers read
+			readRatio := options.ReadRatio
+			if layout.Leader[core] {
+				readRatio = 1 - options.ReadRatio
+			}
+			last := -1
+			for len(programs[core]) < options.Instructions {
+				last = pick(last, buffer)
+				programs[core] = appendAccess(programs[core], memoryOperation(readRatio), last)
+			}
+		}
+
+	case "migratory":
+		layout := newWorkloadLayout(cores, options.SharingDegree, 2)
+		for core := 0; core < cores; core++ {
+			object := layout.Shared[layout.Group[core]]
+			last := -1
+			for len(programs[core]) < options.Instructions {
+				// Read-modify-write of the shared object
+				last = pick(last, object)
+				programs[core] = append(programs[core], Instruction{Type: "READ", Address: last}, Instruction{Type: "INC"}, Instruction{Type: "WRITE", Address: last})
+			}
+		}
+
+	case "read-mostly":
+		layout := newWorkloadLayout(cores, options.SharingDegree, 4)
+		for core := 0; core < cores; core++ {
+			table := layout.Shared[layout.Group[core]]
+			last := -1
+			for len(programs[core]) < options.Instructions {
+				last = pick(last, table)
+				programs[core] = appendAccess(programs[core], memoryOperation(options.ReadRatio), last)
+			}
+		}
+
+	case "false-sharing":
+		// The words of a group are adjacent, every core only uses its own word of the hot spot.
+		// Blocks of several words would be shared by the cores of a group, every coherence miss would be
+		// false sharing. With the blocks of one word of the caches the words never share a block, so the
+		// cores only get compulsory misses and the miss classification reports no false sharing.
+		degree := options.SharingDegree
+		if degree <= 0 || degree > cores {
+			degree = cores
+		}
+		layout := newWorkloadLayout(cores, degree, degree)
+		for core := 0; core < cores; core++ {
+			word := layout.Shared[layout.Group[core]][core%degree]
+			for len(programs[core]) < options.Instructions {
+				programs[core] = appendAccess(programs[core], memoryOperation(options.ReadRatio), word)
+			}
+		}
+
+	case "lock-contention":
+		layout := newWorkloadLayout(cores, options.SharingDegree, 3)
+		for core := 0; core < cores; core++ {
+			lock, data, zero := layout.Shared[layout.Group[core]][0], layout.Shared[layout.Group[core]][1], layout.Shared[layout.Group[core]][2]
+			// Whole iterations only, the jumps of a cut iteration would leave the lock taken
+			for options.Instructions > 0 {
+				iteration := lockIteration(len(programs[core]), lock, zero)
+				for i := 0; i < 2; i++ {
+					iteration = appendAccess(iteration, memoryOperation(options.ReadRatio), data)
+				}
+				// Release: store the 0 of the zero word in the lock
+				iteration = append(iteration, Instruction{Type: "READ", Address: zero}, Instruction{Type: "WRITE", Address: lock})
+				if len(programs[core]) > 0 && len(programs[core])+len(iteration) > options.Instructions {
+					break
+				}
+				programs[core] = append(programs[core], iteration...)
+			}
+		}
+	}
+
+	// The last access may go beyond the requested size, the lock iterations are never cut
+	for core := range programs {
+		if len(programs[core]) > options.Instructions && options.Preset != "lock-contention" {
+			programs[core] = programs[core][:options.Instructions]
+		}
+	}
+	return programs, nil
+}
+
+// Function to build the acquire of a spin lock that is free at 0, starting at the position start of the program.
+// The zero word always holds 0, reading it makes the next BEQZ an unconditional jump.
+func lockIteration(start int, lock int, zero int) []Instruction {
+	retry, take := start, start+4
+	return []Instruction{
+		// retry: a free lock is taken, a busy one is read again
+		{Type: "LL", Address: lock},
+		{Type: "BEQZ", Address: take},
+		{Type: "READ", Address: zero},
+		{Type: "BEQZ", Address: retry},
+		// take: store 1 in the lock, the SC fails when another core wrote the lock since the LL
+		{Type: "INC"},
+		{Type: "SC", Address: lock},
+		{Type: "BEQZ", Address: retry},
+	}
+}
+
+// Function to get the words a workload needs with a known value before it starts, the locks and zero words start at 0
+func WorkloadInitialMemory(cores int, options WorkloadOptions) map[int]uint32 {
+	words := map[int]uint32{}
+	if options.Preset == "lock-contention" {
+		layout := newWorkloadLayout(cores, options.SharingDegree, 3)
+		for _, shared := range layout.Shared {
+			words[shared[0]] = 0
+			words[shared[2]] = 0
+		}
+	}
+	return words
+}
+
+// Function to add a memory access, the WRITEs store a new value
+func appendAccess(program []Instruction, operation string, address int) []Instruction {
+	if operation == "WRITE" {
+		program = append(program, Instruction{Type: "INC"})
+	}
+	return append(program, Instruction{Type: operation, Address: address})
+}